@@ -5,11 +5,11 @@
 // ---------------------------------------------------------------------------
 document.addEventListener('alpine:init', () => {
   // ---------------------------------------------------------------------------
-  // Alpine.data('filtro') — filtro de estudiantes por grado y búsqueda
+  // Alpine.data('filtro') — filtro de estudiantes por grado (la búsqueda por
+  // nombre o código la hace el servidor y reemplaza las filas)
   // ---------------------------------------------------------------------------
   Alpine.data('filtro', (gradosData) => ({
     grado: 'Todos',
-    search: '',
     visibles: 0,
     grados: gradosData,
 
@@ -18,29 +18,74 @@ document.addEventListener('alpine:init', () => {
     },
 
     matchFila(el) {
-      const gradoEl = el.dataset.grado || '';
-      const nombreEl = (el.dataset.nombre || '').toLowerCase();
-      const matchGrado = this.grado === 'Todos' || gradoEl === this.grado;
-      const matchSearch = this.search === '' || nombreEl.includes(this.search.toLowerCase());
-      return matchGrado && matchSearch;
+      return this.grado === 'Todos' || (el.dataset.grado || '') === this.grado;
     },
 
     contarVisibles() {
       this.$nextTick(() => {
         const filas = this.$el.querySelectorAll('#estudiantes-container .estudiante-fila');
-        let count = 0;
-        filas.forEach(fila => {
-          const gradoEl = fila.dataset.grado || '';
-          const nombreEl = (fila.dataset.nombre || '').toLowerCase();
-          const matchGrado = this.grado === 'Todos' || gradoEl === this.grado;
-          const matchSearch = this.search === '' || nombreEl.includes(this.search.toLowerCase());
-          if (matchGrado && matchSearch) count++;
-        });
-        this.visibles = count;
+        this.visibles = Array.from(filas).filter((fila) => this.matchFila(fila)).length;
       });
     },
   }));
 
+  // ---------------------------------------------------------------------------
+  // Alpine.data('ventaContado') — ticket de la venta al contado: cantidades por
+  // producto, total, efectivo recibido y vuelto (en centavos para no redondear)
+  // ---------------------------------------------------------------------------
+  Alpine.data('ventaContado', (estado) => ({
+    precios: estado.precios,
+    nombres: estado.nombres,
+    cantidades: estado.cantidades || {},
+    recibido: estado.recibido || '',
+    maximo: estado.maximo,
+
+    get lineas() {
+      return Object.keys(this.cantidades)
+        .filter((id) => this.cantidades[id] > 0)
+        .map((id) => ({ id, nombre: this.nombres[id], cantidad: this.cantidades[id], total: this.cantidades[id] * this.centavos(this.precios[id]) }));
+    },
+
+    get totalCentavos() {
+      return this.lineas.reduce((suma, l) => suma + l.total, 0);
+    },
+
+    get recibidoCentavos() {
+      const texto = String(this.recibido).trim().replace(',', '.');
+      return texto === '' ? this.totalCentavos : this.centavos(parseFloat(texto));
+    },
+
+    get vueltoCentavos() {
+      return this.recibidoCentavos - this.totalCentavos;
+    },
+
+    get puedeCobrar() {
+      return this.totalCentavos > 0 && !isNaN(this.recibidoCentavos) && this.vueltoCentavos >= 0;
+    },
+
+    centavos(monto) {
+      return Math.round(monto * 100);
+    },
+
+    agregar(id, delta) {
+      const cantidad = Math.min(this.maximo, Math.max(0, (this.cantidades[id] || 0) + delta));
+      this.cantidades = { ...this.cantidades, [id]: cantidad };
+    },
+
+    billete(monto) {
+      this.recibido = monto === 0 ? '' : String(monto);
+    },
+
+    limpiar() {
+      this.cantidades = {};
+      this.recibido = '';
+    },
+
+    moneda(centavos) {
+      return 'S/ ' + (centavos / 100).toFixed(2);
+    },
+  }));
+
   Alpine.data('comprobante', () => ({
     estado: '',
     isLoading: false,
@@ -116,3 +161,79 @@ document.addEventListener('alpine:init', () => {
     },
   }));
 });
+
+// ---------------------------------------------------------------------------
+// Clave de idempotencia de los formularios de consumos: una nueva cada vez que
+// se muestra la página (también al volver con "atrás"), así el reenvío de la
+// cola sin conexión o un doble clic no se aplican dos veces.
+// ---------------------------------------------------------------------------
+function nuevaClaveIdempotencia() {
+  if (crypto.randomUUID) return crypto.randomUUID();
+  // randomUUID solo existe en contextos seguros (https o localhost)
+  return Array.from(crypto.getRandomValues(new Uint8Array(16)), (b) => b.toString(16).padStart(2, '0')).join('');
+}
+
+window.addEventListener('pageshow', () => {
+  document.querySelectorAll('input[name="clave_idempotencia"]').forEach((campo) => {
+    campo.value = nuevaClaveIdempotencia();
+  });
+});
+
+// ---------------------------------------------------------------------------
+// Contador de consumos guardados sin conexión (cola del service worker)
+// ---------------------------------------------------------------------------
+if ('serviceWorker' in navigator) {
+  const pedirAlServiceWorker = (mensaje) =>
+    navigator.serviceWorker.ready.then((reg) => reg.active && reg.active.postMessage(mensaje));
+
+  navigator.serviceWorker.addEventListener('message', (e) => {
+    if (!e.data || e.data.tipo !== 'pendientes') return;
+    const aviso = document.getElementById('pendientes-sync');
+    if (!aviso) return;
+
+    const { cantidad, rechazados } = e.data;
+    const texto = aviso.querySelector('[data-texto]');
+    if (rechazados.length > 0) {
+      // Se mantiene hasta que la cajera lo cierre: esos consumos no se guardaron
+      aviso.dataset.rechazo = '1';
+      aviso.classList.replace('bg-amber-500', 'bg-red-600');
+      texto.textContent = `${rechazados.length} registro(s) sin conexión rechazado(s): ${rechazados.join(' · ')}`;
+    } else if (!aviso.dataset.rechazo) {
+      texto.textContent = `${cantidad} registro(s) pendiente(s) de sincronizar`;
+    }
+    aviso.classList.toggle('hidden', cantidad === 0 && !aviso.dataset.rechazo);
+  });
+
+  document.addEventListener('click', (e) => {
+    const aviso = e.target.closest('#pendientes-sync');
+    if (!aviso || !aviso.dataset.rechazo) return;
+    delete aviso.dataset.rechazo;
+    aviso.classList.replace('bg-red-600', 'bg-amber-500');
+    pedirAlServiceWorker('contar');
+  });
+
+  window.addEventListener('online', () => pedirAlServiceWorker('sincronizar'));
+  pedirAlServiceWorker('sincronizar');
+}
+
+// ---------------------------------------------------------------------------
+// Cambios en vivo: las páginas con [data-en-vivo] escuchan /eventos (SSE) y
+// reenvían cada cambio como evento 'cambio' de htmx en el body; los elementos
+// con hx-trigger="cambio[...] from:body" se vuelven a pedir solos.
+// ---------------------------------------------------------------------------
+let fuenteCambios = null;
+
+window.addEventListener('pageshow', () => {
+  if (fuenteCambios || !window.EventSource || !window.htmx || !document.querySelector('[data-en-vivo]')) return;
+
+  fuenteCambios = new EventSource('/eventos');
+  fuenteCambios.addEventListener('cambio', (e) => {
+    htmx.trigger(document.body, 'cambio', JSON.parse(e.data));
+  });
+});
+
+// Cerrarlo al salir: libera la conexión y permite volver con "atrás" desde la caché
+window.addEventListener('pagehide', () => {
+  if (fuenteCambios) fuenteCambios.close();
+  fuenteCambios = null;
+});
@@ -6,11 +6,16 @@ import (
 	"kiosco/internal/auth"
 	"kiosco/internal/config"
 	"kiosco/internal/controllers"
+	"kiosco/internal/correo"
 	"kiosco/internal/middleware"
+	"kiosco/internal/notificador"
 	"kiosco/internal/router"
+	"kiosco/internal/utils"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+	_ "time/tzdata" // Zonas horarias embebidas: el VPS puede no tener /usr/share/zoneinfo
 )
 
 func main() {
@@ -21,20 +26,88 @@ func main() {
 	auth.LlaveEfimera()
 	fmt.Println("✓ Llave de sesión generada en memoria")
 
-	// Inicializar controlador (SQLite, repositorio y servicio se inicializan internamente)
-	controlador, err := controllers.NuevoControlador()
+	// Zona horaria y semana escolar: definen qué día es "hoy" y los rangos semanales
+	semana, err := config.ObtenerConfiguracionSemana()
 	if err != nil {
-		log.Fatalf("❌ Error al iniciar controlador: %v", err)
+		log.Fatalf("❌ Error en configuración de fechas: %v", err)
 	}
+	if err := utils.ConfigurarSemana(semana); err != nil {
+		log.Fatalf("❌ Error en configuración de fechas: %v", err)
+	}
+	fmt.Printf("✓ Zona horaria %s, semana de %d días desde el %s\n", semana.Zona, semana.Dias, strings.ToLower(utils.NombreDia(semana.DiaInicio)))
 
-	// Configurar rutas y archivos estáticos
-	mux := router.ConfigurarRutas(controlador)
+	// Servidor SMTP para estados de cuenta y recordatorios a apoderados
+	configCorreo, err := config.ObtenerConfiguracionCorreo()
+	if err != nil {
+		log.Fatalf("❌ Error en configuración de correo: %v", err)
+	}
+	if err := correo.Configurar(configCorreo); err != nil {
+		log.Fatalf("❌ Error en configuración de correo: %v", err)
+	}
+	if configCorreo.Habilitado() {
+		fmt.Printf("✓ Correo vía %s:%d\n", configCorreo.Host, configCorreo.Puerto)
+	} else {
+		fmt.Println("⚠️  Correo deshabilitado (sin SMTP_HOST): los envíos quedan en cola")
+	}
+
+	// Pasarela de WhatsApp/SMS para recordatorios y confirmaciones de pago
+	configMensajeria, err := config.ObtenerConfiguracionMensajeria()
+	if err != nil {
+		log.Fatalf("❌ Error en configuración de mensajería: %v", err)
+	}
+	if err := notificador.Configurar(configMensajeria); err != nil {
+		log.Fatalf("❌ Error en configuración de mensajería: %v", err)
+	}
+	if notificador.Habilitado() {
+		fmt.Printf("✓ Mensajes vía %s (máx. %d por minuto)\n", notificador.Vigente().Nombre(), configMensajeria.PorMinuto)
+	} else {
+		fmt.Println("⚠️  Mensajería deshabilitada (sin MENSAJERIA_URL): los envíos quedan en cola")
+	}
+
+	// Colegios atendidos por este binario (COLEGIOS); sin la variable, uno solo
+	colegios, err := config.ObtenerColegios()
+	if err != nil {
+		log.Fatalf("❌ Error en configuración de colegios: %v", err)
+	}
+
+	// Inicializar controladores (SQLite, repositorio y servicio se inicializan internamente)
+	// y configurar rutas y archivos estáticos
+	var controladores []*controllers.Controlador
+	var mux http.Handler
+	if len(colegios) == 0 {
+		controlador, err := controllers.NuevoControlador()
+		if err != nil {
+			log.Fatalf("❌ Error al iniciar controlador: %v", err)
+		}
+		controladores = append(controladores, controlador)
+		mux = router.ConfigurarRutas(controlador)
+	} else {
+		porHost := make(map[string]*controllers.Controlador)
+		for _, c := range colegios {
+			controlador, err := controllers.NuevoControladorColegio(c.Nombre, c.URLPublica(configCorreo.URLPublica))
+			if err != nil {
+				log.Fatalf("❌ Error al iniciar colegio %s: %v", c.Nombre, err)
+			}
+			controladores = append(controladores, controlador)
+			for _, host := range c.Hosts {
+				porHost[host] = controlador
+			}
+			fmt.Printf("✓ Colegio %s: %s (database/%s.db)\n", c.Nombre, strings.Join(c.Hosts, ", "), c.Nombre)
+		}
+		controllers.EnlazarColegios(controladores)
+		mux = router.ConfigurarColegios(porHost)
+	}
 
 	// Iniciar sweeper de rate limit con context cancelable para shutdown limpio
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	middleware.IniciarSweeper(ctx)
 
+	// Procesos en segundo plano de cada colegio: entrega de webhooks, correos y mensajes
+	for _, controlador := range controladores {
+		controlador.IniciarTareas(ctx)
+	}
+
 	// Iniciar servidor
 	direccionServidor := config.ObtenerDireccion()
 	fmt.Printf("✓ Servidor escuchando en %s\n", direccionServidor)
@@ -0,0 +1,54 @@
+// Pasarela de prueba para la mensajería de apoderados: imprime cada mensaje
+// recibido y responde como lo haría un proveedor de WhatsApp/SMS. Sirve para
+// probar el envío sin cuenta en un proveedor real:
+//
+//	go run ./cmd/pasarela-prueba -puerto 3401
+//	MENSAJERIA_URL=http://127.0.0.1:3401/mensajes make dev
+//
+// Con -fallar N responde 503 a uno de cada N mensajes, para ver los reintentos.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+)
+
+func main() {
+	puerto := flag.Int("puerto", 3401, "Puerto donde escuchar")
+	fallar := flag.Int("fallar", 0, "Responder 503 a uno de cada N mensajes (0 = nunca)")
+	flag.Parse()
+
+	var mu sync.Mutex
+	recibidos := 0
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		cuerpo, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		recibidos++
+		n := recibidos
+		mu.Unlock()
+
+		log.Printf("📱 #%d %s %s", n, r.Method, r.URL.RequestURI())
+		if len(cuerpo) > 0 {
+			log.Printf("   %s", cuerpo)
+		}
+
+		if *fallar > 0 && n%*fallar == 0 {
+			log.Printf("   → 503 (simulado)")
+			http.Error(w, "no disponible", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": fmt.Sprintf("prueba-%d", n), "estado": "aceptado"})
+	})
+
+	log.Printf("Pasarela de prueba escuchando en http://127.0.0.1:%d", *puerto)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf("127.0.0.1:%d", *puerto), nil))
+}
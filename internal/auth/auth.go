@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,7 +17,9 @@ import (
 
 const (
 	CookieNombre  = "kiosco_session"
+	CookiePortal  = "kiosco_portal"
 	tiempoExpiry  = 24 * time.Hour
+	ExpiryAPI     = 12 * time.Hour
 	argonMemory   = 16      // 16 KB, coincide con parámetros en BD
 	argonIter     = 3       // coincide con parámetros en BD
 	argonThreads  = 1       // coincide con parámetros en BD
@@ -41,10 +44,64 @@ func LlaveEfimera() []byte {
 	return llaveSecreta
 }
 
+// Contextos de firma: el HMAC de los tokens de la API y del portal incluye un
+// prefijo distinto, así una cookie de sesión no sirve como token Bearer, ni la
+// cookie de un apoderado como sesión del personal, ni al revés.
+const (
+	contextoCookie = ""
+	contextoAPI    = "api:"
+	contextoPortal = "portal:"
+)
+
+// contextoColegio agrega el colegio al contexto de firma: cuando el binario
+// atiende a varios colegios, un token emitido en uno no vale en otro aunque el
+// id de usuario coincida. Con un solo colegio ("") el contexto no cambia.
+func contextoColegio(colegio, contexto string) string {
+	if colegio == "" {
+		return contexto
+	}
+	return "colegio=" + colegio + ":" + contexto
+}
+
 // FirmarToken genera un token firmado con HMAC-SHA256.
 // Formato: <base64url(idUsuario:puede_editar:expiry)>.<base64url(hmac)>
-func FirmarToken(idUsuario int, puedeEditar bool) string {
-	expiry := time.Now().Add(tiempoExpiry).Unix()
+func FirmarToken(colegio string, idUsuario int, puedeEditar bool) string {
+	return firmar(contextoColegio(colegio, contextoCookie), idUsuario, puedeEditar, tiempoExpiry)
+}
+
+// FirmarTokenAPI genera un token Bearer para la API JSON, válido por ExpiryAPI.
+func FirmarTokenAPI(colegio string, idUsuario int, puedeEditar bool) string {
+	return firmar(contextoColegio(colegio, contextoAPI), idUsuario, puedeEditar, ExpiryAPI)
+}
+
+// VerificarToken valida la firma y la expiración del token.
+// Devuelve idUsuario, puedeEditar y true si es válido.
+func VerificarToken(colegio, token string) (int, bool, bool) {
+	idUsuario, puedeEditar, _, ok := verificar(contextoColegio(colegio, contextoCookie), token)
+	return idUsuario, puedeEditar, ok
+}
+
+// VerificarTokenAPI valida un token Bearer emitido por FirmarTokenAPI.
+func VerificarTokenAPI(colegio, token string) (int, bool, bool) {
+	idUsuario, puedeEditar, _, ok := verificar(contextoColegio(colegio, contextoAPI), token)
+	return idUsuario, puedeEditar, ok
+}
+
+// FirmarTokenPortal genera la cookie de sesión de un apoderado en el portal.
+// Nunca lleva permiso de edición.
+func FirmarTokenPortal(colegio string, idApoderado int) string {
+	return firmar(contextoColegio(colegio, contextoPortal), idApoderado, false, tiempoExpiry)
+}
+
+// VerificarTokenPortal valida la cookie del portal y devuelve el id del apoderado
+// y cuándo se emitió (para invalidarla si luego se revoca o reemplaza el enlace).
+func VerificarTokenPortal(colegio, token string) (int, time.Time, bool) {
+	idApoderado, _, expiry, ok := verificar(contextoColegio(colegio, contextoPortal), token)
+	return idApoderado, time.Unix(expiry, 0).Add(-tiempoExpiry), ok
+}
+
+func firmar(contexto string, idUsuario int, puedeEditar bool, duracion time.Duration) string {
+	expiry := time.Now().Add(duracion).Unix()
 	puede := 0
 	if puedeEditar {
 		puede = 1
@@ -53,56 +110,54 @@ func FirmarToken(idUsuario int, puedeEditar bool) string {
 	b64 := base64.RawURLEncoding.EncodeToString([]byte(payload))
 
 	mac := hmac.New(sha256.New, LlaveEfimera())
-	mac.Write([]byte(b64))
+	mac.Write([]byte(contexto + b64))
 	firma := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 
 	return b64 + "." + firma
 }
 
-// VerificarToken valida la firma y la expiración del token.
-// Devuelve idUsuario, puedeEditar y true si es válido.
-func VerificarToken(token string) (int, bool, bool) {
+func verificar(contexto, token string) (int, bool, int64, bool) {
 	partes := strings.SplitN(token, ".", 2)
 	if len(partes) != 2 {
-		return 0, false, false
+		return 0, false, 0, false
 	}
 	b64, firmaRecibida := partes[0], partes[1]
 
 	// Verificar HMAC (tiempo constante)
 	mac := hmac.New(sha256.New, LlaveEfimera())
-	mac.Write([]byte(b64))
+	mac.Write([]byte(contexto + b64))
 	firmaEsperada := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 	if !hmac.Equal([]byte(firmaRecibida), []byte(firmaEsperada)) {
-		return 0, false, false
+		return 0, false, 0, false
 	}
 
 	// Decodificar payload
 	raw, err := base64.RawURLEncoding.DecodeString(b64)
 	if err != nil {
-		return 0, false, false
+		return 0, false, 0, false
 	}
 	campos := strings.SplitN(string(raw), ":", 3)
 	if len(campos) != 3 {
-		return 0, false, false
+		return 0, false, 0, false
 	}
 
 	idUsuario, err := strconv.Atoi(campos[0])
 	if err != nil {
-		return 0, false, false
+		return 0, false, 0, false
 	}
 
 	puede, err := strconv.Atoi(campos[1])
 	if err != nil {
-		return 0, false, false
+		return 0, false, 0, false
 	}
 
 	expiry, err := strconv.ParseInt(campos[2], 10, 64)
 	if err != nil || time.Now().Unix() > expiry {
-		return 0, false, false
+		return 0, false, 0, false
 	}
 
 	puedeEditar := puede == 1
-	return idUsuario, puedeEditar, true
+	return idUsuario, puedeEditar, expiry, true
 }
 
 // VerificarPassword compara un password contra un hash Argon2id almacenado.
@@ -132,3 +187,46 @@ func VerificarPassword(hashAlmacenado, password string) bool {
 	hashComputado := argon2.IDKey([]byte(password), salt, iter, memory, threads, uint32(len(hashEsperado)))
 	return hmac.Equal(hashComputado, hashEsperado)
 }
+
+// PrefijoTokenServicio identifica los tokens de cuentas de servicio frente a los
+// tokens firmados de sesión de la API
+const PrefijoTokenServicio = "kio_"
+
+// GenerarTokenServicio crea un token aleatorio de 256 bits para una cuenta de servicio.
+// Devuelve el valor en claro (para mostrarlo una vez) y el hash que se guarda en la base.
+func GenerarTokenServicio() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := PrefijoTokenServicio + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashTokenServicio(token), nil
+}
+
+// HashTokenServicio retorna el SHA-256 en hexadecimal de un token de servicio.
+// Basta un hash sin sal: el token tiene 256 bits de entropía.
+func HashTokenServicio(token string) string {
+	suma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(suma[:])
+}
+
+// GenerarTokenPortal crea el token del enlace personal de un apoderado al portal.
+// Igual que con los tokens de servicio, solo se guarda el hash (HashTokenServicio).
+func GenerarTokenPortal() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashTokenServicio(token), nil
+}
+
+// FirmaWebhook calcula la firma de un webhook saliente:
+// hex(HMAC-SHA256(secreto, "<timestamp>.<cuerpo>")). El receptor la recalcula con
+// el mismo secreto y compara; el timestamp le permite rechazar reenvíos antiguos.
+func FirmaWebhook(secreto string, timestamp int64, cuerpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(secreto))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(cuerpo)
+	return hex.EncodeToString(mac.Sum(nil))
+}
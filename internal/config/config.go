@@ -1,6 +1,17 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"kiosco/internal/correo"
+	"kiosco/internal/notificador"
+	"kiosco/internal/utils"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // ObtenerDireccion es para usar las variables de entorno HOST y PORT por
 // defecto usa 127.0.0.1:3200 para facilidad de desarrollo y despliegue local
@@ -17,3 +28,181 @@ func ObtenerDireccion() string {
 
 	return host + ":" + puerto
 }
+
+// ObtenerConfiguracionSemana lee la zona horaria y la semana escolar de las
+// variables de entorno ZONA_HORARIA (por defecto America/Lima), SEMANA_INICIO
+// (nombre del primer día, por defecto lunes) y SEMANA_DIAS (por defecto 6)
+func ObtenerConfiguracionSemana() (utils.ConfiguracionSemana, error) {
+	var c utils.ConfiguracionSemana
+
+	zona := os.Getenv("ZONA_HORARIA")
+	if zona == "" {
+		zona = "America/Lima"
+	}
+	ubicacion, err := time.LoadLocation(zona)
+	if err != nil {
+		return c, fmt.Errorf("zona horaria inválida %q: %v", zona, err)
+	}
+	c.Zona = ubicacion
+
+	c.DiaInicio = time.Monday
+	if inicio := os.Getenv("SEMANA_INICIO"); inicio != "" {
+		dia, ok := diasPorNombre[strings.ToLower(inicio)]
+		if !ok {
+			return c, fmt.Errorf("SEMANA_INICIO inválido: %q", inicio)
+		}
+		c.DiaInicio = dia
+	}
+
+	c.Dias = 6
+	if dias := os.Getenv("SEMANA_DIAS"); dias != "" {
+		n, err := strconv.Atoi(dias)
+		if err != nil || n < 1 || n > 7 {
+			return c, fmt.Errorf("SEMANA_DIAS inválido: %q", dias)
+		}
+		c.Dias = n
+	}
+
+	return c, nil
+}
+
+// ObtenerConfiguracionCorreo lee el servidor SMTP de SMTP_HOST, SMTP_PORT (por
+// defecto 587), SMTP_USUARIO, SMTP_PASSWORD y SMTP_REMITENTE, y la base de los
+// enlaces de baja de URL_PUBLICA. Sin SMTP_HOST el envío queda deshabilitado.
+func ObtenerConfiguracionCorreo() (correo.Configuracion, error) {
+	c := correo.Configuracion{
+		Host:       os.Getenv("SMTP_HOST"),
+		Puerto:     587,
+		Usuario:    os.Getenv("SMTP_USUARIO"),
+		Password:   os.Getenv("SMTP_PASSWORD"),
+		Remitente:  os.Getenv("SMTP_REMITENTE"),
+		URLPublica: os.Getenv("URL_PUBLICA"),
+	}
+	if puerto := os.Getenv("SMTP_PORT"); puerto != "" {
+		n, err := strconv.Atoi(puerto)
+		if err != nil {
+			return c, fmt.Errorf("SMTP_PORT inválido: %q", puerto)
+		}
+		c.Puerto = n
+	}
+	if c.Remitente == "" {
+		c.Remitente = c.Usuario
+	}
+	return c, nil
+}
+
+// ObtenerConfiguracionMensajeria lee la pasarela de WhatsApp/SMS de
+// MENSAJERIA_URL, MENSAJERIA_METODO (POST), MENSAJERIA_PLANTILLA (cuerpo JSON
+// por defecto), MENSAJERIA_TIPO_CONTENIDO, MENSAJERIA_TOKEN (cabecera
+// Authorization: Bearer), MENSAJERIA_CAMPO_ID (id), MENSAJERIA_POR_MINUTO (20) y
+// MENSAJERIA_CODIGO_PAIS (51). Sin MENSAJERIA_URL el envío queda deshabilitado.
+func ObtenerConfiguracionMensajeria() (notificador.Configuracion, error) {
+	c := notificador.Configuracion{
+		Pasarela: notificador.ConfiguracionPasarela{
+			URL:           os.Getenv("MENSAJERIA_URL"),
+			Metodo:        os.Getenv("MENSAJERIA_METODO"),
+			Plantilla:     os.Getenv("MENSAJERIA_PLANTILLA"),
+			TipoContenido: os.Getenv("MENSAJERIA_TIPO_CONTENIDO"),
+			CampoId:       os.Getenv("MENSAJERIA_CAMPO_ID"),
+		},
+		PorMinuto:  20,
+		CodigoPais: os.Getenv("MENSAJERIA_CODIGO_PAIS"),
+	}
+	if c.Pasarela.Plantilla == "" && (c.Pasarela.Metodo == "" || strings.EqualFold(c.Pasarela.Metodo, "POST")) {
+		c.Pasarela.Plantilla = notificador.PlantillaPorDefecto
+	}
+	if c.Pasarela.CampoId == "" {
+		c.Pasarela.CampoId = "id"
+	}
+	if token := os.Getenv("MENSAJERIA_TOKEN"); token != "" {
+		c.Pasarela.Cabeceras = map[string]string{"Authorization": "Bearer " + token}
+	}
+	if porMinuto := os.Getenv("MENSAJERIA_POR_MINUTO"); porMinuto != "" {
+		n, err := strconv.Atoi(porMinuto)
+		if err != nil || n < 0 {
+			return c, fmt.Errorf("MENSAJERIA_POR_MINUTO inválido: %q", porMinuto)
+		}
+		c.PorMinuto = n
+	}
+	return c, nil
+}
+
+// Colegio es un colegio atendido por el mismo binario: su nombre (que da el
+// archivo de su base) y los hosts por los que se entra a su kiosco
+type Colegio struct {
+	Nombre string
+	Hosts  []string
+}
+
+// URLPublica arma la URL pública del colegio con el esquema de base (la
+// URL_PUBLICA general) y su primer host. Sin base retorna "".
+func (c Colegio) URLPublica(base string) string {
+	u, err := url.Parse(base)
+	if base == "" || err != nil || u.Scheme == "" {
+		return ""
+	}
+	return u.Scheme + "://" + c.Hosts[0]
+}
+
+var nombreColegioValido = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// ObtenerColegios lee de COLEGIOS los colegios que atiende el binario, con el
+// formato "nombre=host1,host2;nombre2=host3". Sin COLEGIOS retorna nil: un solo
+// colegio con database/database.db, como siempre.
+func ObtenerColegios() ([]Colegio, error) {
+	valor := strings.TrimSpace(os.Getenv("COLEGIOS"))
+	if valor == "" {
+		return nil, nil
+	}
+
+	var colegios []Colegio
+	nombres := make(map[string]bool)
+	hosts := make(map[string]string)
+	for _, entrada := range strings.Split(valor, ";") {
+		if strings.TrimSpace(entrada) == "" {
+			continue
+		}
+		nombre, lista, ok := strings.Cut(entrada, "=")
+		nombre = strings.TrimSpace(nombre)
+		if !ok || !nombreColegioValido.MatchString(nombre) {
+			return nil, fmt.Errorf("COLEGIOS: nombre inválido en %q (minúsculas, números y guiones)", entrada)
+		}
+		if nombres[nombre] {
+			return nil, fmt.Errorf("COLEGIOS: colegio repetido %q", nombre)
+		}
+		nombres[nombre] = true
+
+		c := Colegio{Nombre: nombre}
+		for _, host := range strings.Split(lista, ",") {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host == "" {
+				continue
+			}
+			if otro, repetido := hosts[host]; repetido {
+				return nil, fmt.Errorf("COLEGIOS: el host %q está en %q y en %q", host, otro, nombre)
+			}
+			hosts[host] = nombre
+			c.Hosts = append(c.Hosts, host)
+		}
+		if len(c.Hosts) == 0 {
+			return nil, fmt.Errorf("COLEGIOS: el colegio %q no tiene hosts", nombre)
+		}
+		colegios = append(colegios, c)
+	}
+	if len(colegios) == 0 {
+		return nil, fmt.Errorf("COLEGIOS inválido: %q", valor)
+	}
+	return colegios, nil
+}
+
+var diasPorNombre = map[string]time.Weekday{
+	"domingo":   time.Sunday,
+	"lunes":     time.Monday,
+	"martes":    time.Tuesday,
+	"miercoles": time.Wednesday,
+	"miércoles": time.Wednesday,
+	"jueves":    time.Thursday,
+	"viernes":   time.Friday,
+	"sabado":    time.Saturday,
+	"sábado":    time.Saturday,
+}
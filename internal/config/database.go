@@ -2,10 +2,13 @@ package config
 
 import (
 	"database/sql"
-	_ "embed"
+	"embed"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,53 +19,83 @@ import (
 //go:embed schema.sql
 var schemaSQL string
 
+// Migraciones incrementales aplicadas sobre schema.sql, en orden de nombre.
+// La versión aplicada se guarda en PRAGMA user_version.
+//go:embed migraciones/*.sql
+var migracionesFS embed.FS
+
 const dbPath = "database/database.db"
 
 var (
 	instancia *sql.DB
 	una       sync.Once
+
+	// Bases de cada colegio cuando el binario atiende a varios (COLEGIOS)
+	muColegios   sync.Mutex
+	basesColegio = make(map[string]*sql.DB)
 )
 
 // DB retorna la instancia singleton de la base de datos.
 func DB() *sql.DB {
 	una.Do(func() {
-		if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
-			log.Fatal("Error creando directorio de DB:", err)
-		}
-		dbNueva := !verificarDB()
+		instancia = abrirDB(dbPath)
+	})
+	return instancia
+}
 
-		var err error
-		instancia, err = sql.Open("sqlite", dbPath)
-		if err != nil {
-			log.Fatal("Error al abrir DB:", err)
-		}
-		instancia.SetMaxOpenConns(5)
-		instancia.SetMaxIdleConns(2)
-		instancia.SetConnMaxLifetime(10 * time.Minute)
-
-		pragmas := []string{
-			"PRAGMA journal_mode=WAL",
-			"PRAGMA synchronous=NORMAL",
-			"PRAGMA busy_timeout=5000",
-		}
-		for _, pragma := range pragmas {
-			if _, err := instancia.Exec(pragma); err != nil {
-				log.Printf("Warning: PRAGMA %s failed: %v", pragma, err)
-			}
-		}
+// DBColegio retorna la base de un colegio (database/<nombre>.db), que se crea
+// con el schema y las migraciones la primera vez, igual que la única base.
+func DBColegio(nombre string) *sql.DB {
+	muColegios.Lock()
+	defer muColegios.Unlock()
+	if db, ok := basesColegio[nombre]; ok {
+		return db
+	}
+	db := abrirDB(filepath.Join(filepath.Dir(dbPath), nombre+".db"))
+	basesColegio[nombre] = db
+	return db
+}
 
-		if err := instancia.Ping(); err != nil {
-			log.Fatal("No se pudo conectar a la DB:", err)
-		}
-		if dbNueva {
-			inicializarDB(instancia)
+// abrirDB abre (o crea) la base en ruta y aplica las migraciones pendientes.
+func abrirDB(ruta string) *sql.DB {
+	if err := os.MkdirAll(filepath.Dir(ruta), 0755); err != nil {
+		log.Fatal("Error creando directorio de DB:", err)
+	}
+	dbNueva := !verificarDB(ruta)
+
+	db, err := sql.Open("sqlite", ruta)
+	if err != nil {
+		log.Fatal("Error al abrir DB:", err)
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(10 * time.Minute)
+
+	pragmas := []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA synchronous=NORMAL",
+		"PRAGMA busy_timeout=5000",
+	}
+	for _, pragma := range pragmas {
+		if _, err := db.Exec(pragma); err != nil {
+			log.Printf("Warning: PRAGMA %s failed: %v", pragma, err)
 		}
-	})
-	return instancia
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatal("No se pudo conectar a la DB:", err)
+	}
+	if dbNueva {
+		inicializarDB(db)
+	}
+	if err := aplicarMigraciones(db); err != nil {
+		log.Fatalf("Error al aplicar migraciones en %s: %v", ruta, err)
+	}
+	return db
 }
 
-func verificarDB() bool {
-	_, err := os.Stat(dbPath)
+func verificarDB(ruta string) bool {
+	_, err := os.Stat(ruta)
 	return !os.IsNotExist(err) && err == nil
 }
 
@@ -72,3 +105,43 @@ func inicializarDB(db *sql.DB) {
 	}
 	log.Println("Base de datos inicializada correctamente")
 }
+
+// aplicarMigraciones ejecuta las migraciones pendientes según PRAGMA user_version.
+// Cada archivo corre en su propia transacción junto con el cambio de versión.
+func aplicarMigraciones(db *sql.DB) error {
+	archivos, err := fs.Glob(migracionesFS, "migraciones/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(archivos)
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(archivos); i++ {
+		contenido, err := migracionesFS.ReadFile(archivos[i])
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(contenido)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %v", archivos[i], err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("Migración aplicada: %s", filepath.Base(archivos[i]))
+	}
+	return nil
+}
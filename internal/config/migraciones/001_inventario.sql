@@ -0,0 +1,18 @@
+-- Inventario: existencias por producto y movimientos de stock
+
+ALTER TABLE productos ADD COLUMN controla_stock INTEGER NOT NULL DEFAULT 0; -- 1 si se lleva inventario
+ALTER TABLE productos ADD COLUMN stock_actual INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE productos ADD COLUMN stock_minimo INTEGER NOT NULL DEFAULT 0;  -- umbral de stock bajo
+
+CREATE TABLE movimientos_stock (
+    id_movimiento INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_producto INTEGER NOT NULL,
+    tipo TEXT NOT NULL,          -- entrada, venta, merma, ajuste
+    cantidad INTEGER NOT NULL,   -- con signo: positivo suma, negativo resta
+    fecha DATE NOT NULL,
+    nota TEXT NOT NULL DEFAULT '',
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (id_producto) REFERENCES productos(id_producto)
+);
+
+CREATE INDEX idx_movimientos_producto_fecha ON movimientos_stock(id_producto, fecha);
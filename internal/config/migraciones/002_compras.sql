@@ -0,0 +1,35 @@
+-- Costos de productos, proveedores y compras
+
+ALTER TABLE productos ADD COLUMN costo_unitario NUMERIC(10, 2) NOT NULL DEFAULT 0;
+-- Costo vigente al momento del consumo, para calcular el margen bruto
+ALTER TABLE consumos ADD COLUMN costo_unitario NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE proveedores (
+    id_proveedor INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL,
+    telefono TEXT NOT NULL DEFAULT '',
+    esta_activo INTEGER DEFAULT 1 NOT NULL
+);
+
+CREATE TABLE compras (
+    id_compra INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_proveedor INTEGER NOT NULL,
+    fecha_compra DATE NOT NULL,
+    nota TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (id_proveedor) REFERENCES proveedores(id_proveedor)
+);
+
+CREATE TABLE compra_items (
+    id_item INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_compra INTEGER NOT NULL,
+    id_producto INTEGER NOT NULL,
+    cantidad INTEGER NOT NULL,
+    costo_unitario NUMERIC(10, 2) NOT NULL,
+    total_linea NUMERIC(10, 2) GENERATED ALWAYS AS (cantidad * costo_unitario) STORED,
+    FOREIGN KEY (id_compra) REFERENCES compras(id_compra),
+    FOREIGN KEY (id_producto) REFERENCES productos(id_producto)
+);
+
+CREATE INDEX idx_compras_fecha ON compras(fecha_compra);
+CREATE INDEX idx_compra_items_compra ON compra_items(id_compra);
+CREATE INDEX idx_consumos_fecha ON consumos(fecha_consumo);
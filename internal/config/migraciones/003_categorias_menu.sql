@@ -0,0 +1,24 @@
+-- Categorías de productos, orden manual y menú del día
+
+CREATE TABLE categorias (
+    id_categoria INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL UNIQUE,
+    orden INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO categorias (nombre, orden) VALUES
+('Menú', 1),
+('Postres', 2),
+('Bebidas', 3),
+('Snacks', 4);
+
+ALTER TABLE productos ADD COLUMN id_categoria INTEGER REFERENCES categorias(id_categoria);
+ALTER TABLE productos ADD COLUMN orden INTEGER NOT NULL DEFAULT 0;
+
+-- Productos ofrecidos en una fecha; sin filas para la fecha = se ofrecen todos los activos
+CREATE TABLE menu_dia (
+    fecha DATE NOT NULL,
+    id_producto INTEGER NOT NULL,
+    PRIMARY KEY (fecha, id_producto),
+    FOREIGN KEY (id_producto) REFERENCES productos(id_producto)
+);
@@ -0,0 +1,13 @@
+-- Historial de precios con fecha de vigencia.
+-- productos.precio_unitario queda como precio base: rige para fechas anteriores
+-- al primer cambio registrado aquí.
+
+CREATE TABLE precios_producto (
+    id_precio INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_producto INTEGER NOT NULL,
+    precio_unitario NUMERIC(10, 2) NOT NULL,
+    vigente_desde DATE NOT NULL,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (id_producto, vigente_desde),
+    FOREIGN KEY (id_producto) REFERENCES productos(id_producto)
+);
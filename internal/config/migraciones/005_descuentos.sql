@@ -0,0 +1,43 @@
+-- Becas y descuentos por estudiante o por grupo de estudiantes
+
+CREATE TABLE grupos_descuento (
+    id_grupo INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE estudiantes_grupo (
+    id_grupo INTEGER NOT NULL,
+    id_estudiante INTEGER NOT NULL,
+    PRIMARY KEY (id_grupo, id_estudiante),
+    FOREIGN KEY (id_grupo) REFERENCES grupos_descuento(id_grupo),
+    FOREIGN KEY (id_estudiante) REFERENCES estudiantes(id_estudiante)
+);
+
+-- Una regla aplica a un estudiante o a un grupo, y a un producto, a una
+-- categoría o (ambos NULL) a todos los productos
+CREATE TABLE reglas_descuento (
+    id_regla INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL,
+    id_estudiante INTEGER,
+    id_grupo INTEGER,
+    id_producto INTEGER,
+    id_categoria INTEGER,
+    tipo TEXT NOT NULL CHECK (tipo IN ('porcentaje', 'monto')),
+    valor NUMERIC(10, 2) NOT NULL CHECK (valor > 0),
+    vigente_desde DATE NOT NULL,
+    vigente_hasta DATE, -- NULL = sin fecha de término
+    esta_activo INTEGER DEFAULT 1 NOT NULL,
+    CHECK ((id_estudiante IS NULL) != (id_grupo IS NULL)),
+    CHECK (id_producto IS NULL OR id_categoria IS NULL),
+    FOREIGN KEY (id_estudiante) REFERENCES estudiantes(id_estudiante),
+    FOREIGN KEY (id_grupo) REFERENCES grupos_descuento(id_grupo),
+    FOREIGN KEY (id_producto) REFERENCES productos(id_producto),
+    FOREIGN KEY (id_categoria) REFERENCES categorias(id_categoria)
+);
+
+CREATE INDEX idx_reglas_descuento_estudiante ON reglas_descuento(id_estudiante) WHERE id_estudiante IS NOT NULL;
+CREATE INDEX idx_reglas_descuento_grupo ON reglas_descuento(id_grupo) WHERE id_grupo IS NOT NULL;
+
+-- precio_unitario_venta queda neto; el descuento por unidad se guarda aparte
+ALTER TABLE consumos ADD COLUMN descuento_unitario NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE consumos ADD COLUMN id_regla_descuento INTEGER REFERENCES reglas_descuento(id_regla);
@@ -0,0 +1,53 @@
+-- Combos (productos compuestos) y planes de alimentación con cargo fijo por período
+
+-- Un producto con componentes es un combo: al venderse descuenta el stock de
+-- sus componentes y su costo es la suma del costo de ellos
+CREATE TABLE componentes_producto (
+    id_combo INTEGER NOT NULL,
+    id_componente INTEGER NOT NULL,
+    cantidad INTEGER NOT NULL DEFAULT 1 CHECK (cantidad > 0),
+    PRIMARY KEY (id_combo, id_componente),
+    CHECK (id_combo != id_componente),
+    FOREIGN KEY (id_combo) REFERENCES productos(id_producto),
+    FOREIGN KEY (id_componente) REFERENCES productos(id_producto)
+);
+
+-- Un plan cubre los productos de una categoría (NULL = todos) por un monto fijo
+CREATE TABLE planes (
+    id_plan INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL,
+    monto NUMERIC(10, 2) NOT NULL CHECK (monto > 0),
+    periodo TEXT NOT NULL CHECK (periodo IN ('semanal', 'mensual')),
+    id_categoria INTEGER REFERENCES categorias(id_categoria),
+    esta_activo INTEGER DEFAULT 1 NOT NULL
+);
+
+CREATE TABLE suscripciones_plan (
+    id_suscripcion INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_plan INTEGER NOT NULL,
+    id_estudiante INTEGER NOT NULL,
+    fecha_inicio DATE NOT NULL,
+    fecha_fin DATE, -- NULL = vigente
+    FOREIGN KEY (id_plan) REFERENCES planes(id_plan),
+    FOREIGN KEY (id_estudiante) REFERENCES estudiantes(id_estudiante)
+);
+
+CREATE INDEX idx_suscripciones_estudiante ON suscripciones_plan(id_estudiante);
+
+-- Cargo fijo generado por cada período de una suscripción; se suma a la deuda
+CREATE TABLE cargos_plan (
+    id_cargo INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_suscripcion INTEGER NOT NULL,
+    id_estudiante INTEGER NOT NULL,
+    periodo_inicio DATE NOT NULL,
+    fecha_cargo DATE NOT NULL,
+    monto NUMERIC(10, 2) NOT NULL,
+    UNIQUE (id_suscripcion, periodo_inicio),
+    FOREIGN KEY (id_suscripcion) REFERENCES suscripciones_plan(id_suscripcion),
+    FOREIGN KEY (id_estudiante) REFERENCES estudiantes(id_estudiante)
+);
+
+CREATE INDEX idx_cargos_plan_estudiante_fecha ON cargos_plan(id_estudiante, fecha_cargo);
+
+-- Consumos cubiertos por un plan se registran a precio 0 con su suscripción
+ALTER TABLE consumos ADD COLUMN id_suscripcion INTEGER REFERENCES suscripciones_plan(id_suscripcion);
@@ -0,0 +1,22 @@
+-- Calendario escolar: feriados, vacaciones y días de atención de la semana
+
+-- Un feriado es un rango de un solo día (fecha_desde = fecha_hasta)
+CREATE TABLE dias_no_laborables (
+    id_dia INTEGER PRIMARY KEY AUTOINCREMENT,
+    fecha_desde DATE NOT NULL,
+    fecha_hasta DATE NOT NULL,
+    tipo TEXT NOT NULL CHECK (tipo IN ('feriado', 'vacaciones', 'cierre')),
+    descripcion TEXT NOT NULL,
+    CHECK (fecha_hasta >= fecha_desde)
+);
+
+CREATE INDEX idx_dias_no_laborables_rango ON dias_no_laborables (fecha_desde, fecha_hasta);
+
+-- Parámetros generales del colegio (clave/valor)
+CREATE TABLE configuracion (
+    clave TEXT PRIMARY KEY,
+    valor TEXT NOT NULL
+);
+
+-- Días de la semana con atención (time.Weekday: 1 = lunes ... 6 = sábado)
+INSERT INTO configuracion (clave, valor) VALUES ('dias_laborables', '1,2,3,4,5,6');
@@ -0,0 +1,16 @@
+-- Tokens de larga duración para scripts e integraciones (cuentas de servicio)
+
+-- Solo se guarda el SHA-256 del token; el valor en claro se muestra una única vez al crearlo.
+-- prefijo son los primeros caracteres del token, para reconocerlo en la lista.
+CREATE TABLE tokens_api (
+    id_token INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL,
+    prefijo TEXT NOT NULL,
+    hash_token TEXT NOT NULL UNIQUE,
+    alcance TEXT NOT NULL CHECK (alcance IN ('lectura', 'edicion')),
+    id_usuario INTEGER REFERENCES usuarios(id_usuario),
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_uso DATETIME,
+    expira_en DATE,
+    revocado_en DATETIME
+);
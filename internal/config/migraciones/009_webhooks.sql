@@ -0,0 +1,30 @@
+-- Webhooks salientes: avisos firmados a otros sistemas del colegio
+
+-- eventos es una lista separada por comas (ej. 'pago.registrado,pago.anulado')
+CREATE TABLE webhooks (
+    id_webhook INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    secreto TEXT NOT NULL,
+    eventos TEXT NOT NULL,
+    esta_activo INTEGER NOT NULL DEFAULT 1,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Bandeja de salida: cada evento se guarda aquí antes de enviarse y se reintenta
+-- con espera creciente hasta entregarse o agotar los intentos
+CREATE TABLE webhook_entregas (
+    id_entrega INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_webhook INTEGER NOT NULL REFERENCES webhooks(id_webhook) ON DELETE CASCADE,
+    id_evento TEXT NOT NULL,
+    evento TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    estado TEXT NOT NULL DEFAULT 'pendiente' CHECK (estado IN ('pendiente', 'entregado', 'fallido')),
+    intentos INTEGER NOT NULL DEFAULT 0,
+    proximo_intento DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_status INTEGER,
+    ultimo_error TEXT,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    entregado_en DATETIME
+);
+
+CREATE INDEX idx_webhook_entregas_pendientes ON webhook_entregas (estado, proximo_intento);
@@ -0,0 +1,41 @@
+-- Correos a apoderados: estados de cuenta semanales y recordatorios de deuda
+
+-- Un estudiante puede tener varios apoderados. recibe_correos = 0 es la baja
+-- voluntaria (desde el enlace del correo o desde la administración).
+-- token_baja identifica al apoderado en el enlace de baja sin exponer su id.
+CREATE TABLE apoderados (
+    id_apoderado INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_estudiante INTEGER NOT NULL REFERENCES estudiantes(id_estudiante),
+    nombre TEXT NOT NULL,
+    email TEXT NOT NULL,
+    recibe_correos INTEGER NOT NULL DEFAULT 1,
+    token_baja TEXT NOT NULL UNIQUE,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (id_estudiante, email)
+);
+
+-- Bandeja de salida y registro de envíos. El mensaje se guarda ya armado para
+-- que los reintentos envíen exactamente lo mismo.
+-- semana es el inicio de la semana informada: un apoderado recibe a lo sumo un
+-- correo de cada tipo por semana aunque el envío se lance varias veces.
+CREATE TABLE correos (
+    id_correo INTEGER PRIMARY KEY AUTOINCREMENT,
+    tipo TEXT NOT NULL CHECK (tipo IN ('estado_cuenta', 'recordatorio', 'prueba')),
+    id_apoderado INTEGER REFERENCES apoderados(id_apoderado) ON DELETE SET NULL,
+    id_estudiante INTEGER REFERENCES estudiantes(id_estudiante),
+    semana DATE,
+    destinatario TEXT NOT NULL,
+    asunto TEXT NOT NULL,
+    cuerpo_texto TEXT NOT NULL,
+    cuerpo_html TEXT NOT NULL,
+    url_baja TEXT NOT NULL DEFAULT '',
+    estado TEXT NOT NULL DEFAULT 'pendiente' CHECK (estado IN ('pendiente', 'enviado', 'fallido')),
+    intentos INTEGER NOT NULL DEFAULT 0,
+    proximo_intento DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_error TEXT,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    enviado_en DATETIME,
+    UNIQUE (tipo, id_apoderado, semana)
+);
+
+CREATE INDEX idx_correos_pendientes ON correos (estado, proximo_intento);
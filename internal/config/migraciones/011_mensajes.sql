@@ -0,0 +1,48 @@
+-- Mensajes por WhatsApp/SMS a apoderados a través de una pasarela HTTP
+
+-- apoderados se reconstruye para que el correo sea opcional (apoderados solo
+-- con teléfono) y para agregar el teléfono con su propia baja.
+-- Con email NULL la restricción UNIQUE no choca entre apoderados sin correo.
+CREATE TABLE apoderados_nueva (
+    id_apoderado INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_estudiante INTEGER NOT NULL REFERENCES estudiantes(id_estudiante),
+    nombre TEXT NOT NULL,
+    email TEXT,
+    telefono TEXT,
+    recibe_correos INTEGER NOT NULL DEFAULT 1,
+    recibe_mensajes INTEGER NOT NULL DEFAULT 1,
+    token_baja TEXT NOT NULL UNIQUE,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (id_estudiante, email),
+    UNIQUE (id_estudiante, telefono)
+);
+
+INSERT INTO apoderados_nueva (id_apoderado, id_estudiante, nombre, email, recibe_correos, token_baja, creado_en)
+SELECT id_apoderado, id_estudiante, nombre, email, recibe_correos, token_baja, creado_en FROM apoderados;
+
+DROP TABLE apoderados;
+ALTER TABLE apoderados_nueva RENAME TO apoderados;
+
+-- Bandeja de salida y registro de mensajes. referencia evita duplicados:
+-- inicio de semana para recordatorios, id_pago para confirmaciones de pago.
+CREATE TABLE mensajes (
+    id_mensaje INTEGER PRIMARY KEY AUTOINCREMENT,
+    tipo TEXT NOT NULL CHECK (tipo IN ('recordatorio', 'pago', 'prueba')),
+    id_apoderado INTEGER REFERENCES apoderados(id_apoderado) ON DELETE SET NULL,
+    id_estudiante INTEGER REFERENCES estudiantes(id_estudiante),
+    referencia TEXT,
+    telefono TEXT NOT NULL,
+    texto TEXT NOT NULL,
+    proveedor TEXT NOT NULL DEFAULT '',
+    estado TEXT NOT NULL DEFAULT 'pendiente' CHECK (estado IN ('pendiente', 'enviado', 'fallido')),
+    intentos INTEGER NOT NULL DEFAULT 0,
+    proximo_intento DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_status INTEGER,
+    ultimo_error TEXT,
+    id_externo TEXT,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    enviado_en DATETIME,
+    UNIQUE (tipo, id_apoderado, referencia)
+);
+
+CREATE INDEX idx_mensajes_pendientes ON mensajes (estado, proximo_intento);
@@ -0,0 +1,6 @@
+-- Portal de apoderados: acceso de solo lectura con un enlace personal.
+-- Solo se guarda el hash del token del enlace; NULL = sin acceso.
+ALTER TABLE apoderados ADD COLUMN hash_portal TEXT;
+ALTER TABLE apoderados ADD COLUMN portal_generado_en DATETIME;
+
+CREATE UNIQUE INDEX idx_apoderados_hash_portal ON apoderados (hash_portal);
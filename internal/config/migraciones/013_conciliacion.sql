@@ -0,0 +1,31 @@
+-- Conciliación de extractos bancarios y de billeteras (Yape, Plin)
+
+-- Un extracto es un archivo CSV importado; sus líneas son abonos recibidos.
+CREATE TABLE extractos (
+    id_extracto INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre_archivo TEXT NOT NULL,
+    origen TEXT NOT NULL,
+    lineas INTEGER NOT NULL DEFAULT 0,     -- Abonos nuevos importados
+    repetidas INTEGER NOT NULL DEFAULT 0,  -- Abonos que ya estaban en otro extracto
+    cargado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- huella evita importar dos veces el mismo abono cuando los extractos se solapan.
+-- id_estudiante es la sugerencia automática (criterio) o la elección del tesorero;
+-- al confirmar se crea el pago y se guarda id_pago.
+CREATE TABLE movimientos_extracto (
+    id_movimiento INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_extracto INTEGER NOT NULL REFERENCES extractos(id_extracto),
+    fecha DATE NOT NULL,
+    monto REAL NOT NULL,
+    descripcion TEXT NOT NULL DEFAULT '',
+    ordenante TEXT NOT NULL DEFAULT '',
+    huella TEXT NOT NULL UNIQUE,
+    estado TEXT NOT NULL DEFAULT 'pendiente' CHECK (estado IN ('pendiente', 'confirmado', 'descartado')),
+    id_estudiante INTEGER REFERENCES estudiantes(id_estudiante),
+    criterio TEXT CHECK (criterio IN ('codigo', 'monto')),
+    id_pago INTEGER,
+    resuelto_en DATETIME
+);
+
+CREATE INDEX idx_movimientos_extracto_estado ON movimientos_extracto (estado, fecha);
@@ -0,0 +1,10 @@
+-- Claves de idempotencia de los formularios de consumos. El service worker
+-- reenvía al volver la conexión los envíos que quedaron en cola; si el primero sí
+-- llegó al servidor, la clave repetida evita aplicarlo dos veces.
+CREATE TABLE claves_idempotencia (
+    clave TEXT PRIMARY KEY,
+    ruta TEXT NOT NULL,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_claves_idempotencia_creado ON claves_idempotencia (creado_en);
@@ -0,0 +1,17 @@
+-- Código del carné de cada estudiante (opcional: sin él, el carné y el lector
+-- usan su código de pago K…) y búsqueda de texto sin tildes por apellidos,
+-- nombres y código. estudiantes_fts lo mantiene el repositorio al crear o
+-- editar estudiantes (rowid = id_estudiante).
+ALTER TABLE estudiantes ADD COLUMN codigo TEXT;
+
+CREATE UNIQUE INDEX idx_estudiantes_codigo ON estudiantes (codigo COLLATE NOCASE) WHERE codigo IS NOT NULL;
+
+CREATE VIRTUAL TABLE estudiantes_fts USING fts5 (
+    apellidos,
+    nombres,
+    codigo,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO estudiantes_fts (rowid, apellidos, nombres, codigo)
+SELECT id_estudiante, apellidos, nombres, '' FROM estudiantes;
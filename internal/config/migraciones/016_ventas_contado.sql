@@ -0,0 +1,29 @@
+-- Ventas al contado: docentes y visitantes que pagan en efectivo en el momento.
+-- No pertenecen a la cuenta de ningún estudiante, así que van aparte de consumos
+-- y los reportes las suman por separado.
+
+-- recibido es el efectivo que entregó el cliente; el vuelto es recibido - total.
+-- Una venta anulada devuelve su stock y deja de contar en los reportes.
+CREATE TABLE ventas_contado (
+    id_venta INTEGER PRIMARY KEY AUTOINCREMENT,
+    fecha DATE NOT NULL,
+    total NUMERIC(10, 2) NOT NULL,
+    recibido NUMERIC(10, 2) NOT NULL,
+    id_usuario INTEGER REFERENCES usuarios(id_usuario), -- Quién cobró
+    anulada INTEGER NOT NULL DEFAULT 0,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Precio y costo vigentes al momento de la venta, como en consumos
+CREATE TABLE venta_contado_items (
+    id_item INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_venta INTEGER NOT NULL REFERENCES ventas_contado(id_venta),
+    id_producto INTEGER NOT NULL REFERENCES productos(id_producto),
+    cantidad INTEGER NOT NULL,
+    precio_unitario NUMERIC(10, 2) NOT NULL,
+    costo_unitario NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    total_linea NUMERIC(10, 2) GENERATED ALWAYS AS (cantidad * precio_unitario) STORED
+);
+
+CREATE INDEX idx_ventas_contado_fecha ON ventas_contado (fecha);
+CREATE INDEX idx_venta_contado_items_venta ON venta_contado_items (id_venta);
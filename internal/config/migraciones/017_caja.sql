@@ -0,0 +1,41 @@
+-- Caja: sesiones por cajero con fondo inicial, arqueo al cierre y diferencia.
+-- El efectivo esperado es el fondo más los pagos en efectivo y las ventas al
+-- contado cobrados por el cajero mientras su caja estuvo abierta.
+
+-- Los totales de pagos y ventas se congelan al cerrar para que el reporte no
+-- cambie si luego se anula una venta o se elimina un pago. Un cajero tiene a lo
+-- más una caja abierta.
+CREATE TABLE sesiones_caja (
+    id_sesion INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_usuario INTEGER NOT NULL REFERENCES usuarios(id_usuario),
+    monto_inicial NUMERIC(10, 2) NOT NULL,
+    abierta_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    cerrada_en DATETIME,
+    total_pagos NUMERIC(10, 2),
+    total_ventas NUMERIC(10, 2),
+    monto_contado NUMERIC(10, 2),
+    notas TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX idx_sesiones_caja_abierta ON sesiones_caja (id_usuario) WHERE cerrada_en IS NULL;
+CREATE INDEX idx_sesiones_caja_abierta_en ON sesiones_caja (abierta_en);
+
+-- Método de pago: solo el efectivo entra en la caja. Los pagos anteriores se
+-- toman como efectivo salvo los creados desde un extracto conciliado.
+ALTER TABLE pagos ADD COLUMN metodo TEXT NOT NULL DEFAULT 'efectivo'
+    CHECK (metodo IN ('efectivo', 'transferencia', 'billetera', 'otro'));
+ALTER TABLE pagos ADD COLUMN id_usuario INTEGER REFERENCES usuarios(id_usuario); -- Quién lo registró
+ALTER TABLE pagos ADD COLUMN id_sesion_caja INTEGER REFERENCES sesiones_caja(id_sesion);
+
+UPDATE pagos SET metodo = (
+    SELECT CASE x.origen WHEN 'banco' THEN 'transferencia' WHEN 'otro' THEN 'otro' ELSE 'billetera' END
+    FROM movimientos_extracto m
+    JOIN extractos x ON x.id_extracto = m.id_extracto
+    WHERE m.id_pago = pagos.id_pago
+)
+WHERE id_pago IN (SELECT id_pago FROM movimientos_extracto WHERE id_pago IS NOT NULL);
+
+ALTER TABLE ventas_contado ADD COLUMN id_sesion_caja INTEGER REFERENCES sesiones_caja(id_sesion);
+
+CREATE INDEX idx_pagos_sesion_caja ON pagos (id_sesion_caja) WHERE id_sesion_caja IS NOT NULL;
+CREATE INDEX idx_ventas_contado_sesion_caja ON ventas_contado (id_sesion_caja) WHERE id_sesion_caja IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Clientes que no son estudiantes: docentes, personal del colegio y otros que
+-- consumen a cuenta y pagan a fin de mes. Usan la misma tabla (consumos, pagos
+-- y saldos no cambian) y se distinguen por su tipo; no tienen grado.
+ALTER TABLE estudiantes ADD COLUMN tipo TEXT NOT NULL DEFAULT 'estudiante'
+    CHECK (tipo IN ('estudiante', 'personal', 'otro'));
+
+CREATE INDEX idx_estudiantes_tipo ON estudiantes (tipo) WHERE tipo <> 'estudiante';
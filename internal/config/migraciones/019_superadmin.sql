@@ -0,0 +1,3 @@
+-- Usuarios que ven el resumen de ventas de todos los colegios del binario
+-- (/superadmin). Se marca a mano: UPDATE usuarios SET es_superadmin = 1 ...
+ALTER TABLE usuarios ADD COLUMN es_superadmin INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"kiosco/internal/auth"
+	"kiosco/internal/middleware"
+	"kiosco/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Documento OpenAPI de /api/v1, servido tal cual en /api/v1/openapi.json
+//
+//go:embed openapi.json
+var documentoOpenAPI []byte
+
+const (
+	porPaginaDefecto = 50
+	porPaginaMaximo  = 500
+	maxCuerpoAPI     = 1 << 20 // 1 MB por request JSON
+	maxDiasRangoAPI  = 366     // Rango máximo de fechas en los listados
+)
+
+// Paginacion acompaña a todos los listados de la API
+type Paginacion struct {
+	Pagina    int `json:"pagina"`
+	PorPagina int `json:"por_pagina"`
+	Total     int `json:"total"`
+}
+
+// respuestaListado es el sobre común de los listados: {"datos": [...], "paginacion": {...}}
+type respuestaListado struct {
+	Datos      any        `json:"datos"`
+	Paginacion Paginacion `json:"paginacion"`
+}
+
+// responderJSON escribe v como JSON con el status indicado
+func responderJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error al escribir respuesta de API: %v", err)
+	}
+}
+
+// responderErrorAPI es un atajo a middleware.ResponderErrorAPI
+func responderErrorAPI(w http.ResponseWriter, status int, codigo, mensaje string) {
+	middleware.ResponderErrorAPI(w, status, codigo, mensaje)
+}
+
+// responderErrorInterno registra el error y responde 500 sin exponer detalles
+func responderErrorInterno(w http.ResponseWriter, contexto string, err error) {
+	log.Printf("Error al %s: %v", contexto, err)
+	responderErrorAPI(w, http.StatusInternalServerError, "error_interno", "Error al "+contexto)
+}
+
+// leerJSON decodifica el cuerpo del request en v, rechazando campos desconocidos
+func leerJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		responderErrorAPI(w, http.StatusUnsupportedMediaType, "tipo_no_soportado", "El cuerpo debe ser application/json")
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCuerpoAPI))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		mensaje := "JSON inválido: " + err.Error()
+		if errors.Is(err, io.EOF) {
+			mensaje = "El cuerpo del request está vacío"
+		}
+		responderErrorAPI(w, http.StatusBadRequest, "json_invalido", mensaje)
+		return false
+	}
+	return true
+}
+
+// paginar recorta datos según ?pagina= y ?por_pagina= y responde el listado
+func paginar[T any](w http.ResponseWriter, r *http.Request, datos []T) {
+	pagina, porPagina := 1, porPaginaDefecto
+	if v := r.URL.Query().Get("pagina"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "pagina debe ser un entero mayor a cero")
+			return
+		}
+		pagina = n
+	}
+	if v := r.URL.Query().Get("por_pagina"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > porPaginaMaximo {
+			responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "por_pagina debe estar entre 1 y "+strconv.Itoa(porPaginaMaximo))
+			return
+		}
+		porPagina = n
+	}
+
+	// Se compara antes de multiplicar: una página enorme desbordaría el producto
+	desde := len(datos)
+	if pagina-1 < (len(datos)+porPagina-1)/porPagina {
+		desde = (pagina - 1) * porPagina
+	}
+	hasta := min(desde+porPagina, len(datos))
+	pag := datos[desde:hasta]
+	if pag == nil {
+		pag = []T{}
+	}
+	responderJSON(w, http.StatusOK, respuestaListado{
+		Datos:      pag,
+		Paginacion: Paginacion{Pagina: pagina, PorPagina: porPagina, Total: len(datos)},
+	})
+}
+
+// idDeRuta lee un entero positivo del patrón de la ruta ({id})
+func idDeRuta(w http.ResponseWriter, r *http.Request, nombre string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(nombre))
+	if err != nil || id <= 0 {
+		responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", nombre+" inválido")
+		return 0, false
+	}
+	return id, true
+}
+
+// rangoFechasAPI lee ?desde= y ?hasta= (por defecto, la semana actual)
+func rangoFechasAPI(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	desde, hasta := utils.ObtenerSemanaActual()
+	if v := r.URL.Query().Get("desde"); v != "" {
+		f, err := utils.ParsearFecha(v)
+		if err != nil {
+			responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "desde debe tener formato AAAA-MM-DD")
+			return desde, hasta, false
+		}
+		desde = f
+	}
+	if v := r.URL.Query().Get("hasta"); v != "" {
+		f, err := utils.ParsearFecha(v)
+		if err != nil {
+			responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "hasta debe tener formato AAAA-MM-DD")
+			return desde, hasta, false
+		}
+		hasta = f
+	}
+	if hasta.Before(desde) {
+		responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "hasta no puede ser anterior a desde")
+		return desde, hasta, false
+	}
+	if hasta.Sub(desde) > maxDiasRangoAPI*24*time.Hour {
+		responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "El rango no puede superar "+strconv.Itoa(maxDiasRangoAPI)+" días")
+		return desde, hasta, false
+	}
+	return desde, hasta, true
+}
+
+// fechaAPI lee una fecha AAAA-MM-DD opcional; vacía significa hoy
+func fechaAPI(valor string) (time.Time, error) {
+	if valor == "" {
+		return utils.Hoy(), nil
+	}
+	return utils.ParsearFecha(valor)
+}
+
+// solicitudToken es el cuerpo de POST /api/v1/auth/token
+type solicitudToken struct {
+	Usuario  string `json:"usuario"`
+	Password string `json:"password"`
+}
+
+// respuestaToken contiene el token Bearer emitido
+type respuestaToken struct {
+	Token       string    `json:"token"`
+	Tipo        string    `json:"tipo"`
+	Expira      time.Time `json:"expira"`
+	PuedeEditar bool      `json:"puede_editar"`
+}
+
+// APIEmitirToken valida usuario y contraseña y emite un token Bearer para la API.
+// Comparte el rate limiting por IP con el login del navegador.
+func (m *Controlador) APIEmitirToken(w http.ResponseWriter, r *http.Request) {
+	var solicitud solicitudToken
+	if !leerJSON(w, r, &solicitud) {
+		return
+	}
+
+	u, err := m.servicio.Repo.ObtenerUsuarioPorNombre(strings.TrimSpace(solicitud.Usuario))
+	if err != nil || !auth.VerificarPassword(u.Contrasenha, solicitud.Password) {
+		middleware.IncrementarIntentosLogin(r)
+		// Mismo mensaje para no revelar si el usuario existe o no
+		responderErrorAPI(w, http.StatusUnauthorized, "credenciales_invalidas", "Usuario o contraseña incorrectos")
+		return
+	}
+	middleware.ResetearRateLimitLogin(r)
+
+	responderJSON(w, http.StatusOK, respuestaToken{
+		Token:       auth.FirmarTokenAPI(middleware.Colegio(r), u.IdUsuario, u.PuedeEditar),
+		Tipo:        "Bearer",
+		Expira:      time.Now().Add(auth.ExpiryAPI).UTC().Truncate(time.Second),
+		PuedeEditar: u.PuedeEditar,
+	})
+}
+
+// APIOpenAPI sirve el documento OpenAPI de la API (público, sin token)
+func (m *Controlador) APIOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if _, err := w.Write(documentoOpenAPI); err != nil {
+		log.Printf("Error al escribir documento OpenAPI: %v", err)
+	}
+}
+
+// APINoEncontrado responde 404 en JSON para rutas desconocidas bajo /api/
+func (m *Controlador) APINoEncontrado(w http.ResponseWriter, r *http.Request) {
+	responderErrorAPI(w, http.StatusNotFound, "no_encontrado", "Ruta no encontrada: "+r.Method+" "+r.URL.Path)
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"kiosco/internal/models"
+	"kiosco/internal/services"
+	"kiosco/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// estudianteAPI es la representación JSON de un estudiante
+type estudianteAPI struct {
+	IdEstudiante int    `json:"id_estudiante"`
+	Nombres      string `json:"nombres"`
+	Apellidos    string `json:"apellidos"`
+	IdGrado      int    `json:"id_grado"`
+	NombreGrado  string `json:"nombre_grado"`
+	Activo       bool   `json:"activo"`
+	Tipo         string `json:"tipo"`
+}
+
+func nuevoEstudianteAPI(e models.Estudiante) estudianteAPI {
+	// Quien no es estudiante no tiene grado: id_grado 0 y nombre_grado vacío
+	nombreGrado := ""
+	if e.EsEstudiante() {
+		nombreGrado = e.NombreGrado
+		if nombreGrado == "" {
+			nombreGrado = utils.NombreGrado(e.IdGrado)
+		}
+	}
+	return estudianteAPI{
+		IdEstudiante: e.IdEstudiante,
+		Nombres:      e.Nombres,
+		Apellidos:    e.Apellidos,
+		IdGrado:      e.IdGrado,
+		NombreGrado:  nombreGrado,
+		Activo:       e.EstaActivo,
+		Tipo:         e.Tipo,
+	}
+}
+
+// productoAPI es la representación JSON de un producto con su precio vigente hoy
+type productoAPI struct {
+	IdProducto      int     `json:"id_producto"`
+	Nombre          string  `json:"nombre"`
+	Precio          float64 `json:"precio"`
+	Activo          bool    `json:"activo"`
+	IdCategoria     int     `json:"id_categoria,omitempty"`
+	NombreCategoria string  `json:"nombre_categoria,omitempty"`
+	EsCombo         bool    `json:"es_combo"`
+	ControlaStock   bool    `json:"controla_stock"`
+	StockActual     *int    `json:"stock_actual,omitempty"` // Solo si controla stock
+}
+
+func nuevoProductoAPI(p models.Producto) productoAPI {
+	dto := productoAPI{
+		IdProducto:      p.IdProducto,
+		Nombre:          p.Nombre,
+		Precio:          p.PrecioUnitario,
+		Activo:          p.EstaActivo,
+		IdCategoria:     p.IdCategoria,
+		NombreCategoria: p.NombreCategoria,
+		EsCombo:         p.EsCombo,
+		ControlaStock:   p.ControlaStock,
+	}
+	if p.ControlaStock {
+		stock := p.StockActual
+		dto.StockActual = &stock
+	}
+	return dto
+}
+
+// gradoDeConsulta lee ?grado= (0 = todos) validando contra la lista de grados
+func gradoDeConsulta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("grado")
+	if v == "" {
+		return 0, true
+	}
+	idGrado, err := strconv.Atoi(v)
+	if err != nil || (idGrado != 0 && utils.NombreGrado(idGrado) == "") {
+		responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "grado inválido")
+		return 0, false
+	}
+	return idGrado, true
+}
+
+// APIListarEstudiantes lista estudiantes y demás cuentas, filtrando por ?grado=,
+// ?tipo= y ?activo=
+func (m *Controlador) APIListarEstudiantes(w http.ResponseWriter, r *http.Request) {
+	idGrado, ok := gradoDeConsulta(w, r)
+	if !ok {
+		return
+	}
+	tipo := r.URL.Query().Get("tipo")
+	if tipo != "" && !models.TipoClienteValido(tipo) {
+		responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "tipo inválido")
+		return
+	}
+
+	estudiantes, err := m.servicio.Repo.ObtenerTodosEstudiantes()
+	if err != nil {
+		responderErrorInterno(w, "obtener estudiantes", err)
+		return
+	}
+
+	activo := r.URL.Query().Get("activo")
+	datos := make([]estudianteAPI, 0, len(estudiantes))
+	for _, e := range estudiantes {
+		if idGrado != 0 && e.IdGrado != idGrado {
+			continue
+		}
+		if tipo != "" && e.Tipo != tipo {
+			continue
+		}
+		if (activo == "true" && !e.EstaActivo) || (activo == "false" && e.EstaActivo) {
+			continue
+		}
+		datos = append(datos, nuevoEstudianteAPI(e))
+	}
+	paginar(w, r, datos)
+}
+
+// APIObtenerEstudiante retorna un estudiante por su ID
+func (m *Controlador) APIObtenerEstudiante(w http.ResponseWriter, r *http.Request) {
+	id, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+
+	est, err := m.servicio.Repo.ObtenerEstudiantePorId(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		responderErrorAPI(w, http.StatusNotFound, "no_encontrado", "Estudiante no encontrado")
+		return
+	}
+	if err != nil {
+		responderErrorInterno(w, "obtener estudiante", err)
+		return
+	}
+	responderJSON(w, http.StatusOK, nuevoEstudianteAPI(est))
+}
+
+// solicitudEstudiante es el cuerpo de POST /api/v1/estudiantes
+type solicitudEstudiante struct {
+	Nombres   string `json:"nombres"`
+	Apellidos string `json:"apellidos"`
+	IdGrado   int    `json:"id_grado"`
+	Tipo      string `json:"tipo"`
+}
+
+// APICrearEstudiante registra un estudiante nuevo
+func (m *Controlador) APICrearEstudiante(w http.ResponseWriter, r *http.Request) {
+	var solicitud solicitudEstudiante
+	if !leerJSON(w, r, &solicitud) {
+		return
+	}
+
+	nombres := strings.TrimSpace(solicitud.Nombres)
+	apellidos := strings.TrimSpace(solicitud.Apellidos)
+	if nombres == "" || apellidos == "" {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", "nombres y apellidos son obligatorios")
+		return
+	}
+	// Los estudiantes necesitan id_grado; personal y otros no llevan
+	tipo, idGrado, err := services.ValidarTipoCliente(solicitud.Tipo, solicitud.IdGrado)
+	if err != nil {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", err.Error())
+		return
+	}
+
+	est, err := m.servicio.Repo.InsertarEstudiante(nombres, apellidos, idGrado, tipo)
+	if err != nil {
+		responderErrorInterno(w, "agregar estudiante", err)
+		return
+	}
+	w.Header().Set("Location", "/api/v1/estudiantes/"+strconv.Itoa(est.IdEstudiante))
+	responderJSON(w, http.StatusCreated, nuevoEstudianteAPI(est))
+}
+
+// APIListarProductos lista productos; ?activo=true deja solo los activos
+func (m *Controlador) APIListarProductos(w http.ResponseWriter, r *http.Request) {
+	var productos []models.Producto
+	var err error
+	if r.URL.Query().Get("activo") == "true" {
+		productos, err = m.servicio.Repo.ObtenerProductosActivos()
+	} else {
+		productos, err = m.servicio.Repo.ObtenerTodosProductos()
+	}
+	if err != nil {
+		responderErrorInterno(w, "obtener productos", err)
+		return
+	}
+
+	datos := make([]productoAPI, 0, len(productos))
+	for _, p := range productos {
+		datos = append(datos, nuevoProductoAPI(p))
+	}
+	paginar(w, r, datos)
+}
+
+// APIObtenerProducto retorna un producto por su ID
+func (m *Controlador) APIObtenerProducto(w http.ResponseWriter, r *http.Request) {
+	id, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+
+	producto, err := m.servicio.Repo.ObtenerProductoPorId(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		responderErrorAPI(w, http.StatusNotFound, "no_encontrado", "Producto no encontrado")
+		return
+	}
+	if err != nil {
+		responderErrorInterno(w, "obtener producto", err)
+		return
+	}
+	responderJSON(w, http.StatusOK, nuevoProductoAPI(*producto))
+}
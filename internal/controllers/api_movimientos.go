@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+// consumoAPI es la representación JSON de una línea de consumo
+type consumoAPI struct {
+	IdConsumo      int64   `json:"id_consumo"`
+	IdEstudiante   int     `json:"id_estudiante"`
+	IdProducto     int     `json:"id_producto"`
+	Cantidad       int     `json:"cantidad"`
+	PrecioUnitario float64 `json:"precio_unitario"` // Precio cobrado, con descuentos y planes aplicados
+	Total          float64 `json:"total"`
+	Fecha          string  `json:"fecha"`
+}
+
+func nuevoConsumoAPI(c models.Consumo) consumoAPI {
+	return consumoAPI{
+		IdConsumo:      c.IdConsumo,
+		IdEstudiante:   c.IdEstudiante,
+		IdProducto:     c.IdProducto,
+		Cantidad:       c.Cantidad,
+		PrecioUnitario: c.PrecioUnitarioVenta,
+		Total:          c.TotalLinea,
+		Fecha:          utils.FormatearFechaCompleta(c.FechaConsumo),
+	}
+}
+
+// pagoAPI es la representación JSON de un pago
+type pagoAPI struct {
+	IdPago       int     `json:"id_pago"`
+	IdEstudiante int     `json:"id_estudiante"`
+	Monto        float64 `json:"monto"`
+	Fecha        string  `json:"fecha"`
+	Metodo       string  `json:"metodo"`
+}
+
+func nuevoPagoAPI(p models.Pago) pagoAPI {
+	return pagoAPI{
+		IdPago:       p.IdPago,
+		IdEstudiante: p.IdEstudiante,
+		Monto:        p.Monto,
+		Fecha:        utils.FormatearFechaCompleta(p.FechaPago),
+		Metodo:       p.Metodo,
+	}
+}
+
+// estudianteDeConsulta lee ?id_estudiante= (0 = todos)
+func estudianteDeConsulta(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("id_estudiante")
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "id_estudiante inválido")
+		return 0, false
+	}
+	return id, true
+}
+
+// validarEstudianteAPI responde 422 si el estudiante no existe
+func (m *Controlador) validarEstudianteAPI(w http.ResponseWriter, idEstudiante int) bool {
+	_, err := m.servicio.Repo.ObtenerEstudiantePorId(idEstudiante)
+	if errors.Is(err, sql.ErrNoRows) {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", "El estudiante "+strconv.Itoa(idEstudiante)+" no existe")
+		return false
+	}
+	if err != nil {
+		responderErrorInterno(w, "obtener estudiante", err)
+		return false
+	}
+	return true
+}
+
+// APIListarConsumos lista consumos entre ?desde= y ?hasta=, opcionalmente de un estudiante
+func (m *Controlador) APIListarConsumos(w http.ResponseWriter, r *http.Request) {
+	desde, hasta, ok := rangoFechasAPI(w, r)
+	if !ok {
+		return
+	}
+	idEstudiante, ok := estudianteDeConsulta(w, r)
+	if !ok {
+		return
+	}
+
+	consumos, err := m.servicio.Repo.ObtenerConsumosSemana(desde, hasta)
+	if err != nil {
+		responderErrorInterno(w, "obtener consumos", err)
+		return
+	}
+
+	datos := make([]consumoAPI, 0, len(consumos))
+	for _, c := range consumos {
+		if idEstudiante != 0 && c.IdEstudiante != idEstudiante {
+			continue
+		}
+		datos = append(datos, nuevoConsumoAPI(c))
+	}
+	paginar(w, r, datos)
+}
+
+// solicitudConsumo es el cuerpo de POST /api/v1/consumos
+type solicitudConsumo struct {
+	IdEstudiante int    `json:"id_estudiante"`
+	IdProducto   int    `json:"id_producto"`
+	Cantidad     int    `json:"cantidad"`
+	Fecha        string `json:"fecha"` // AAAA-MM-DD, hoy si se omite
+}
+
+// APIRegistrarConsumo fija la cantidad consumida de un producto en un día,
+// igual que la grilla semanal: cantidad 0 anula el consumo.
+func (m *Controlador) APIRegistrarConsumo(w http.ResponseWriter, r *http.Request) {
+	var solicitud solicitudConsumo
+	if !leerJSON(w, r, &solicitud) {
+		return
+	}
+
+	fecha, err := fechaAPI(solicitud.Fecha)
+	if err != nil {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", "fecha debe tener formato AAAA-MM-DD")
+		return
+	}
+	if err := m.servicio.ValidarCantidadConsumo(solicitud.Cantidad); err != nil {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", err.Error())
+		return
+	}
+	if !m.validarEstudianteAPI(w, solicitud.IdEstudiante) {
+		return
+	}
+	if _, err := m.servicio.Repo.ObtenerProductoPorId(solicitud.IdProducto); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", "El producto "+strconv.Itoa(solicitud.IdProducto)+" no existe")
+			return
+		}
+		responderErrorInterno(w, "obtener producto", err)
+		return
+	}
+
+	if solicitud.Cantidad > 0 {
+		if err := m.servicio.ValidarDiaLaborable(fecha); err != nil {
+			responderErrorAPI(w, http.StatusUnprocessableEntity, "dia_no_laborable", err.Error())
+			return
+		}
+	}
+
+	if err := m.servicio.RegistrarConsumoDesdeFormulario(solicitud.IdEstudiante, solicitud.IdProducto, solicitud.Cantidad, fecha); err != nil {
+		responderErrorInterno(w, "registrar consumo", err)
+		return
+	}
+
+	// Devolver la línea tal como quedó, con el precio efectivamente cobrado
+	consumos, err := m.servicio.Repo.ObtenerConsumosSemana(fecha, fecha)
+	if err != nil {
+		responderErrorInterno(w, "obtener consumos", err)
+		return
+	}
+	for _, c := range consumos {
+		if c.IdEstudiante == solicitud.IdEstudiante && c.IdProducto == solicitud.IdProducto {
+			responderJSON(w, http.StatusOK, nuevoConsumoAPI(c))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIListarPagos lista pagos entre ?desde= y ?hasta=, opcionalmente de un estudiante
+func (m *Controlador) APIListarPagos(w http.ResponseWriter, r *http.Request) {
+	desde, hasta, ok := rangoFechasAPI(w, r)
+	if !ok {
+		return
+	}
+	idEstudiante, ok := estudianteDeConsulta(w, r)
+	if !ok {
+		return
+	}
+
+	pagos, err := m.servicio.Repo.ObtenerPagosRango(desde, hasta)
+	if err != nil {
+		responderErrorInterno(w, "obtener pagos", err)
+		return
+	}
+
+	datos := make([]pagoAPI, 0, len(pagos))
+	for _, p := range pagos {
+		if idEstudiante != 0 && p.IdEstudiante != idEstudiante {
+			continue
+		}
+		datos = append(datos, nuevoPagoAPI(p))
+	}
+	paginar(w, r, datos)
+}
+
+// solicitudPago es el cuerpo de POST /api/v1/pagos
+type solicitudPago struct {
+	IdEstudiante int     `json:"id_estudiante"`
+	Monto        float64 `json:"monto"`
+	Fecha        string  `json:"fecha"`  // AAAA-MM-DD, hoy si se omite
+	Metodo       string  `json:"metodo"` // efectivo si se omite
+}
+
+// APIRegistrarPago registra un pago de un estudiante
+func (m *Controlador) APIRegistrarPago(w http.ResponseWriter, r *http.Request) {
+	var solicitud solicitudPago
+	if !leerJSON(w, r, &solicitud) {
+		return
+	}
+
+	fecha, err := fechaAPI(solicitud.Fecha)
+	if err != nil {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", "fecha debe tener formato AAAA-MM-DD")
+		return
+	}
+	if solicitud.Monto <= 0 {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", "El monto debe ser mayor a cero")
+		return
+	}
+	if solicitud.Metodo == "" {
+		solicitud.Metodo = models.MetodoEfectivo
+	}
+	if !models.MetodoPagoValido(solicitud.Metodo) {
+		responderErrorAPI(w, http.StatusUnprocessableEntity, "datos_invalidos", "metodo debe ser efectivo, transferencia, billetera u otro")
+		return
+	}
+	if !m.validarEstudianteAPI(w, solicitud.IdEstudiante) {
+		return
+	}
+
+	pago, err := m.servicio.RegistrarPagoDesdeFormulario(solicitud.IdEstudiante, solicitud.Monto, fecha, solicitud.Metodo, 0)
+	if err != nil {
+		responderErrorInterno(w, "registrar pago", err)
+		return
+	}
+	w.Header().Set("Location", "/api/v1/pagos/"+strconv.Itoa(pago.IdPago))
+	responderJSON(w, http.StatusCreated, nuevoPagoAPI(pago))
+}
+
+// APIObtenerPago retorna un pago por su ID
+func (m *Controlador) APIObtenerPago(w http.ResponseWriter, r *http.Request) {
+	id, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+
+	pago, err := m.servicio.Repo.ObtenerPagoPorId(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		responderErrorAPI(w, http.StatusNotFound, "no_encontrado", "Pago no encontrado")
+		return
+	}
+	if err != nil {
+		responderErrorInterno(w, "obtener pago", err)
+		return
+	}
+	responderJSON(w, http.StatusOK, nuevoPagoAPI(pago))
+}
+
+// APIEliminarPago elimina un pago por su ID
+func (m *Controlador) APIEliminarPago(w http.ResponseWriter, r *http.Request) {
+	id, ok := idDeRuta(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := m.servicio.Repo.ObtenerPagoPorId(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responderErrorAPI(w, http.StatusNotFound, "no_encontrado", "Pago no encontrado")
+			return
+		}
+		responderErrorInterno(w, "obtener pago", err)
+		return
+	}
+
+	if err := m.servicio.AnularPago(id); err != nil {
+		responderErrorInterno(w, "eliminar pago", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
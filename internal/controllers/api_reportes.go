@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"kiosco/internal/utils"
+	"net/http"
+)
+
+// saldoAPI es el estado de cuenta semanal de un estudiante
+type saldoAPI struct {
+	IdEstudiante  int     `json:"id_estudiante"`
+	Nombres       string  `json:"nombres"`
+	Apellidos     string  `json:"apellidos"`
+	IdGrado       int     `json:"id_grado"`
+	ConsumoSemana float64 `json:"consumo_semana"` // Incluye cargos_plan
+	CargosPlan    float64 `json:"cargos_plan"`
+	DeudaAnterior float64 `json:"deuda_anterior"`
+	Pagos         float64 `json:"pagos"`
+	Saldo         float64 `json:"saldo"` // Positivo = el estudiante debe
+}
+
+// APIListarSaldos — GET /api/v1/saldos?fecha=&grado=&id_estudiante=&con_deuda=
+// Saldos de la semana escolar que contiene la fecha (hoy por defecto),
+// con el mismo cálculo que la vista principal.
+func (m *Controlador) APIListarSaldos(w http.ResponseWriter, r *http.Request) {
+	fecha, err := fechaAPI(r.URL.Query().Get("fecha"))
+	if err != nil {
+		responderErrorAPI(w, http.StatusBadRequest, "parametro_invalido", "fecha debe tener formato AAAA-MM-DD")
+		return
+	}
+	idGrado, ok := gradoDeConsulta(w, r)
+	if !ok {
+		return
+	}
+	idEstudiante, ok := estudianteDeConsulta(w, r)
+	if !ok {
+		return
+	}
+
+	inicio, fin := utils.CalcularSemanaDesdeFecha(fecha)
+	datos, err := m.servicio.ObtenerDatosVistaPrincipal(inicio, fin, idGrado, "")
+	if err != nil {
+		responderErrorInterno(w, "obtener saldos", err)
+		return
+	}
+
+	conDeuda := r.URL.Query().Get("con_deuda") == "true"
+	saldos := make([]saldoAPI, 0, len(datos.EstudiantesConData))
+	for _, e := range datos.EstudiantesConData {
+		if idEstudiante != 0 && e.IdEstudiante != idEstudiante {
+			continue
+		}
+		if conDeuda && e.Total <= 0 {
+			continue
+		}
+		saldos = append(saldos, saldoAPI{
+			IdEstudiante:  e.IdEstudiante,
+			Nombres:       e.Nombres,
+			Apellidos:     e.Apellidos,
+			IdGrado:       e.IdGrado,
+			ConsumoSemana: e.SubTotal,
+			CargosPlan:    e.CargosPlan,
+			DeudaAnterior: e.DeudaAnterior,
+			Pagos:         e.Descuento,
+			Saldo:         e.Total,
+		})
+	}
+
+	w.Header().Set("X-Semana-Desde", utils.FormatearFechaCompleta(inicio))
+	w.Header().Set("X-Semana-Hasta", utils.FormatearFechaCompleta(fin))
+	paginar(w, r, saldos)
+}
+
+// margenProductoAPI es una fila del reporte de margen por producto
+type margenProductoAPI struct {
+	IdProducto int     `json:"id_producto"`
+	Nombre     string  `json:"nombre"`
+	Cantidad   int     `json:"cantidad"`
+	Ingresos   float64 `json:"ingresos"`
+	Costo      float64 `json:"costo"`
+	Margen     float64 `json:"margen"`
+}
+
+// margenSemanaAPI es una fila del reporte de margen por semana
+type margenSemanaAPI struct {
+	Inicio       string  `json:"inicio"`
+	Ingresos     float64 `json:"ingresos"`
+	Costo        float64 `json:"costo"`
+	Margen       float64 `json:"margen"`
+	DiasAtencion int     `json:"dias_atencion"`
+	Contado      float64 `json:"contado"` // Ventas al contado, aparte de los ingresos
+}
+
+// reporteMargenAPI es la respuesta de GET /api/v1/reportes/margen
+type reporteMargenAPI struct {
+	Desde         string              `json:"desde"`
+	Hasta         string              `json:"hasta"`
+	TotalIngresos float64             `json:"total_ingresos"`
+	TotalCosto    float64             `json:"total_costo"`
+	TotalMargen   float64             `json:"total_margen"`
+	TotalCompras  float64             `json:"total_compras"`
+	TotalPlanes   float64             `json:"total_planes"`
+	PorProducto   []margenProductoAPI `json:"por_producto"`
+	PorSemana     []margenSemanaAPI   `json:"por_semana"`
+
+	// Ventas al contado (efectivo sin cuenta), separadas de los consumos
+	TotalContado       float64             `json:"total_contado"`
+	CostoContado       float64             `json:"costo_contado"`
+	MargenContado      float64             `json:"margen_contado"`
+	ContadoPorProducto []margenProductoAPI `json:"contado_por_producto"`
+}
+
+// APIReporteMargen — GET /api/v1/reportes/margen?desde=&hasta=
+func (m *Controlador) APIReporteMargen(w http.ResponseWriter, r *http.Request) {
+	desde, hasta, ok := rangoFechasAPI(w, r)
+	if !ok {
+		return
+	}
+
+	datos, err := m.servicio.ObtenerReporteMargen(desde, hasta)
+	if err != nil {
+		responderErrorInterno(w, "obtener reporte de margen", err)
+		return
+	}
+
+	reporte := reporteMargenAPI{
+		Desde:         utils.FormatearFechaCompleta(desde),
+		Hasta:         utils.FormatearFechaCompleta(hasta),
+		TotalIngresos: datos.TotalIngresos,
+		TotalCosto:    datos.TotalCosto,
+		TotalMargen:   datos.TotalMargen,
+		TotalCompras:  datos.TotalCompras,
+		TotalPlanes:   datos.TotalPlanes,
+		PorProducto:   make([]margenProductoAPI, 0, len(datos.PorProducto)),
+		PorSemana:     make([]margenSemanaAPI, 0, len(datos.PorPeriodo)),
+
+		TotalContado:       datos.TotalContado,
+		CostoContado:       datos.CostoContado,
+		MargenContado:      datos.MargenContado,
+		ContadoPorProducto: make([]margenProductoAPI, 0, len(datos.ContadoPorProducto)),
+	}
+	for _, p := range datos.PorProducto {
+		reporte.PorProducto = append(reporte.PorProducto, margenProductoAPI{
+			IdProducto: p.IdProducto,
+			Nombre:     p.NombreProducto,
+			Cantidad:   p.Cantidad,
+			Ingresos:   p.Ingresos,
+			Costo:      p.Costo,
+			Margen:     p.Margen,
+		})
+	}
+	for _, p := range datos.ContadoPorProducto {
+		reporte.ContadoPorProducto = append(reporte.ContadoPorProducto, margenProductoAPI{
+			IdProducto: p.IdProducto,
+			Nombre:     p.NombreProducto,
+			Cantidad:   p.Cantidad,
+			Ingresos:   p.Ingresos,
+			Costo:      p.Costo,
+			Margen:     p.Margen,
+		})
+	}
+	for _, p := range datos.PorPeriodo {
+		reporte.PorSemana = append(reporte.PorSemana, margenSemanaAPI{
+			Inicio:       utils.FormatearFechaCompleta(p.Inicio),
+			Ingresos:     p.Ingresos,
+			Costo:        p.Costo,
+			Margen:       p.Margen,
+			DiasAtencion: p.DiasAtencion,
+			Contado:      p.Contado,
+		})
+	}
+	responderJSON(w, http.StatusOK, reporte)
+}
+
+// descuentoEstudianteAPI es una fila del reporte de descuentos por estudiante
+type descuentoEstudianteAPI struct {
+	IdEstudiante int     `json:"id_estudiante"`
+	Nombre       string  `json:"nombre"`
+	NombreGrado  string  `json:"nombre_grado"`
+	Bruto        float64 `json:"bruto"`
+	Descuento    float64 `json:"descuento"`
+	Neto         float64 `json:"neto"`
+}
+
+// descuentoReglaAPI es una fila del reporte de descuentos por regla
+type descuentoReglaAPI struct {
+	IdRegla   int     `json:"id_regla"`
+	Nombre    string  `json:"nombre"`
+	Lineas    int     `json:"lineas"`
+	Descuento float64 `json:"descuento"`
+}
+
+// reporteDescuentosAPI es la respuesta de GET /api/v1/reportes/descuentos
+type reporteDescuentosAPI struct {
+	Desde          string                   `json:"desde"`
+	Hasta          string                   `json:"hasta"`
+	TotalDescuento float64                  `json:"total_descuento"`
+	PorEstudiante  []descuentoEstudianteAPI `json:"por_estudiante"`
+	PorRegla       []descuentoReglaAPI      `json:"por_regla"`
+}
+
+// APIReporteDescuentos — GET /api/v1/reportes/descuentos?desde=&hasta=
+func (m *Controlador) APIReporteDescuentos(w http.ResponseWriter, r *http.Request) {
+	desde, hasta, ok := rangoFechasAPI(w, r)
+	if !ok {
+		return
+	}
+
+	datos, err := m.servicio.ObtenerReporteDescuentos(desde, hasta)
+	if err != nil {
+		responderErrorInterno(w, "obtener reporte de descuentos", err)
+		return
+	}
+
+	reporte := reporteDescuentosAPI{
+		Desde:          utils.FormatearFechaCompleta(desde),
+		Hasta:          utils.FormatearFechaCompleta(hasta),
+		TotalDescuento: datos.TotalDescuento,
+		PorEstudiante:  make([]descuentoEstudianteAPI, 0, len(datos.PorEstudiante)),
+		PorRegla:       make([]descuentoReglaAPI, 0, len(datos.PorRegla)),
+	}
+	for _, d := range datos.PorEstudiante {
+		reporte.PorEstudiante = append(reporte.PorEstudiante, descuentoEstudianteAPI{
+			IdEstudiante: d.IdEstudiante,
+			Nombre:       d.NombreEstudiante,
+			NombreGrado:  d.NombreGrado,
+			Bruto:        d.Bruto,
+			Descuento:    d.Descuento,
+			Neto:         d.Neto,
+		})
+	}
+	for _, d := range datos.PorRegla {
+		reporte.PorRegla = append(reporte.PorRegla, descuentoReglaAPI{
+			IdRegla:   d.IdRegla,
+			Nombre:    d.Nombre,
+			Lineas:    d.Lineas,
+			Descuento: d.Descuento,
+		})
+	}
+	responderJSON(w, http.StatusOK, reporte)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaginar(t *testing.T) {
+	datos := []int{1, 2, 3, 4, 5}
+	casos := []struct {
+		consulta string
+		esperado []int
+	}{
+		{"", []int{1, 2, 3, 4, 5}},
+		{"?pagina=2&por_pagina=2", []int{3, 4}},
+		{"?pagina=3&por_pagina=2", []int{5}},
+		{"?pagina=4&por_pagina=2", []int{}},
+		// (pagina-1)*por_pagina desbordaría a un negativo
+		{"?pagina=9223372036854775807&por_pagina=2", []int{}},
+	}
+
+	for _, caso := range casos {
+		w := httptest.NewRecorder()
+		paginar(w, httptest.NewRequest(http.MethodGet, "/api/v1/productos"+caso.consulta, nil), datos)
+		if w.Code != http.StatusOK {
+			t.Errorf("%q: estado %d, se esperaba 200", caso.consulta, w.Code)
+			continue
+		}
+		var respuesta struct {
+			Datos      []int      `json:"datos"`
+			Paginacion Paginacion `json:"paginacion"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &respuesta); err != nil {
+			t.Fatalf("%q: %v", caso.consulta, err)
+		}
+		if respuesta.Datos == nil || len(respuesta.Datos) != len(caso.esperado) {
+			t.Errorf("%q: datos = %v, se esperaba %v", caso.consulta, respuesta.Datos, caso.esperado)
+			continue
+		}
+		for i := range caso.esperado {
+			if respuesta.Datos[i] != caso.esperado[i] {
+				t.Errorf("%q: datos = %v, se esperaba %v", caso.consulta, respuesta.Datos, caso.esperado)
+				break
+			}
+		}
+		if respuesta.Paginacion.Total != len(datos) {
+			t.Errorf("%q: total = %d, se esperaba %d", caso.consulta, respuesta.Paginacion.Total, len(datos))
+		}
+	}
+}
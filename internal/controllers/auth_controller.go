@@ -16,7 +16,7 @@ import (
 // También maneja errores de seguridad (CSRF, rate limit).
 func (m *Controlador) MostrarLogin(w http.ResponseWriter, r *http.Request) {
 	if cookie, err := r.Cookie(auth.CookieNombre); err == nil {
-		if _, _, ok := auth.VerificarToken(cookie.Value); ok {
+		if _, _, ok := auth.VerificarToken(middleware.Colegio(r), cookie.Value); ok {
 			http.Redirect(w, r, "/", http.StatusSeeOther)
 			return
 		}
@@ -78,7 +78,7 @@ func (m *Controlador) ProcesarLogin(w http.ResponseWriter, r *http.Request) {
 	// Reset rate limit al login exitoso
 	middleware.ResetearRateLimitLogin(r)
 
-	token := auth.FirmarToken(u.IdUsuario, u.PuedeEditar)
+	token := auth.FirmarToken(middleware.Colegio(r), u.IdUsuario, u.PuedeEditar)
 	http.SetCookie(w, &http.Cookie{
 		Name:     auth.CookieNombre,
 		Value:    token,
@@ -1,13 +1,51 @@
 package controllers
 
-import "kiosco/internal/services"
+import (
+	"context"
+	"kiosco/internal/services"
+)
 
 // Controlador contiene las dependencias para los controladores
 type Controlador struct {
 	servicio *services.Servicio
+	colegio  string // "" con un solo colegio
+
+	// Todos los colegios del binario (incluido este), para /superadmin
+	colegios []*Controlador
 }
 
 // NuevoControlador crea una instancia del controlador con su servicio.
 func NuevoControlador() (*Controlador, error) {
-	return &Controlador{servicio: services.NuevoServicio()}, nil
+	m := &Controlador{servicio: services.NuevoServicio()}
+	m.colegios = []*Controlador{m}
+	return m, nil
+}
+
+// NuevoControladorColegio crea el controlador de uno de los colegios del
+// binario, con su propia base de datos.
+func NuevoControladorColegio(nombre, urlPublica string) (*Controlador, error) {
+	return &Controlador{servicio: services.NuevoServicioColegio(nombre, urlPublica), colegio: nombre}, nil
+}
+
+// EnlazarColegios da a cada controlador la lista de todos los colegios, que la
+// vista de superadministrador recorre para sumar sus ventas.
+func EnlazarColegios(controladores []*Controlador) {
+	for _, m := range controladores {
+		m.colegios = controladores
+	}
+}
+
+// Colegio retorna el nombre del colegio del controlador ("" con uno solo)
+func (m *Controlador) Colegio() string {
+	return m.colegio
+}
+
+// IniciarTareas arranca los procesos en segundo plano (bandejas de salida de webhooks, correos y mensajes,
+// cargos de planes de alimentación y purga de claves de idempotencia). Se detienen al cancelar ctx.
+func (m *Controlador) IniciarTareas(ctx context.Context) {
+	m.servicio.IniciarDespachoWebhooks(ctx)
+	m.servicio.IniciarEnvioCorreos(ctx)
+	m.servicio.IniciarEnvioMensajes(ctx)
+	m.servicio.IniciarGeneracionCargos(ctx)
+	m.servicio.IniciarPurgaIdempotencia(ctx)
 }
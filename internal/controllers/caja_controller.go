@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"database/sql"
+	"kiosco/internal/middleware"
+	"kiosco/internal/models"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Caja — GET /caja
+// Caja del usuario actual: abrirla con el fondo inicial o, si está abierta,
+// ver el efectivo del turno y cerrarla con el arqueo
+func (m *Controlador) Caja(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosCaja(usuarioActual(r), puedeEditar(r))
+	if err != nil {
+		log.Printf("Error al obtener caja: %v", err)
+		http.Error(w, "Error al cargar la caja", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Caja(datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar caja: %v", err)
+	}
+}
+
+// AbrirCaja — POST /caja/abrir
+// Campo monto_inicial: el efectivo con que empieza el turno
+func (m *Controlador) AbrirCaja(w http.ResponseWriter, r *http.Request) {
+	montoInicial := r.FormValue("monto_inicial")
+	if err := m.servicio.AbrirCaja(usuarioActual(r), montoInicial); err != nil {
+		m.mostrarErrorCaja(w, r, models.DatosCaja{MontoInicial: montoInicial}, "No se abrió la caja: "+err.Error()+".")
+		return
+	}
+
+	http.Redirect(w, r, "/caja", http.StatusSeeOther)
+}
+
+// CerrarCaja — POST /caja/cerrar
+// Campos monto_contado y notas. Al cerrar muestra el reporte para imprimir.
+func (m *Controlador) CerrarCaja(w http.ResponseWriter, r *http.Request) {
+	montoContado, notas := r.FormValue("monto_contado"), r.FormValue("notas")
+	idSesion, err := m.servicio.CerrarCaja(usuarioActual(r), montoContado, notas)
+	if err != nil {
+		m.mostrarErrorCaja(w, r, models.DatosCaja{MontoContado: montoContado, Notas: notas}, "No se cerró la caja: "+err.Error()+".")
+		return
+	}
+
+	http.Redirect(w, r, "/caja/sesion?id="+strconv.Itoa(idSesion), http.StatusSeeOther)
+}
+
+// mostrarErrorCaja vuelve a mostrar la caja con lo enviado y el motivo del rechazo
+func (m *Controlador) mostrarErrorCaja(w http.ResponseWriter, r *http.Request, enviado models.DatosCaja, aviso string) {
+	datos, err := m.servicio.ObtenerDatosCaja(usuarioActual(r), puedeEditar(r))
+	if err != nil {
+		log.Printf("Error al obtener caja: %v", err)
+		http.Error(w, "Error al cargar la caja", http.StatusInternalServerError)
+		return
+	}
+	datos.Error = aviso
+	datos.MontoInicial = enviado.MontoInicial
+	datos.MontoContado = enviado.MontoContado
+	datos.Notas = enviado.Notas
+
+	// El formulario necesita el token CSRF y esta ruta no lo inyecta
+	ctx := middleware.InyectarCSRFToken(w, r)
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	if err := pages.Caja(datos).Render(ctx, w); err != nil {
+		log.Printf("Error al renderizar caja: %v", err)
+	}
+}
+
+// ReporteCaja — GET /caja/sesion?id=
+// Reporte de una sesión de caja, listo para imprimir. El cajero ve las suyas;
+// con permiso de edición se ven todas.
+func (m *Controlador) ReporteCaja(w http.ResponseWriter, r *http.Request) {
+	idSesion, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID de sesión inválido", http.StatusBadRequest)
+		return
+	}
+
+	datos, err := m.servicio.ObtenerReporteCaja(idSesion, usuarioActual(r), puedeEditar(r))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Sesión de caja no encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al obtener reporte de caja: %v", err)
+		http.Error(w, "Error al cargar el reporte de caja", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.ReporteCaja(datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar reporte de caja: %v", err)
+	}
+}
+
+// SesionesCaja — GET /caja/sesiones?desde=&hasta=
+// Sesiones de caja de todos los cajeros para revisión; por defecto el mes en curso
+func (m *Controlador) SesionesCaja(w http.ResponseWriter, r *http.Request) {
+	desde, hasta := rangoReporte(r)
+
+	datos, err := m.servicio.ObtenerSesionesCaja(desde, hasta)
+	if err != nil {
+		log.Printf("Error al obtener sesiones de caja: %v", err)
+		http.Error(w, "Error al cargar las sesiones de caja", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.SesionesCaja(datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar sesiones de caja: %v", err)
+	}
+}
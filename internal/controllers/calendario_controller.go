@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calendario muestra los días de atención y los feriados/vacaciones de un año
+func (m *Controlador) Calendario(w http.ResponseWriter, r *http.Request) {
+	anio := utils.Hoy().Year()
+	if v := r.URL.Query().Get("anio"); v != "" {
+		a, err := strconv.Atoi(v)
+		if err != nil || a < 2000 || a > 2100 {
+			http.Error(w, "Año inválido", http.StatusBadRequest)
+			return
+		}
+		anio = a
+	}
+
+	datos, err := m.servicio.ObtenerDatosCalendario(anio)
+	if err != nil {
+		log.Printf("Error al obtener calendario: %v", err)
+		http.Error(w, "Error al cargar calendario", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Calendario(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar calendario: %v", err)
+	}
+}
+
+// CrearDiaNoLaborable registra un feriado (sin fecha_hasta) o un rango de vacaciones
+func (m *Controlador) CrearDiaNoLaborable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	desde, err := utils.ParsearFecha(r.FormValue("fecha_desde"))
+	if err != nil {
+		http.Error(w, "Fecha de inicio inválida", http.StatusBadRequest)
+		return
+	}
+	hasta := desde
+	if v := r.FormValue("fecha_hasta"); v != "" {
+		if hasta, err = utils.ParsearFecha(v); err != nil {
+			http.Error(w, "Fecha de término inválida", http.StatusBadRequest)
+			return
+		}
+	}
+
+	dia := models.DiaNoLaborable{
+		FechaDesde:  desde,
+		FechaHasta:  hasta,
+		Tipo:        r.FormValue("tipo"),
+		Descripcion: strings.TrimSpace(r.FormValue("descripcion")),
+	}
+	if err := m.servicio.CrearDiaNoLaborable(dia); err != nil {
+		log.Printf("Error al crear día no laborable: %v", err)
+		http.Error(w, "Error al guardar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/calendario?anio="+strconv.Itoa(desde.Year()), http.StatusSeeOther)
+}
+
+// EliminarDiaNoLaborable quita un feriado o rango del calendario
+func (m *Controlador) EliminarDiaNoLaborable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idDia, err := strconv.Atoi(r.FormValue("id_dia"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.Repo.EliminarDiaNoLaborable(idDia); err != nil {
+		log.Printf("Error al eliminar día no laborable %d: %v", idDia, err)
+		http.Error(w, "Error al eliminar", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/calendario?anio="+r.FormValue("anio"), http.StatusSeeOther)
+}
+
+// GuardarDiasLaborables actualiza los días de atención de la semana (campos "dia" repetidos)
+func (m *Controlador) GuardarDiasLaborables(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	var dias []time.Weekday
+	for _, v := range r.Form["dia"] {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Día inválido", http.StatusBadRequest)
+			return
+		}
+		dias = append(dias, time.Weekday(n))
+	}
+
+	if err := m.servicio.GuardarDiasLaborables(dias); err != nil {
+		log.Printf("Error al guardar días laborables: %v", err)
+		http.Error(w, "Error al guardar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/calendario", http.StatusSeeOther)
+}
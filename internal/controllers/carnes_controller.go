@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Carnes — GET /setup/carnes?grado=
+// Hojas A4 de carnés con QR de los estudiantes activos, listas para imprimir
+// desde el navegador. Sin grado (o con 0) incluye todos.
+func (m *Controlador) Carnes(w http.ResponseWriter, r *http.Request) {
+	idGrado := 0
+	if g := r.URL.Query().Get("grado"); g != "" {
+		var err error
+		if idGrado, err = strconv.Atoi(g); err != nil || idGrado < 0 {
+			http.Error(w, "Grado inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	datos, err := m.servicio.ObtenerDatosCarnes(idGrado)
+	if err != nil {
+		log.Printf("Error al obtener carnés: %v", err)
+		http.Error(w, "Error al cargar los carnés", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Carnes(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar carnés: %v", err)
+	}
+}
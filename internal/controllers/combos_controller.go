@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Combos muestra los productos combo con sus componentes
+func (m *Controlador) Combos(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosCombos()
+	if err != nil {
+		log.Printf("Error al obtener combos: %v", err)
+		http.Error(w, "Error al cargar combos", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Combos(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar combos: %v", err)
+	}
+}
+
+// GuardarComponenteCombo agrega o quita (accion=quitar) un componente de un combo
+func (m *Controlador) GuardarComponenteCombo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idCombo, errCombo := strconv.Atoi(r.FormValue("id_combo"))
+	idComponente, errComponente := strconv.Atoi(r.FormValue("id_componente"))
+	if errCombo != nil || errComponente != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if r.FormValue("accion") == "quitar" {
+		if err := m.servicio.Repo.QuitarComponenteCombo(idCombo, idComponente); err != nil {
+			log.Printf("Error al quitar componente %d del combo %d: %v", idComponente, idCombo, err)
+			http.Error(w, "Error al quitar componente", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/setup/combos", http.StatusSeeOther)
+		return
+	}
+
+	cantidad, err := strconv.Atoi(r.FormValue("cantidad"))
+	if err != nil {
+		http.Error(w, "Cantidad inválida", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.AgregarComponenteCombo(idCombo, idComponente, cantidad); err != nil {
+		log.Printf("Error al agregar componente: %v", err)
+		http.Error(w, "Error al agregar componente: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/combos", http.StatusSeeOther)
+}
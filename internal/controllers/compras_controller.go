@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Compras muestra el registro de compras a proveedores
+func (m *Controlador) Compras(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosCompras()
+	if err != nil {
+		log.Printf("Error al obtener compras: %v", err)
+		http.Error(w, "Error al cargar compras", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Compras(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar compras: %v", err)
+	}
+}
+
+// RegistrarCompra procesa el formulario de compra con sus líneas (campos repetidos)
+func (m *Controlador) RegistrarCompra(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idProveedor, err := strconv.Atoi(r.FormValue("id_proveedor"))
+	if err != nil {
+		http.Error(w, "Proveedor inválido", http.StatusBadRequest)
+		return
+	}
+
+	fecha, err := utils.ParsearFecha(r.FormValue("fecha_compra"))
+	if err != nil {
+		http.Error(w, "Fecha inválida", http.StatusBadRequest)
+		return
+	}
+
+	productos := r.Form["id_producto"]
+	cantidades := r.Form["cantidad"]
+	costos := r.Form["costo_unitario"]
+	if len(productos) != len(cantidades) || len(productos) != len(costos) {
+		http.Error(w, "Ítems incompletos", http.StatusBadRequest)
+		return
+	}
+
+	items := make([]models.ItemCompra, 0, len(productos))
+	for i := range productos {
+		idProducto, errProducto := strconv.Atoi(productos[i])
+		cantidad, errCantidad := strconv.Atoi(cantidades[i])
+		costo, errCosto := strconv.ParseFloat(costos[i], 64)
+		if errProducto != nil || errCantidad != nil || errCosto != nil {
+			http.Error(w, "Ítem inválido", http.StatusBadRequest)
+			return
+		}
+		items = append(items, models.ItemCompra{
+			IdProducto:    idProducto,
+			Cantidad:      cantidad,
+			CostoUnitario: costo,
+		})
+	}
+
+	nota := strings.TrimSpace(r.FormValue("nota"))
+	if err := m.servicio.RegistrarCompraDesdeFormulario(idProveedor, fecha, nota, items); err != nil {
+		log.Printf("Error al registrar compra: %v", err)
+		http.Error(w, "Error al registrar compra: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/compras", http.StatusSeeOther)
+}
+
+// AgregarProveedor inserta un proveedor desde la página de compras
+func (m *Controlador) AgregarProveedor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	nombre := strings.TrimSpace(r.FormValue("nombre"))
+	telefono := strings.TrimSpace(r.FormValue("telefono"))
+	if nombre == "" {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := m.servicio.Repo.InsertarProveedor(nombre, telefono); err != nil {
+		log.Printf("Error al insertar proveedor: %v", err)
+		http.Error(w, "Error al agregar proveedor", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/compras", http.StatusSeeOther)
+}
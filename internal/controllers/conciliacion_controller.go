@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const maxExtracto = 2 << 20 // 2 MB: un extracto mensual ocupa bastante menos
+
+// Conciliacion muestra los abonos de extractos pendientes de conciliar
+func (m *Controlador) Conciliacion(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosConciliacion()
+	if err != nil {
+		log.Printf("Error al obtener conciliación: %v", err)
+		http.Error(w, "Error al cargar la conciliación", http.StatusInternalServerError)
+		return
+	}
+	datos.Aviso = r.URL.Query().Get("aviso")
+
+	if err := pages.Conciliacion(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar conciliación: %v", err)
+	}
+}
+
+// ImportarExtracto recibe un CSV de banco o billetera (campo "archivo") y deja
+// sus abonos pendientes de conciliar
+func (m *Controlador) ImportarExtracto(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxExtracto); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	archivo, cabecera, err := r.FormFile("archivo")
+	if err != nil {
+		http.Error(w, "Seleccione un archivo CSV", http.StatusBadRequest)
+		return
+	}
+	defer archivo.Close()
+	if cabecera.Size > maxExtracto {
+		http.Error(w, "El archivo es demasiado grande", http.StatusRequestEntityTooLarge)
+		return
+	}
+	contenido, err := io.ReadAll(archivo)
+	if err != nil {
+		http.Error(w, "Error al leer el archivo", http.StatusBadRequest)
+		return
+	}
+
+	extracto, err := m.servicio.ImportarExtracto(cabecera.Filename, r.FormValue("origen"), contenido)
+	if err != nil {
+		http.Error(w, "Error al importar extracto: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	aviso := fmt.Sprintf("%d abono(s) importado(s)", extracto.Lineas)
+	if extracto.Repetidas > 0 {
+		aviso += fmt.Sprintf(", %d ya estaban en otro extracto", extracto.Repetidas)
+	}
+	http.Redirect(w, r, "/setup/conciliacion?aviso="+url.QueryEscape(aviso), http.StatusSeeOther)
+}
+
+// AccionMovimiento confirma (crea el pago), descarta o reabre un abono (campo "accion")
+func (m *Controlador) AccionMovimiento(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idMovimiento, err := strconv.Atoi(r.FormValue("id_movimiento"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	var ok bool
+	switch r.FormValue("accion") {
+	case "confirmar":
+		idEstudiante, _ := strconv.Atoi(r.FormValue("id_estudiante"))
+		_, err = m.servicio.ConfirmarMovimiento(idMovimiento, idEstudiante)
+		ok = !errors.Is(err, sql.ErrNoRows)
+		if !ok {
+			err = nil
+		}
+	case "descartar":
+		ok, err = m.servicio.DescartarMovimiento(idMovimiento)
+	case "reabrir":
+		ok, err = m.servicio.ReabrirMovimiento(idMovimiento)
+	default:
+		http.Error(w, "Acción inválida", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al conciliar abono %d (%s): %v", idMovimiento, r.FormValue("accion"), err)
+		http.Error(w, "Error al conciliar el abono: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		http.Error(w, "El abono ya fue conciliado", http.StatusConflict)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/conciliacion", http.StatusSeeOther)
+}
+
+// ConfirmarSugeridos crea los pagos de todos los abonos con estudiante sugerido
+func (m *Controlador) ConfirmarSugeridos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	confirmados, err := m.servicio.ConfirmarSugeridos()
+	if err != nil {
+		log.Printf("Error al confirmar abonos sugeridos: %v", err)
+		http.Error(w, fmt.Sprintf("Error tras confirmar %d abono(s): %v", confirmados, err), http.StatusInternalServerError)
+		return
+	}
+
+	aviso := fmt.Sprintf("%d pago(s) registrado(s)", confirmados)
+	http.Redirect(w, r, "/setup/conciliacion?aviso="+url.QueryEscape(aviso), http.StatusSeeOther)
+}
@@ -1,13 +1,18 @@
 package controllers
 
 import (
+	"database/sql"
 	"fmt"
-	"kiosco/internal/auth"
+	"kiosco/internal/middleware"
 	"kiosco/internal/models"
+	"kiosco/internal/services"
+	"kiosco/internal/utils"
 	"kiosco/templates/pages"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -40,18 +45,23 @@ func (m *Controlador) RegistrarConsumo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Cantidad inválida", http.StatusBadRequest)
 		return
 	}
+	if err := m.servicio.ValidarCantidadConsumo(cantidad); err != nil {
+		http.Error(w, "Cantidad inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	fechaStr := r.FormValue("fecha")
-	fecha, err := time.Parse("2006-01-02", fechaStr)
+	fecha, err := utils.ParsearFecha(fechaStr)
 	if err != nil {
 		http.Error(w, "Fecha inválida", http.StatusBadRequest)
 		return
 	}
 
-	if err := m.servicio.RegistrarConsumoDesdeFormulario(idEstudiante, idProducto, cantidad, fecha); err != nil {
-		log.Printf("Error al registrar consumo: %v", err)
-		http.Error(w, "Error al registrar consumo", http.StatusInternalServerError)
-		return
+	if cantidad > 0 {
+		if err := m.servicio.ValidarDiaLaborable(fecha); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
 
 	grado := r.FormValue("grado")
@@ -59,6 +69,26 @@ func (m *Controlador) RegistrarConsumo(w http.ResponseWriter, r *http.Request) {
 	if grado != "" {
 		urlRedireccion += "&grado=" + grado
 	}
+
+	clave := r.FormValue("clave_idempotencia")
+	nueva, err := m.servicio.ReclamarSolicitud(clave, r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !nueva {
+		// Ya se registró: es un reenvío tras volver la conexión o un doble clic
+		http.Redirect(w, r, urlRedireccion, http.StatusSeeOther)
+		return
+	}
+
+	if err := m.servicio.RegistrarConsumoDesdeFormulario(idEstudiante, idProducto, cantidad, fecha); err != nil {
+		m.servicio.LiberarSolicitud(clave)
+		log.Printf("Error al registrar consumo: %v", err)
+		http.Error(w, "Error al registrar consumo", http.StatusInternalServerError)
+		return
+	}
+
 	http.Redirect(w, r, urlRedireccion, http.StatusSeeOther)
 }
 
@@ -71,7 +101,7 @@ func (m *Controlador) EditarConsumos(w http.ResponseWriter, r *http.Request) {
 	}
 
 	fechaStr := r.URL.Query().Get("fecha")
-	fecha, err := time.Parse("2006-01-02", fechaStr)
+	fecha, err := utils.ParsearFecha(fechaStr)
 	if err != nil {
 		http.Error(w, "Fecha inválida", http.StatusBadRequest)
 		return
@@ -85,37 +115,43 @@ func (m *Controlador) EditarConsumos(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sector := r.URL.Query().Get("sector")
-	if sector != "" && sector != "menor" && sector != "mayor" {
-		http.Error(w, "Sector inválido: debe ser 'menor' o 'mayor'", http.StatusBadRequest)
+	if sector != "" && !services.SectorValido(sector) {
+		http.Error(w, "Sector inválido: debe ser 'menor', 'mayor' o 'personal'", http.StatusBadRequest)
 		return
 	}
 
-	estudiantes, err := m.servicio.Repo.ObtenerEstudiantesPorGrado(0)
+	datos, err := m.datosEditarConsumos(r, idEstudiante, fecha, idGrado, sector, nil)
 	if err != nil {
-		http.Error(w, "Error al obtener estudiante", http.StatusInternalServerError)
+		log.Printf("Error al cargar edición de consumos: %v", err)
+		http.Error(w, "Error al obtener consumos", http.StatusInternalServerError)
 		return
 	}
 
-	var nombreEstudiante string
-	for _, est := range estudiantes {
-		if est.IdEstudiante == idEstudiante {
-			nombreEstudiante = est.Apellidos + ", " + est.Nombres
-			break
-		}
+	if err := pages.EditarConsumos(datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar editar_consumos: %v", err)
 	}
+}
 
-	productos, err := m.servicio.Repo.ObtenerProductosActivos()
-	if err != nil {
-		http.Error(w, "Error al obtener productos", http.StatusInternalServerError)
-		return
+// datosEditarConsumos arma los datos del formulario de un día. enviadas (id_producto
+// → cantidad), si no es nil, reemplaza a las cantidades guardadas: se usa para volver
+// a mostrar un formulario rechazado con lo que se había enviado.
+func (m *Controlador) datosEditarConsumos(r *http.Request, idEstudiante int, fecha time.Time, idGrado int, sector string, enviadas map[int]int) (models.DatosEditarConsumos, error) {
+	var datos models.DatosEditarConsumos
+
+	// Por ID: la cuenta puede ser de personal, que no aparece en las listas por grado
+	var nombreEstudiante string
+	est, err := m.servicio.Repo.ObtenerEstudiantePorId(idEstudiante)
+	if err == nil {
+		nombreEstudiante = est.Apellidos + ", " + est.Nombres
+	} else if err != sql.ErrNoRows {
+		return datos, fmt.Errorf("error al obtener estudiante: %v", err)
 	}
 
 	fechaInicio := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, fecha.Location())
 	fechaFin := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 23, 59, 59, 0, fecha.Location())
 	consumos, err := m.servicio.Repo.ObtenerConsumosSemana(fechaInicio, fechaFin)
 	if err != nil {
-		http.Error(w, "Error al obtener consumos", http.StatusInternalServerError)
-		return
+		return datos, fmt.Errorf("error al obtener consumos: %v", err)
 	}
 
 	consumosPorDia := make(map[int]map[string]map[int]int)
@@ -130,12 +166,36 @@ func (m *Controlador) EditarConsumos(w http.ResponseWriter, r *http.Request) {
 		consumosPorDia[c.IdEstudiante][fechaKey][c.IdProducto] = c.Cantidad
 	}
 
-	puedeEditar := false
-	if cookie, err := r.Cookie(auth.CookieNombre); err == nil {
-		_, puedeEditar, _ = auth.VerificarToken(cookie.Value)
+	fechaKey := fecha.Format("2006-01-02")
+	if len(enviadas) > 0 {
+		if consumosPorDia[idEstudiante] == nil {
+			consumosPorDia[idEstudiante] = make(map[string]map[int]int)
+		}
+		if consumosPorDia[idEstudiante][fechaKey] == nil {
+			consumosPorDia[idEstudiante][fechaKey] = make(map[int]int)
+		}
+		for idProducto, cantidad := range enviadas {
+			consumosPorDia[idEstudiante][fechaKey][idProducto] = cantidad
+		}
+	}
+
+	// Productos del menú del día más los que el estudiante ya consumió
+	productos, err := m.servicio.ObtenerProductosEdicion(idEstudiante, fecha, consumosPorDia[idEstudiante][fechaKey])
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	calendario, err := m.servicio.ObtenerCalendario(fecha, fecha)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener calendario: %v", err)
 	}
 
-	datos := models.DatosEditarConsumos{
+	version, err := m.servicio.Repo.ObtenerVersionConsumosDia(idEstudiante, fecha)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener versión de consumos: %v", err)
+	}
+
+	return models.DatosEditarConsumos{
 		IdEstudiante:      idEstudiante,
 		NombreEstudiante:  nombreEstudiante,
 		Fecha:             fecha,
@@ -143,12 +203,10 @@ func (m *Controlador) EditarConsumos(w http.ResponseWriter, r *http.Request) {
 		Consumos:          consumosPorDia,
 		GradoSeleccionado: idGrado,
 		Sector:            sector,
-		PuedeEditar:       puedeEditar,
-	}
-
-	if err := pages.EditarConsumos(datos).Render(r.Context(), w); err != nil {
-		log.Printf("Error al renderizar editar_consumos: %v", err)
-	}
+		PuedeEditar:       puedeEditar(r),
+		MotivoCierre:      calendario.MotivoCierre(fecha),
+		Version:           version,
+	}, nil
 }
 
 // GuardarConsumosDia guarda todos los consumos de un día
@@ -170,7 +228,7 @@ func (m *Controlador) GuardarConsumosDia(w http.ResponseWriter, r *http.Request)
 	}
 
 	fechaStr := r.FormValue("fecha")
-	fecha, err := time.Parse("2006-01-02", fechaStr)
+	fecha, err := utils.ParsearFecha(fechaStr)
 	if err != nil {
 		http.Error(w, "Fecha inválida", http.StatusBadRequest)
 		return
@@ -179,28 +237,18 @@ func (m *Controlador) GuardarConsumosDia(w http.ResponseWriter, r *http.Request)
 	grado := r.FormValue("grado")
 
 	sector := r.FormValue("sector")
-	if sector != "" && sector != "menor" && sector != "mayor" {
-		http.Error(w, "Sector inválido: debe ser 'menor' o 'mayor'", http.StatusBadRequest)
+	if sector != "" && !services.SectorValido(sector) {
+		http.Error(w, "Sector inválido: debe ser 'menor', 'mayor' o 'personal'", http.StatusBadRequest)
 		return
 	}
 
-	productos, err := m.servicio.Repo.ObtenerProductosActivos()
-	if err != nil {
-		http.Error(w, "Error al obtener productos", http.StatusInternalServerError)
+	// Días sin atención: se rechaza el formulario completo (los consumos en cero
+	// se siguen aceptando para poder anular registros previos)
+	if err := m.servicio.ValidarDiaLaborable(fecha); err != nil && formularioTieneConsumos(r) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	for _, producto := range productos {
-		cantidadStr := r.FormValue(fmt.Sprintf("cantidad_%d", producto.IdProducto))
-		cantidad, err := strconv.Atoi(cantidadStr)
-		if err != nil {
-			cantidad = 0
-		}
-		if err := m.servicio.RegistrarConsumoDesdeFormulario(idEstudiante, producto.IdProducto, cantidad, fecha); err != nil {
-			log.Printf("Error al registrar consumo producto %d: %v", producto.IdProducto, err)
-		}
-	}
-
 	var urlRedireccion string
 	if sector != "" {
 		urlRedireccion = "/registro/" + sector + "?fecha=" + fechaStr
@@ -210,5 +258,147 @@ func (m *Controlador) GuardarConsumosDia(w http.ResponseWriter, r *http.Request)
 			urlRedireccion += "&grado=" + grado
 		}
 	}
+
+	clave := r.FormValue("clave_idempotencia")
+	if err := services.ValidarClaveIdempotencia(clave); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Solo se actualizan los productos presentes en el formulario; los que
+	// quedaron fuera del menú del día conservan sus consumos
+	valores := make(map[int]string)
+	for campo := range r.Form {
+		sufijo, ok := strings.CutPrefix(campo, "cantidad_")
+		if !ok {
+			continue
+		}
+		idProducto, err := strconv.Atoi(sufijo)
+		if err != nil {
+			http.Error(w, "Campo de cantidad inválido: "+campo, http.StatusBadRequest)
+			return
+		}
+		valores[idProducto] = r.FormValue(campo)
+	}
+
+	cantidades, errores, err := m.servicio.ValidarCantidadesDia(valores)
+	if err != nil {
+		log.Printf("Error al validar consumos del día: %v", err)
+		http.Error(w, "Error al guardar consumos", http.StatusInternalServerError)
+		return
+	}
+	if len(errores) > 0 {
+		m.mostrarErroresConsumos(w, r, idEstudiante, fecha, grado, sector, valores, errores,
+			"No se guardó ningún cambio: revise las cantidades marcadas.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	resultado, err := m.servicio.GuardarConsumosDia(idEstudiante, fecha, r.FormValue("version"), clave, r.URL.Path, cantidades)
+	if err != nil {
+		log.Printf("Error al guardar consumos del día: %v", err)
+		errores := make(map[int]string)
+		if resultado.IdProductoFallido != 0 {
+			errores[resultado.IdProductoFallido] = "No se pudo guardar este producto"
+		}
+		m.mostrarErroresConsumos(w, r, idEstudiante, fecha, grado, sector, valores, errores,
+			"No se guardó ningún cambio: ocurrió un error al guardar. Intente de nuevo.", http.StatusInternalServerError)
+		return
+	}
+
+	if resultado.Conflicto {
+		idGrado, _ := strconv.Atoi(grado)
+		datos := models.DatosConflictoConsumos{
+			IdEstudiante:      idEstudiante,
+			Fecha:             fecha,
+			GradoSeleccionado: idGrado,
+			Sector:            sector,
+			Version:           resultado.Version,
+		}
+		if est, err := m.servicio.Repo.ObtenerEstudiantePorId(idEstudiante); err == nil {
+			datos.NombreEstudiante = est.Apellidos + ", " + est.Nombres
+		}
+		productos, err := m.servicio.Repo.ObtenerTodosProductos()
+		if err != nil {
+			http.Error(w, "Error al obtener productos", http.StatusInternalServerError)
+			return
+		}
+		for _, producto := range productos {
+			if cantidad, ok := cantidades[producto.IdProducto]; ok {
+				datos.Filas = append(datos.Filas, models.FilaConflictoConsumo{
+					IdProducto: producto.IdProducto,
+					Nombre:     producto.Nombre,
+					Enviada:    cantidad,
+					Actual:     resultado.Anteriores[producto.IdProducto],
+				})
+			}
+		}
+		// Esta ruta no pasa por ProtegerEdicion: la página lleva su propio token CSRF
+		ctx := middleware.InyectarCSRFToken(w, r)
+		w.WriteHeader(http.StatusConflict)
+		if err := pages.ConflictoConsumos(datos).Render(ctx, w); err != nil {
+			log.Printf("Error al renderizar conflicto de consumos: %v", err)
+		}
+		return
+	}
+
+	// Guardado, o ya se había guardado con esta clave (reenvío tras volver la
+	// conexión o doble clic): en ambos casos se vuelve a la misma vista
 	http.Redirect(w, r, urlRedireccion, http.StatusSeeOther)
 }
+
+// mostrarErroresConsumos vuelve a mostrar el formulario de un día con lo enviado,
+// los errores por producto y el aviso general. No se guardó nada.
+func (m *Controlador) mostrarErroresConsumos(w http.ResponseWriter, r *http.Request, idEstudiante int, fecha time.Time, grado, sector string, valores, errores map[int]string, aviso string, estado int) {
+	enviadas := make(map[int]int, len(valores))
+	for idProducto, valor := range valores {
+		if cantidad, err := strconv.Atoi(strings.TrimSpace(valor)); err == nil {
+			enviadas[idProducto] = cantidad
+		}
+	}
+
+	idGrado, _ := strconv.Atoi(grado)
+	datos, err := m.datosEditarConsumos(r, idEstudiante, fecha, idGrado, sector, enviadas)
+	if err != nil {
+		log.Printf("Error al cargar edición de consumos: %v", err)
+		http.Error(w, "Error al guardar consumos", http.StatusInternalServerError)
+		return
+	}
+	// Se conserva la versión enviada para que el nuevo intento siga detectando
+	// si otra persona editó el día mientras tanto
+	datos.Version = r.FormValue("version")
+	datos.Errores = errores
+
+	// Los errores de productos que no aparecen en el formulario van en el aviso
+	enFormulario := make(map[int]bool, len(datos.Productos))
+	for _, p := range datos.Productos {
+		enFormulario[p.IdProducto] = true
+	}
+	detalles := []string{aviso}
+	for idProducto, mensaje := range errores {
+		if !enFormulario[idProducto] {
+			detalles = append(detalles, mensaje+".")
+		}
+	}
+	sort.Strings(detalles[1:])
+	datos.ErrorGeneral = strings.Join(detalles, " ")
+
+	// El formulario necesita el token CSRF y esta ruta no lo inyecta
+	ctx := middleware.InyectarCSRFToken(w, r)
+	w.WriteHeader(estado)
+	if err := pages.EditarConsumos(datos).Render(ctx, w); err != nil {
+		log.Printf("Error al renderizar editar_consumos: %v", err)
+	}
+}
+
+// formularioTieneConsumos indica si algún campo cantidad_<id> trae una cantidad positiva
+func formularioTieneConsumos(r *http.Request) bool {
+	for campo, valores := range r.Form {
+		if !strings.HasPrefix(campo, "cantidad_") || len(valores) == 0 {
+			continue
+		}
+		if cantidad, err := strconv.Atoi(valores[0]); err == nil && cantidad > 0 {
+			return true
+		}
+	}
+	return false
+}
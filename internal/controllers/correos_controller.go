@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Correos muestra apoderados, ajustes de envío y el registro de correos (?estado=)
+func (m *Controlador) Correos(w http.ResponseWriter, r *http.Request) {
+	estado := r.URL.Query().Get("estado")
+	switch estado {
+	case "", models.CorreoPendiente, models.CorreoEnviado, models.CorreoFallido:
+	default:
+		http.Error(w, "Estado inválido", http.StatusBadRequest)
+		return
+	}
+
+	datos, err := m.servicio.ObtenerDatosCorreos(estado)
+	if err != nil {
+		log.Printf("Error al obtener correos: %v", err)
+		http.Error(w, "Error al cargar correos", http.StatusInternalServerError)
+		return
+	}
+
+	if n := r.URL.Query().Get("encolados"); n != "" {
+		datos.Aviso = n + " correo(s) en cola de envío"
+	}
+
+	if err := pages.Correos(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar correos: %v", err)
+	}
+}
+
+// AgregarApoderado registra el correo y/o teléfono de un apoderado de un estudiante
+func (m *Controlador) AgregarApoderado(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idEstudiante, err := strconv.Atoi(r.FormValue("id_estudiante"))
+	if err != nil {
+		http.Error(w, "Estudiante inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.AgregarApoderado(idEstudiante, r.FormValue("nombre"), r.FormValue("email"), r.FormValue("telefono")); err != nil {
+		log.Printf("Error al agregar apoderado: %v", err)
+		http.Error(w, "Error al guardar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/correos", http.StatusSeeOther)
+}
+
+// AccionApoderado suspende, reanuda o elimina un apoderado (campo "accion").
+// Correos y mensajes se suspenden por separado.
+func (m *Controlador) AccionApoderado(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idApoderado, err := strconv.Atoi(r.FormValue("id_apoderado"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	switch r.FormValue("accion") {
+	case "suspender":
+		err = m.servicio.Repo.CambiarRecepcionApoderado(idApoderado, false)
+	case "reanudar":
+		err = m.servicio.Repo.CambiarRecepcionApoderado(idApoderado, true)
+	case "suspender_mensajes":
+		err = m.servicio.Repo.CambiarRecepcionMensajes(idApoderado, false)
+	case "reanudar_mensajes":
+		err = m.servicio.Repo.CambiarRecepcionMensajes(idApoderado, true)
+	case "eliminar":
+		err = m.servicio.Repo.EliminarApoderado(idApoderado)
+	default:
+		http.Error(w, "Acción inválida", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error en apoderado %d (%s): %v", idApoderado, r.FormValue("accion"), err)
+		http.Error(w, "Error al actualizar apoderado", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/correos", http.StatusSeeOther)
+}
+
+// GuardarAjustesCorreos guarda el umbral de recordatorios y el envío automático semanal
+func (m *Controlador) GuardarAjustesCorreos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	umbral, err := strconv.ParseFloat(r.FormValue("umbral"), 64)
+	if err != nil {
+		http.Error(w, "Umbral inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.GuardarAjustesCorreos(umbral, r.FormValue("automatico") == "1"); err != nil {
+		log.Printf("Error al guardar ajustes de correo: %v", err)
+		http.Error(w, "Error al guardar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/correos", http.StatusSeeOther)
+}
+
+// EnviarCorreos encola estados de cuenta, recordatorios o un correo de prueba (campo "tipo")
+func (m *Controlador) EnviarCorreos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	var encolados int
+	var err error
+	switch r.FormValue("tipo") {
+	case models.CorreoEstadoCuenta:
+		fecha, errFecha := utils.ParsearFecha(r.FormValue("fecha"))
+		if errFecha != nil {
+			http.Error(w, "Fecha inválida", http.StatusBadRequest)
+			return
+		}
+		idGrado, _ := strconv.Atoi(r.FormValue("grado"))
+		encolados, err = m.servicio.EncolarEstadosCuenta(fecha, idGrado)
+	case models.CorreoRecordatorio:
+		encolados, err = m.servicio.EncolarRecordatorios()
+	case models.CorreoPrueba:
+		err = m.servicio.EncolarCorreoPrueba(r.FormValue("para"))
+		encolados = 1
+	default:
+		http.Error(w, "Tipo de correo inválido", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al encolar correos (%s): %v", r.FormValue("tipo"), err)
+		http.Error(w, "Error al preparar correos: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/correos?encolados="+strconv.Itoa(encolados), http.StatusSeeOther)
+}
+
+// ReintentarCorreo vuelve a encolar un correo fallido
+func (m *Controlador) ReintentarCorreo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idCorreo, err := strconv.Atoi(r.FormValue("id_correo"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := m.servicio.Repo.ReintentarCorreo(idCorreo)
+	if err != nil {
+		log.Printf("Error al reintentar correo %d: %v", idCorreo, err)
+		http.Error(w, "Error al reintentar correo", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "El apoderado fue eliminado o se dio de baja", http.StatusConflict)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/correos?estado="+r.FormValue("estado"), http.StatusSeeOther)
+}
+
+// BajaCorreos es el enlace de baja incluido en cada correo o mensaje (público,
+// sin sesión); canal=mensajes da de baja WhatsApp/SMS en lugar de correos.
+// GET solo pide confirmación: los filtros de correo abren los enlaces por su cuenta.
+func (m *Controlador) BajaCorreos(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("t")
+	if token == "" {
+		http.Error(w, "Enlace inválido", http.StatusBadRequest)
+		return
+	}
+
+	canal := r.FormValue("canal")
+	if canal != "mensajes" {
+		canal = "correos"
+	}
+
+	var apoderado models.Apoderado
+	var err error
+	if r.Method == http.MethodPost {
+		apoderado, err = m.servicio.DarDeBaja(token, canal)
+	} else {
+		apoderado, err = m.servicio.Repo.ObtenerApoderadoPorTokenBaja(token)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Enlace inválido o vencido", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al procesar baja de correos: %v", err)
+		http.Error(w, "Error al procesar la baja", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.BajaCorreos(apoderado, token, canal).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar baja de correos: %v", err)
+	}
+}
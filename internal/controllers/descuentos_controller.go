@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Descuentos muestra las reglas de descuento y los grupos de estudiantes
+func (m *Controlador) Descuentos(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosDescuentos()
+	if err != nil {
+		log.Printf("Error al obtener descuentos: %v", err)
+		http.Error(w, "Error al cargar descuentos", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Descuentos(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar descuentos: %v", err)
+	}
+}
+
+// CrearReglaDescuento procesa el formulario de nueva regla.
+// destino es "e:<id_estudiante>" o "g:<id_grupo>"; alcance es "", "p:<id_producto>" o "c:<id_categoria>".
+func (m *Controlador) CrearReglaDescuento(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	regla := models.ReglaDescuento{
+		Nombre: strings.TrimSpace(r.FormValue("nombre")),
+		Tipo:   r.FormValue("tipo"),
+	}
+
+	var err error
+	if regla.Valor, err = strconv.ParseFloat(r.FormValue("valor"), 64); err != nil {
+		http.Error(w, "Valor inválido", http.StatusBadRequest)
+		return
+	}
+	if regla.VigenteDesde, err = utils.ParsearFecha(r.FormValue("vigente_desde")); err != nil {
+		http.Error(w, "Fecha de inicio inválida", http.StatusBadRequest)
+		return
+	}
+	if hastaStr := r.FormValue("vigente_hasta"); hastaStr != "" {
+		hasta, err := utils.ParsearFecha(hastaStr)
+		if err != nil {
+			http.Error(w, "Fecha de término inválida", http.StatusBadRequest)
+			return
+		}
+		regla.VigenteHasta = &hasta
+	}
+
+	tipoDestino, idDestino, err := parsearReferencia(r.FormValue("destino"))
+	if err != nil {
+		http.Error(w, "Destino inválido", http.StatusBadRequest)
+		return
+	}
+	switch tipoDestino {
+	case "e":
+		regla.IdEstudiante = idDestino
+	case "g":
+		regla.IdGrupo = idDestino
+	}
+
+	tipoAlcance, idAlcance, err := parsearReferencia(r.FormValue("alcance"))
+	if err != nil {
+		http.Error(w, "Alcance inválido", http.StatusBadRequest)
+		return
+	}
+	switch tipoAlcance {
+	case "p":
+		regla.IdProducto = idAlcance
+	case "c":
+		regla.IdCategoria = idAlcance
+	}
+
+	if err := m.servicio.CrearReglaDescuento(regla); err != nil {
+		log.Printf("Error al crear regla de descuento: %v", err)
+		http.Error(w, "Error al crear regla: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/descuentos", http.StatusSeeOther)
+}
+
+// CambiarEstadoReglaDescuento activa o desactiva una regla
+func (m *Controlador) CambiarEstadoReglaDescuento(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idRegla, err := strconv.Atoi(r.FormValue("id_regla"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	activa := r.FormValue("esta_activo") == "1"
+	if err := m.servicio.Repo.CambiarEstadoReglaDescuento(idRegla, activa); err != nil {
+		log.Printf("Error al cambiar estado de regla %d: %v", idRegla, err)
+		http.Error(w, "Error al cambiar estado", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/descuentos", http.StatusSeeOther)
+}
+
+// CrearGrupoDescuento agrega un grupo de estudiantes
+func (m *Controlador) CrearGrupoDescuento(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	nombre := strings.TrimSpace(r.FormValue("nombre"))
+	if nombre == "" {
+		http.Error(w, "Nombre requerido", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.Repo.InsertarGrupoDescuento(nombre); err != nil {
+		log.Printf("Error al crear grupo de descuento: %v", err)
+		http.Error(w, "Error al crear grupo", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/descuentos", http.StatusSeeOther)
+}
+
+// ModificarGrupoDescuento agrega o quita (accion=quitar) un estudiante de un grupo
+func (m *Controlador) ModificarGrupoDescuento(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idGrupo, err := strconv.Atoi(r.FormValue("id_grupo"))
+	idEstudiante, errEst := strconv.Atoi(r.FormValue("id_estudiante"))
+	if err != nil || errEst != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if r.FormValue("accion") == "quitar" {
+		err = m.servicio.Repo.QuitarEstudianteGrupo(idGrupo, idEstudiante)
+	} else {
+		err = m.servicio.Repo.AgregarEstudianteGrupo(idGrupo, idEstudiante)
+	}
+	if err != nil {
+		log.Printf("Error al modificar grupo %d: %v", idGrupo, err)
+		http.Error(w, "Error al modificar grupo", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/descuentos", http.StatusSeeOther)
+}
+
+// parsearReferencia separa valores de la forma "<tipo>:<id>"; vacío retorna tipo ""
+func parsearReferencia(valor string) (string, int, error) {
+	if valor == "" {
+		return "", 0, nil
+	}
+	tipo, idStr, ok := strings.Cut(valor, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("referencia inválida: %q", valor)
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("referencia inválida: %q", valor)
+	}
+	return tipo, id, nil
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// intervaloPing mantiene viva la conexión de eventos a través de proxies que
+// cortan las conexiones sin tráfico
+const intervaloPing = 25 * time.Second
+
+// EventosEnVivo — GET /eventos
+// Flujo Server-Sent Events con los cambios de consumos y pagos de todas las
+// terminales del colegio. Las páginas de resumen y la grilla semanal lo escuchan para
+// refrescarse solas (ver assets/main.js).
+func (m *Controlador) EventosEnVivo(w http.ResponseWriter, r *http.Request) {
+	cambios, cancelar, ok := m.servicio.EnVivo.Suscribir()
+	if !ok {
+		http.Error(w, "Demasiadas conexiones de eventos abiertas", http.StatusServiceUnavailable)
+		return
+	}
+	defer cancelar()
+
+	rc := http.NewResponseController(w)
+	// La conexión queda abierta mientras la página esté abierta: sin el límite de
+	// escritura del servidor
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Error al preparar eventos en vivo: %v", err)
+		http.Error(w, "Eventos no disponibles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: no acumular la respuesta
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(intervaloPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case c := <-cambios:
+			datos, err := json.Marshal(c)
+			if err != nil {
+				log.Printf("Error al serializar cambio en vivo: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: cambio\ndata: %s\n\n", datos)
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Inventario muestra existencias por producto y los últimos movimientos de stock
+func (m *Controlador) Inventario(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosInventario(puedeEditar(r))
+	if err != nil {
+		log.Printf("Error al obtener inventario: %v", err)
+		http.Error(w, "Error al cargar inventario", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Inventario(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar inventario: %v", err)
+	}
+}
+
+// RegistrarMovimientoStock procesa una entrada, merma o ajuste de inventario
+func (m *Controlador) RegistrarMovimientoStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idProducto, err := strconv.Atoi(r.FormValue("id_producto"))
+	cantidad, errCantidad := strconv.Atoi(r.FormValue("cantidad"))
+	if err != nil || errCantidad != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	fecha, err := utils.ParsearFecha(r.FormValue("fecha"))
+	if err != nil {
+		fecha = utils.Hoy()
+	}
+
+	tipo := r.FormValue("tipo")
+	nota := strings.TrimSpace(r.FormValue("nota"))
+
+	if err := m.servicio.RegistrarMovimientoInventario(idProducto, tipo, cantidad, fecha, nota); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Producto no encontrado", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error al registrar movimiento de stock: %v", err)
+		http.Error(w, "Error al registrar movimiento: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/inventario", http.StatusSeeOther)
+}
+
+// ConfigurarStock activa el control de inventario de un producto y fija su stock mínimo
+func (m *Controlador) ConfigurarStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idProducto, err := strconv.Atoi(r.FormValue("id_producto"))
+	stockMinimo, errMinimo := strconv.Atoi(r.FormValue("stock_minimo"))
+	if err != nil || errMinimo != nil || stockMinimo < 0 {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	controlaStock := r.FormValue("controla_stock") == "1"
+	if err := m.servicio.Repo.ConfigurarStockProducto(idProducto, controlaStock, stockMinimo); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Producto no encontrado", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error al configurar stock de producto %d: %v", idProducto, err)
+		http.Error(w, "Error al configurar stock", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/inventario", http.StatusSeeOther)
+}
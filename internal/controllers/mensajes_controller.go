@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"kiosco/internal/models"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Mensajes muestra los ajustes y el registro de WhatsApp/SMS (?estado=)
+func (m *Controlador) Mensajes(w http.ResponseWriter, r *http.Request) {
+	estado := r.URL.Query().Get("estado")
+	switch estado {
+	case "", models.MensajePendiente, models.MensajeEnviado, models.MensajeFallido:
+	default:
+		http.Error(w, "Estado inválido", http.StatusBadRequest)
+		return
+	}
+
+	datos, err := m.servicio.ObtenerDatosMensajes(estado)
+	if err != nil {
+		log.Printf("Error al obtener mensajes: %v", err)
+		http.Error(w, "Error al cargar mensajes", http.StatusInternalServerError)
+		return
+	}
+
+	if n := r.URL.Query().Get("encolados"); n != "" {
+		datos.Aviso = n + " mensaje(s) en cola de envío"
+	}
+
+	if err := pages.Mensajes(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar mensajes: %v", err)
+	}
+}
+
+// GuardarAjustesMensajes activa o desactiva la confirmación de pagos
+func (m *Controlador) GuardarAjustesMensajes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.GuardarAjustesMensajes(r.FormValue("confirmar_pagos") == "1"); err != nil {
+		log.Printf("Error al guardar ajustes de mensajes: %v", err)
+		http.Error(w, "Error al guardar ajustes", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/mensajes", http.StatusSeeOther)
+}
+
+// EnviarMensajes encola recordatorios o un mensaje de prueba (campo "tipo")
+func (m *Controlador) EnviarMensajes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	var encolados int
+	var err error
+	switch r.FormValue("tipo") {
+	case models.MensajeRecordatorio:
+		encolados, err = m.servicio.EncolarRecordatoriosMensaje()
+	case models.MensajePrueba:
+		if err := m.servicio.EncolarMensajePrueba(r.FormValue("telefono")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		encolados = 1
+	default:
+		http.Error(w, "Tipo de mensaje inválido", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al encolar mensajes (%s): %v", r.FormValue("tipo"), err)
+		http.Error(w, "Error al preparar mensajes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/mensajes?encolados="+strconv.Itoa(encolados), http.StatusSeeOther)
+}
+
+// ReintentarMensaje vuelve a encolar un mensaje fallido
+func (m *Controlador) ReintentarMensaje(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idMensaje, err := strconv.Atoi(r.FormValue("id_mensaje"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := m.servicio.Repo.ReintentarMensaje(idMensaje)
+	if err != nil {
+		log.Printf("Error al reintentar mensaje %d: %v", idMensaje, err)
+		http.Error(w, "Error al reintentar mensaje", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "El apoderado fue eliminado o se dio de baja", http.StatusConflict)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/mensajes?estado="+r.FormValue("estado"), http.StatusSeeOther)
+}
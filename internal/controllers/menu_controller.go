@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// MenuDia muestra los productos ofrecidos en una fecha (por defecto hoy)
+func (m *Controlador) MenuDia(w http.ResponseWriter, r *http.Request) {
+	fecha := utils.Hoy()
+	if fechaStr := r.URL.Query().Get("fecha"); fechaStr != "" {
+		f, err := utils.ParsearFecha(fechaStr)
+		if err != nil {
+			http.Error(w, "Fecha inválida", http.StatusBadRequest)
+			return
+		}
+		fecha = f
+	}
+
+	datos, err := m.servicio.ObtenerDatosMenuDia(fecha, puedeEditar(r))
+	if err != nil {
+		log.Printf("Error al obtener menú del día: %v", err)
+		http.Error(w, "Error al cargar menú", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.MenuDia(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar menú del día: %v", err)
+	}
+}
+
+// GuardarMenuDia reemplaza los productos ofrecidos en la fecha del formulario
+func (m *Controlador) GuardarMenuDia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	fechaStr := r.FormValue("fecha")
+	fecha, err := utils.ParsearFecha(fechaStr)
+	if err != nil {
+		http.Error(w, "Fecha inválida", http.StatusBadRequest)
+		return
+	}
+
+	// "limpiar" borra el menú para volver a ofrecer todos los productos activos
+	var ids []int
+	if r.FormValue("limpiar") == "" {
+		for _, valor := range r.Form["id_producto"] {
+			id, err := strconv.Atoi(valor)
+			if err != nil {
+				http.Error(w, "Producto inválido", http.StatusBadRequest)
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	if err := m.servicio.Repo.GuardarMenuDia(fecha, ids); err != nil {
+		log.Printf("Error al guardar menú del día: %v", err)
+		http.Error(w, "Error al guardar menú", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/menu?fecha="+fechaStr, http.StatusSeeOther)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"database/sql"
 	"fmt"
 	"kiosco/internal/models"
 	"kiosco/internal/utils"
@@ -36,19 +37,28 @@ func (m *Controlador) RegistrarPago(w http.ResponseWriter, r *http.Request) {
 	}
 
 	fechaStr := r.FormValue("fecha")
-	fecha, err := time.Parse("2006-01-02", fechaStr)
+	fecha, err := utils.ParsearFecha(fechaStr)
 	if err != nil {
-		fecha = time.Now()
+		fecha = utils.Hoy()
 	}
 
 	fechaPago := fecha
 	if fechaPagoStr := r.FormValue("fecha_pago"); fechaPagoStr != "" {
-		if fp, err := time.Parse("2006-01-02", fechaPagoStr); err == nil {
+		if fp, err := utils.ParsearFecha(fechaPagoStr); err == nil {
 			fechaPago = fp
 		}
 	}
 
-	if err := m.servicio.RegistrarPagoDesdeFormulario(idEstudiante, monto, fechaPago); err != nil {
+	metodo := r.FormValue("metodo")
+	if metodo == "" {
+		metodo = models.MetodoEfectivo
+	}
+	if !models.MetodoPagoValido(metodo) {
+		http.Error(w, "Método de pago inválido", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := m.servicio.RegistrarPagoDesdeFormulario(idEstudiante, monto, fechaPago, metodo, usuarioActual(r)); err != nil {
 		log.Printf("Error al registrar pago: %v", err)
 		http.Error(w, "Error al registrar pago: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -63,6 +73,11 @@ func (m *Controlador) RegistrarPago(w http.ResponseWriter, r *http.Request) {
 		if grado != "" {
 			urlRedireccion += "&grado=" + grado
 		}
+	} else if redirect == "personal" {
+		urlRedireccion = "/personal"
+		if mes, err := time.Parse("2006-01", r.FormValue("mes")); err == nil {
+			urlRedireccion += "?mes=" + mes.Format("2006-01")
+		}
 	} else {
 		urlRedireccion = "/?fecha=" + fechaStr
 		if grado != "" {
@@ -82,7 +97,7 @@ func (m *Controlador) EditarPagos(w http.ResponseWriter, r *http.Request) {
 	}
 
 	fechaStr := r.URL.Query().Get("fecha")
-	fecha, err := time.Parse("2006-01-02", fechaStr)
+	fecha, err := utils.ParsearFecha(fechaStr)
 	if err != nil {
 		http.Error(w, "Fecha inválida", http.StatusBadRequest)
 		return
@@ -97,20 +112,15 @@ func (m *Controlador) EditarPagos(w http.ResponseWriter, r *http.Request) {
 
 	fechaInicio, fechaFin := utils.CalcularSemanaDesdeFecha(fecha)
 
-	estudiantes, err := m.servicio.Repo.ObtenerEstudiantesPorGrado(0)
-	if err != nil {
+	var nombreEstudiante string
+	est, err := m.servicio.Repo.ObtenerEstudiantePorId(idEstudiante)
+	if err == nil {
+		nombreEstudiante = est.Apellidos + ", " + est.Nombres
+	} else if err != sql.ErrNoRows {
 		http.Error(w, "Error al obtener estudiante", http.StatusInternalServerError)
 		return
 	}
 
-	var nombreEstudiante string
-	for _, est := range estudiantes {
-		if est.IdEstudiante == idEstudiante {
-			nombreEstudiante = est.Apellidos + ", " + est.Nombres
-			break
-		}
-	}
-
 	pagos, err := m.servicio.Repo.ObtenerPagosSemanaDetalle(idEstudiante, fechaInicio, fechaFin)
 	if err != nil {
 		http.Error(w, "Error al obtener pagos", http.StatusInternalServerError)
@@ -133,6 +143,16 @@ func (m *Controlador) EditarPagos(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Sumar cargos de planes de alimentación de la semana
+	cargos, err := m.servicio.Repo.ObtenerCargosPlanSemana(fechaInicio, fechaFin)
+	if err == nil {
+		for _, c := range cargos {
+			if c.IdEstudiante == idEstudiante {
+				subTotal += c.Monto
+			}
+		}
+	}
+
 	// Obtener deuda anterior (acumulada de semanas anteriores)
 	deudaAnterior, err := m.servicio.Repo.ObtenerDeudaAnterior(idEstudiante, fechaInicio)
 	if err != nil {
@@ -181,7 +201,7 @@ func (m *Controlador) EliminarPago(w http.ResponseWriter, r *http.Request) {
 	fechaStr := r.FormValue("fecha")
 	grado := r.FormValue("grado")
 
-	if err := m.servicio.Repo.EliminarPago(idPago); err != nil {
+	if err := m.servicio.AnularPago(idPago); err != nil {
 		log.Printf("Error al eliminar pago: %v", err)
 		http.Error(w, "Error al eliminar pago", http.StatusInternalServerError)
 		return
@@ -191,7 +211,7 @@ func (m *Controlador) EliminarPago(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("HX-Request") == "true" {
 		// Re-calcular datos actualizados después de eliminar el pago
 		idEstudianteInt, _ := strconv.Atoi(idEstudiante)
-		fecha, _ := time.Parse("2006-01-02", fechaStr)
+		fecha, _ := utils.ParsearFecha(fechaStr)
 		fechaInicio, fechaFin := utils.CalcularSemanaDesdeFecha(fecha)
 
 		pagos, _ := m.servicio.Repo.ObtenerPagosSemanaDetalle(idEstudianteInt, fechaInicio, fechaFin)
@@ -207,6 +227,12 @@ func (m *Controlador) EliminarPago(w http.ResponseWriter, r *http.Request) {
 				subTotal += c.TotalLinea
 			}
 		}
+		cargos, _ := m.servicio.Repo.ObtenerCargosPlanSemana(fechaInicio, fechaFin)
+		for _, c := range cargos {
+			if c.IdEstudiante == idEstudianteInt {
+				subTotal += c.Monto
+			}
+		}
 
 		deudaAnterior, _ := m.servicio.Repo.ObtenerDeudaAnterior(idEstudianteInt, fechaInicio)
 		deudaActual := (subTotal + deudaAnterior) - totalPagos
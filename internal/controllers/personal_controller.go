@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"database/sql"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// mesDeConsulta lee ?mes=AAAA-MM; por defecto el mes en curso
+func mesDeConsulta(r *http.Request) time.Time {
+	if mes, err := time.Parse("2006-01", r.URL.Query().Get("mes")); err == nil {
+		return mes
+	}
+	hoy := utils.Hoy()
+	return time.Date(hoy.Year(), hoy.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Personal — GET /personal?mes=
+// Cuentas de docentes, personal y otros clientes que pagan a fin de mes:
+// saldo anterior, consumos y pagos del mes y saldo, con cobro en la misma fila
+func (m *Controlador) Personal(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosPersonal(mesDeConsulta(r))
+	if err != nil {
+		log.Printf("Error al obtener cuentas del personal: %v", err)
+		http.Error(w, "Error al cargar las cuentas del personal", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Personal(datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar personal: %v", err)
+	}
+}
+
+// EstadoCuentaPersonal — GET /personal/estado?id_estudiante=&mes=
+// Nota de venta del mes de una cuenta, con el mismo formato que la semanal
+func (m *Controlador) EstadoCuentaPersonal(w http.ResponseWriter, r *http.Request) {
+	idEstudiante, err := strconv.Atoi(r.URL.Query().Get("id_estudiante"))
+	if err != nil {
+		http.Error(w, "ID de cliente inválido", http.StatusBadRequest)
+		return
+	}
+
+	datos, err := m.servicio.ObtenerEstadoCuentaMes(idEstudiante, mesDeConsulta(r))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Cliente no encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al obtener estado de cuenta: %v", err)
+		http.Error(w, "Error al obtener consumos", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.VerConsumoSemanal(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar estado de cuenta: %v", err)
+	}
+}
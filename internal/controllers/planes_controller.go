@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Planes muestra los planes de alimentación y los estudiantes inscritos
+func (m *Controlador) Planes(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosPlanes()
+	if err != nil {
+		log.Printf("Error al obtener planes: %v", err)
+		http.Error(w, "Error al cargar planes", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Planes(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar planes: %v", err)
+	}
+}
+
+// CrearPlan procesa el formulario de nuevo plan
+func (m *Controlador) CrearPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	monto, err := strconv.ParseFloat(r.FormValue("monto"), 64)
+	if err != nil {
+		http.Error(w, "Monto inválido", http.StatusBadRequest)
+		return
+	}
+
+	// id_categoria vacío = el plan cubre todos los productos
+	idCategoria := 0
+	if v := r.FormValue("id_categoria"); v != "" {
+		if idCategoria, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Categoría inválida", http.StatusBadRequest)
+			return
+		}
+	}
+
+	plan := models.Plan{
+		Nombre:      strings.TrimSpace(r.FormValue("nombre")),
+		Monto:       monto,
+		Periodo:     r.FormValue("periodo"),
+		IdCategoria: idCategoria,
+		EstaActivo:  true,
+	}
+	if err := m.servicio.CrearPlan(plan); err != nil {
+		log.Printf("Error al crear plan: %v", err)
+		http.Error(w, "Error al crear plan: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/planes", http.StatusSeeOther)
+}
+
+// InscribirEnPlan suscribe a un estudiante a un plan desde una fecha
+func (m *Controlador) InscribirEnPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idPlan, errPlan := strconv.Atoi(r.FormValue("id_plan"))
+	idEstudiante, errEstudiante := strconv.Atoi(r.FormValue("id_estudiante"))
+	if errPlan != nil || errEstudiante != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	fechaInicio, err := utils.ParsearFecha(r.FormValue("fecha_inicio"))
+	if err != nil {
+		http.Error(w, "Fecha inválida", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.InscribirEnPlan(idPlan, idEstudiante, fechaInicio); err != nil {
+		log.Printf("Error al inscribir en plan: %v", err)
+		http.Error(w, "Error al inscribir: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/planes", http.StatusSeeOther)
+}
+
+// FinalizarSuscripcion da de baja una suscripción; los períodos ya cobrados se mantienen
+func (m *Controlador) FinalizarSuscripcion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idSuscripcion, err := strconv.Atoi(r.FormValue("id_suscripcion"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	fechaFin, err := utils.ParsearFecha(r.FormValue("fecha_fin"))
+	if err != nil {
+		http.Error(w, "Fecha inválida", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.Repo.FinalizarSuscripcion(idSuscripcion, fechaFin); err != nil {
+		log.Printf("Error al finalizar suscripción %d: %v", idSuscripcion, err)
+		http.Error(w, "Error al finalizar suscripción", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/planes", http.StatusSeeOther)
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"kiosco/internal/auth"
+	"kiosco/internal/middleware"
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// cookiePortal guarda (o con valor "" borra) la sesión del apoderado. Solo se
+// envía a /portal, nunca a las rutas del personal.
+func cookiePortal(w http.ResponseWriter, valor string) {
+	maxAge := int(24 * time.Hour / time.Second)
+	if valor == "" {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.CookiePortal,
+		Value:    valor,
+		Path:     "/portal",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	})
+}
+
+// PortalAcceso canjea el enlace personal (?t=) por la sesión del portal y
+// redirige a /portal, así el token no queda en el historial ni en el Referer.
+// Sin token explica cómo entrar.
+func (m *Controlador) PortalAcceso(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	token := r.URL.Query().Get("t")
+	if token == "" {
+		if err := pages.PortalAcceso("Para ver los consumos y pagos, abra el enlace que le envió el colegio.").Render(r.Context(), w); err != nil {
+			log.Printf("Error al renderizar acceso al portal: %v", err)
+		}
+		return
+	}
+
+	idApoderado, err := m.servicio.IniciarSesionPortal(token)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		if err := pages.PortalAcceso("El enlace no es válido o fue reemplazado por uno nuevo. Solicite otro al colegio.").Render(r.Context(), w); err != nil {
+			log.Printf("Error al renderizar acceso al portal: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("Error al validar enlace del portal: %v", err)
+		http.Error(w, "Error al validar el enlace", http.StatusInternalServerError)
+		return
+	}
+
+	cookiePortal(w, auth.FirmarTokenPortal(middleware.Colegio(r), idApoderado))
+	http.Redirect(w, r, "/portal", http.StatusSeeOther)
+}
+
+// Portal muestra al apoderado los consumos de la semana, el saldo y los pagos de
+// sus estudiantes (?id_estudiante=&fecha=). Es de solo lectura.
+func (m *Controlador) Portal(w http.ResponseWriter, r *http.Request) {
+	sesion, _ := r.Context().Value(middleware.SesionPortalContextKey).(middleware.SesionPortal)
+
+	idEstudiante, _ := strconv.Atoi(r.URL.Query().Get("id_estudiante"))
+	fecha := utils.Hoy()
+	if v := r.URL.Query().Get("fecha"); v != "" {
+		f, err := utils.ParsearFecha(v)
+		if err != nil {
+			http.Error(w, "Fecha inválida", http.StatusBadRequest)
+			return
+		}
+		fecha = f
+	}
+
+	datos, err := m.servicio.ObtenerDatosPortal(sesion.IdApoderado, sesion.Emitida, idEstudiante, fecha)
+	if errors.Is(err, sql.ErrNoRows) {
+		// El colegio revocó o reemplazó el enlace, o eliminó al apoderado
+		cookiePortal(w, "")
+		http.Redirect(w, r, "/portal/acceso", http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al obtener datos del portal: %v", err)
+		http.Error(w, "Error al cargar la información", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := pages.Portal(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar portal: %v", err)
+	}
+}
+
+// PortalSalir cierra la sesión del apoderado
+func (m *Controlador) PortalSalir(w http.ResponseWriter, r *http.Request) {
+	cookiePortal(w, "")
+	if err := pages.PortalAcceso("Sesión cerrada. Para volver a entrar, abra otra vez el enlace del colegio.").Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar acceso al portal: %v", err)
+	}
+}
+
+// AccesoPortalApoderado genera (accion=generar) o revoca (accion=revocar) el
+// enlace al portal de un apoderado. El enlace nuevo se muestra una sola vez.
+func (m *Controlador) AccesoPortalApoderado(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idApoderado, err := strconv.Atoi(r.FormValue("id_apoderado"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	switch r.FormValue("accion") {
+	case "revocar":
+		if err := m.servicio.RevocarAccesoPortal(idApoderado); err != nil {
+			log.Printf("Error al revocar portal de apoderado %d: %v", idApoderado, err)
+			http.Error(w, "Error al revocar el enlace", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/setup/correos", http.StatusSeeOther)
+		return
+	case "generar":
+	default:
+		http.Error(w, "Acción inválida", http.StatusBadRequest)
+		return
+	}
+
+	token, err := m.servicio.GenerarAccesoPortal(idApoderado)
+	if err != nil {
+		log.Printf("Error al generar portal de apoderado %d: %v", idApoderado, err)
+		http.Error(w, "Error al generar el enlace: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	datos, err := m.servicio.ObtenerDatosCorreos("")
+	if err != nil {
+		log.Printf("Error al obtener correos: %v", err)
+		http.Error(w, "Error al cargar correos", http.StatusInternalServerError)
+		return
+	}
+	for _, a := range datos.Apoderados {
+		if a.IdApoderado == idApoderado {
+			datos.NombrePortal = a.Nombre + " (" + a.NombreEstudiante + ")"
+		}
+	}
+	datos.EnlacePortal = m.servicio.EnlacePortal(token, r.Host)
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := pages.Correos(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar correos: %v", err)
+	}
+}
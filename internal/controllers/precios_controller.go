@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// HistorialPrecios muestra los cambios de precio registrados y programados
+func (m *Controlador) HistorialPrecios(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosHistorialPrecios(puedeEditar(r))
+	if err != nil {
+		log.Printf("Error al obtener historial de precios: %v", err)
+		http.Error(w, "Error al cargar historial de precios", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.HistorialPrecios(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar historial de precios: %v", err)
+	}
+}
+
+// ProgramarPrecio registra un precio vigente desde la fecha del formulario
+func (m *Controlador) ProgramarPrecio(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idProducto, err := strconv.Atoi(r.FormValue("id_producto"))
+	precio, errPrecio := strconv.ParseFloat(r.FormValue("precio_unitario"), 64)
+	vigenteDesde, errFecha := utils.ParsearFecha(r.FormValue("vigente_desde"))
+	if err != nil || errPrecio != nil || errFecha != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.ProgramarCambioPrecio(idProducto, precio, vigenteDesde); err != nil {
+		log.Printf("Error al programar precio del producto %d: %v", idProducto, err)
+		http.Error(w, "Error al programar precio: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/precios", http.StatusSeeOther)
+}
+
+// CancelarPrecio elimina un cambio de precio que aún no entra en vigencia
+func (m *Controlador) CancelarPrecio(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idPrecio, err := strconv.Atoi(r.FormValue("id_precio"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	eliminado, err := m.servicio.Repo.EliminarPrecioProgramado(idPrecio)
+	if err != nil {
+		log.Printf("Error al cancelar precio %d: %v", idPrecio, err)
+		http.Error(w, "Error al cancelar precio", http.StatusInternalServerError)
+		return
+	}
+	if !eliminado {
+		http.Error(w, "Solo se pueden cancelar precios programados", http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/precios", http.StatusSeeOther)
+}
@@ -1,6 +1,9 @@
 package controllers
 
 import (
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
 	"kiosco/templates/pages"
 	"log"
 	"net/http"
@@ -16,7 +19,12 @@ func (m *Controlador) SetupProductos(w http.ResponseWriter, r *http.Request) {
 		productos = nil
 	}
 
-	if err := pages.SetupProductos(productos).Render(r.Context(), w); err != nil {
+	categorias, err := m.servicio.Repo.ObtenerCategorias()
+	if err != nil {
+		log.Printf("Error al obtener categorías: %v", err)
+	}
+
+	if err := pages.SetupProductos(productos, categorias).Render(r.Context(), w); err != nil {
 		log.Printf("Error al renderizar setup productos: %v", err)
 	}
 }
@@ -33,14 +41,13 @@ func (m *Controlador) AgregarProducto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nombre := strings.TrimSpace(r.FormValue("nombre"))
-	precio, err := strconv.ParseFloat(r.FormValue("precio_unitario"), 64)
-	if err != nil || nombre == "" || precio <= 0 {
+	prod, err := leerProductoFormulario(r)
+	if err != nil {
 		http.Error(w, "Datos inválidos", http.StatusBadRequest)
 		return
 	}
 
-	prod, err := m.servicio.Repo.InsertarProducto(nombre, precio)
+	prod, err = m.servicio.Repo.InsertarProducto(prod)
 	if err != nil {
 		log.Printf("Error al insertar producto: %v", err)
 		http.Error(w, "Error al agregar producto", http.StatusInternalServerError)
@@ -48,7 +55,7 @@ func (m *Controlador) AgregarProducto(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Header.Get("HX-Request") == "true" {
-		if err := pages.FilaProducto(prod).Render(r.Context(), w); err != nil {
+		if err := pages.FilaProducto(prod, m.categorias()).Render(r.Context(), w); err != nil {
 			log.Printf("Error al renderizar fila producto: %v", err)
 		}
 		return
@@ -57,7 +64,7 @@ func (m *Controlador) AgregarProducto(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/setup/productos", http.StatusSeeOther)
 }
 
-// ActualizarProducto modifica nombre y precio de un producto existente
+// ActualizarProducto modifica los datos de catálogo de un producto existente
 func (m *Controlador) ActualizarProducto(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
@@ -70,14 +77,20 @@ func (m *Controlador) ActualizarProducto(w http.ResponseWriter, r *http.Request)
 	}
 
 	idProducto, err := strconv.Atoi(r.FormValue("id_producto"))
-	nombre := strings.TrimSpace(r.FormValue("nombre"))
-	precio, errPrecio := strconv.ParseFloat(r.FormValue("precio_unitario"), 64)
-	if err != nil || errPrecio != nil || nombre == "" || precio <= 0 {
+	datos, errDatos := leerProductoFormulario(r)
+	if err != nil || errDatos != nil {
 		http.Error(w, "Datos inválidos", http.StatusBadRequest)
 		return
 	}
+	datos.IdProducto = idProducto
+
+	// Un cambio de precio rige desde la fecha indicada (por defecto hoy)
+	vigenteDesde, err := utils.ParsearFecha(r.FormValue("vigente_desde"))
+	if err != nil {
+		vigenteDesde = utils.Hoy()
+	}
 
-	if err := m.servicio.Repo.ActualizarProducto(idProducto, nombre, precio); err != nil {
+	if err := m.servicio.ActualizarProducto(datos, vigenteDesde); err != nil {
 		log.Printf("Error al actualizar producto %d: %v", idProducto, err)
 		http.Error(w, "Error al actualizar producto", http.StatusInternalServerError)
 		return
@@ -90,7 +103,7 @@ func (m *Controlador) ActualizarProducto(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := pages.FilaProducto(*prod).Render(r.Context(), w); err != nil {
+	if err := pages.FilaProducto(*prod, m.categorias()).Render(r.Context(), w); err != nil {
 		log.Printf("Error al renderizar fila producto: %v", err)
 	}
 }
@@ -127,7 +140,81 @@ func (m *Controlador) ToggleProducto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := pages.FilaProducto(*prod).Render(r.Context(), w); err != nil {
+	if err := pages.FilaProducto(*prod, m.categorias()).Render(r.Context(), w); err != nil {
 		log.Printf("Error al renderizar fila producto: %v", err)
 	}
 }
+
+// AgregarCategoria crea una categoría de productos al final del orden
+func (m *Controlador) AgregarCategoria(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	nombre := strings.TrimSpace(r.FormValue("nombre"))
+	if nombre == "" {
+		http.Error(w, "Nombre requerido", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.Repo.InsertarCategoria(nombre); err != nil {
+		log.Printf("Error al insertar categoría: %v", err)
+		http.Error(w, "Error al agregar categoría", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/productos", http.StatusSeeOther)
+}
+
+// categorias carga las categorías para los selectores del formulario de producto
+func (m *Controlador) categorias() []models.Categoria {
+	categorias, err := m.servicio.Repo.ObtenerCategorias()
+	if err != nil {
+		log.Printf("Error al obtener categorías: %v", err)
+	}
+	return categorias
+}
+
+// leerProductoFormulario valida los campos de catálogo comunes a alta y edición
+func leerProductoFormulario(r *http.Request) (models.Producto, error) {
+	nombre := strings.TrimSpace(r.FormValue("nombre"))
+	precio, err := strconv.ParseFloat(r.FormValue("precio_unitario"), 64)
+	if err != nil || nombre == "" || precio <= 0 {
+		return models.Producto{}, fmt.Errorf("nombre o precio inválido")
+	}
+
+	costo, err := parsearCosto(r.FormValue("costo_unitario"))
+	if err != nil {
+		return models.Producto{}, err
+	}
+
+	// Categoría y orden son opcionales: vacío = sin categoría / orden 0
+	idCategoria, _ := strconv.Atoi(r.FormValue("id_categoria"))
+	orden, _ := strconv.Atoi(r.FormValue("orden"))
+
+	return models.Producto{
+		Nombre:         nombre,
+		PrecioUnitario: precio,
+		CostoUnitario:  costo,
+		IdCategoria:    idCategoria,
+		Orden:          orden,
+	}, nil
+}
+
+// parsearCosto interpreta el costo unitario opcional del formulario (vacío = 0)
+func parsearCosto(valor string) (float64, error) {
+	if strings.TrimSpace(valor) == "" {
+		return 0, nil
+	}
+	costo, err := strconv.ParseFloat(valor, 64)
+	if err != nil || costo < 0 {
+		return 0, fmt.Errorf("costo inválido: %q", valor)
+	}
+	return costo, nil
+}
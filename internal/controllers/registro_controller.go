@@ -9,15 +9,18 @@ package controllers
 //     do not require CSRF tokens. CSRF middleware is applied at the router level.
 
 import (
+	"database/sql"
 	"fmt"
 	"kiosco/internal/auth"
+	"kiosco/internal/middleware"
 	"kiosco/internal/models"
+	"kiosco/internal/services"
 	"kiosco/internal/utils"
 	"kiosco/templates/pages"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
-	"time"
 )
 
 // RegistroConsumos — GET /registro
@@ -54,44 +57,132 @@ func (m *Controlador) RegistroSector(w http.ResponseWriter, r *http.Request) {
 	sector := strings.TrimPrefix(r.URL.Path, "/registro/")
 	fecha := r.URL.Query().Get("fecha")
 
-	if sector != "menor" && sector != "mayor" {
+	if !services.SectorValido(sector) {
 		http.Error(w, "Sector inválido", http.StatusBadRequest)
 		return
 	}
 
 	if fecha == "" {
-		fecha = time.Now().Format("2006-01-02")
-	} else if _, err := time.Parse("2006-01-02", fecha); err != nil {
+		fecha = utils.FormatearFechaCompleta(utils.Hoy())
+	} else if _, err := utils.ParsearFecha(fecha); err != nil {
 		http.Error(w, "Fecha inválida", http.StatusBadRequest)
 		return
 	}
 
-	// Cargar estudiantes y productos
-	estudiantes, err := m.servicio.Repo.ObtenerEstudiantesActivosPorSector(sector)
+	// Cargar estudiantes (filtrados si se llegó desde la búsqueda) y productos
+	busqueda := strings.TrimSpace(r.URL.Query().Get("q"))
+	estudiantes, err := m.servicio.BuscarEstudiantesRegistro(busqueda, sector)
 	if err != nil {
 		log.Printf("Error al obtener estudiantes: %v", err)
 		http.Error(w, "Error al cargar estudiantes", http.StatusInternalServerError)
 		return
 	}
 
-	productos, err := m.servicio.Repo.ObtenerProductosActivos()
+	fechaMenu, _ := utils.ParsearFecha(fecha)
+	productos, err := m.servicio.Repo.ObtenerProductosParaFecha(fechaMenu)
 	if err != nil {
 		log.Printf("Error al obtener productos: %v", err)
 		http.Error(w, "Error al cargar productos", http.StatusInternalServerError)
 		return
 	}
 
-	fechas := generarFechasSemana(fecha)
+	stockBajo, err := m.servicio.Repo.ObtenerProductosStockBajo()
+	if err != nil {
+		log.Printf("Error al obtener stock bajo: %v", err)
+	}
+
+	calendario, err := m.servicio.ObtenerCalendarioSemana(fechaMenu)
+	if err != nil {
+		log.Printf("Error al obtener calendario: %v", err)
+		http.Error(w, "Error al cargar calendario", http.StatusInternalServerError)
+		return
+	}
+
+	fechas := generarFechasSemana(fecha, calendario)
 	grados := utils.GradosNombres(utils.ObtenerGradosEstaticos())
+	if sector == "personal" {
+		// Sin grado, el filtro es por tipo de cliente
+		grados = []string{"Todos", models.NombreTipoCliente(models.TipoPersonal), models.NombreTipoCliente(models.TipoOtro)}
+	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	if err := pages.RegistroConsumosCon(sector, fechas, fecha, estudiantes, productos, grados).Render(r.Context(), w); err != nil {
+	if err := pages.RegistroConsumosCon(sector, fechas, fecha, busqueda, estudiantes, productos, grados, stockBajo).Render(r.Context(), w); err != nil {
 		log.Printf("Error al renderizar página: %v", err)
 		http.Error(w, "Error al cargar la página", http.StatusInternalServerError)
 	}
 }
 
+// BuscarEstudiantesRegistro — GET /registro/buscar?q=&sector=&fecha=[&ir=1]
+// Devuelve las filas de estudiantes que coinciden con la búsqueda. Con ir=1 (Enter
+// en el buscador o la lectura de un carné) abre directamente la edición de
+// consumos si el texto es un código o si hay un solo resultado; sin HTMX vuelve
+// al registro con la búsqueda aplicada.
+func (m *Controlador) BuscarEstudiantesRegistro(w http.ResponseWriter, r *http.Request) {
+	consulta := r.URL.Query()
+	busqueda := strings.TrimSpace(consulta.Get("q"))
+	sector := consulta.Get("sector")
+	fecha := consulta.Get("fecha")
+
+	if !services.SectorValido(sector) {
+		http.Error(w, "Sector inválido", http.StatusBadRequest)
+		return
+	}
+	if fecha == "" {
+		fecha = utils.FormatearFechaCompleta(utils.Hoy())
+	} else if _, err := utils.ParsearFecha(fecha); err != nil {
+		http.Error(w, "Fecha inválida", http.StatusBadRequest)
+		return
+	}
+
+	esHTMX := r.Header.Get("HX-Request") == "true"
+	if consulta.Get("ir") == "1" {
+		var destino string
+		if e, err := m.servicio.IdentificarEstudiante(busqueda); err == nil {
+			// El carné puede ser de otro sector: se edita igual, volviendo a su sector
+			destino = fmt.Sprintf("/editar-consumos?id_estudiante=%d&fecha=%s&sector=%s", e.IdEstudiante, fecha, services.SectorDeEstudiante(e))
+		} else if err != sql.ErrNoRows {
+			log.Printf("Error al identificar estudiante: %v", err)
+			http.Error(w, "Error al buscar estudiante", http.StatusInternalServerError)
+			return
+		} else if busqueda != "" {
+			estudiantes, err := m.servicio.BuscarEstudiantesRegistro(busqueda, sector)
+			if err != nil {
+				log.Printf("Error al buscar estudiantes: %v", err)
+				http.Error(w, "Error al buscar estudiantes", http.StatusInternalServerError)
+				return
+			}
+			if len(estudiantes) == 1 {
+				destino = fmt.Sprintf("/editar-consumos?id_estudiante=%d&fecha=%s&sector=%s", estudiantes[0].IdEstudiante, fecha, sector)
+			}
+		}
+
+		if destino == "" && !esHTMX {
+			destino = "/registro/" + sector + "?" + url.Values{"fecha": {fecha}, "q": {busqueda}}.Encode()
+		}
+		if destino != "" {
+			if esHTMX {
+				w.Header().Set("HX-Redirect", destino)
+				return
+			}
+			http.Redirect(w, r, destino, http.StatusSeeOther)
+			return
+		}
+	}
+
+	estudiantes, err := m.servicio.BuscarEstudiantesRegistro(busqueda, sector)
+	if err != nil {
+		log.Printf("Error al buscar estudiantes: %v", err)
+		http.Error(w, "Error al buscar estudiantes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.FilasRegistro(sector, fecha, estudiantes).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar búsqueda: %v", err)
+	}
+}
+
 // Helpers
 
 func validarAuth(r *http.Request) bool {
@@ -99,42 +190,56 @@ func validarAuth(r *http.Request) bool {
 	if err != nil {
 		return false
 	}
-	_, _, ok := auth.VerificarToken(cookie.Value)
+	_, _, ok := auth.VerificarToken(middleware.Colegio(r), cookie.Value)
 	return ok
 }
 
-func generarFechasSemana(fechaStr string) []models.DiaFecha {
-	fecha, err := time.Parse("2006-01-02", fechaStr)
+// puedeEditar indica si la sesión actual tiene permiso de edición
+func puedeEditar(r *http.Request) bool {
+	cookie, err := r.Cookie(auth.CookieNombre)
 	if err != nil {
-		fecha = time.Now()
+		return false
 	}
+	_, puede, ok := auth.VerificarToken(middleware.Colegio(r), cookie.Value)
+	return ok && puede
+}
 
-	diaSemana := fecha.Weekday()
-	var diferencia int
-	if diaSemana == 0 {
-		diferencia = -6
-	} else {
-		diferencia = 1 - int(diaSemana)
+// usuarioActual retorna el ID del usuario de la sesión (0 si no hay sesión válida)
+func usuarioActual(r *http.Request) int {
+	cookie, err := r.Cookie(auth.CookieNombre)
+	if err != nil {
+		return 0
 	}
+	id, _, ok := auth.VerificarToken(middleware.Colegio(r), cookie.Value)
+	if !ok {
+		return 0
+	}
+	return id
+}
 
-	lunes := fecha.AddDate(0, 0, diferencia)
-	lunes = time.Date(lunes.Year(), lunes.Month(), lunes.Day(), 0, 0, 0, 0, lunes.Location())
+// generarFechasSemana arma el selector de días de la semana que contiene la fecha:
+// solo los días de atención, con el motivo de cierre de feriados y vacaciones
+func generarFechasSemana(fechaStr string, calendario *models.Calendario) []models.DiaFecha {
+	fecha, err := utils.ParsearFecha(fechaStr)
+	if err != nil {
+		fecha = utils.Hoy()
+	}
 
-	nombres := []string{"Lunes", "Martes", "Miércoles", "Jueves", "Viernes", "Sábado"}
+	inicio, _ := utils.CalcularSemanaDesdeFecha(fecha)
 	meses := []string{"Ene", "Feb", "Mar", "Abr", "May", "Jun", "Jul", "Ago", "Sep", "Oct", "Nov", "Dic"}
 
-	hoy := time.Now().Format("2006-01-02")
-	dias := make([]models.DiaFecha, 6)
+	hoy := utils.FormatearFechaCompleta(utils.Hoy())
+	var dias []models.DiaFecha
 
-	for i := 0; i < 6; i++ {
-		d := lunes.AddDate(0, 0, i)
+	for _, d := range calendario.DiasAtencion(utils.DiasDeSemana(inicio)) {
 		fechaFormato := d.Format("2006-01-02")
-		dias[i] = models.DiaFecha{
-			Nombre:       nombres[i],
+		dias = append(dias, models.DiaFecha{
+			Nombre:       utils.NombreDia(d.Weekday()),
 			Fecha:        fechaFormato,
 			FechaFormato: fmt.Sprintf("%d %s", d.Day(), meses[int(d.Month())-1]),
 			EsHoy:        fechaFormato == hoy,
-		}
+			Motivo:       calendario.MotivoCierre(d),
+		})
 	}
 
 	return dias
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ReporteMargen — GET /reportes/margen?desde=&hasta=
+// Por defecto muestra el mes en curso.
+func (m *Controlador) ReporteMargen(w http.ResponseWriter, r *http.Request) {
+	desde, hasta := rangoReporte(r)
+
+	datos, err := m.servicio.ObtenerReporteMargen(desde, hasta)
+	if err != nil {
+		log.Printf("Error al obtener reporte de margen: %v", err)
+		http.Error(w, "Error al cargar reporte", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.ReporteMargen(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar reporte de margen: %v", err)
+	}
+}
+
+// rangoReporte lee desde/hasta de la query; usa el mes en curso si faltan o son inválidos
+func rangoReporte(r *http.Request) (time.Time, time.Time) {
+	hoy := utils.Hoy()
+	desde := time.Date(hoy.Year(), hoy.Month(), 1, 0, 0, 0, 0, time.UTC)
+	hasta := desde.AddDate(0, 1, -1)
+
+	if d, err := utils.ParsearFecha(r.URL.Query().Get("desde")); err == nil {
+		desde = d
+	}
+	if h, err := utils.ParsearFecha(r.URL.Query().Get("hasta")); err == nil {
+		hasta = h
+	}
+	if hasta.Before(desde) {
+		desde, hasta = hasta, desde
+	}
+	return desde, hasta
+}
+
+// ReporteDescuentos — GET /reportes/descuentos?desde=&hasta=
+// Descuentos y becas otorgados, por estudiante y por regla.
+func (m *Controlador) ReporteDescuentos(w http.ResponseWriter, r *http.Request) {
+	desde, hasta := rangoReporte(r)
+
+	datos, err := m.servicio.ObtenerReporteDescuentos(desde, hasta)
+	if err != nil {
+		log.Printf("Error al obtener reporte de descuentos: %v", err)
+		http.Error(w, "Error al cargar reporte", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.ReporteDescuentos(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar reporte de descuentos: %v", err)
+	}
+}
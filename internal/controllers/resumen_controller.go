@@ -2,11 +2,12 @@ package controllers
 
 import (
 	"kiosco/internal/models"
+	"kiosco/internal/services"
+	"kiosco/internal/utils"
 	"kiosco/templates/pages"
 	"log"
 	"net/http"
 	"strings"
-	"time"
 )
 
 // ResumenSector — GET /resumen/{sector}
@@ -18,17 +19,17 @@ func (m *Controlador) ResumenSector(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sector := strings.TrimPrefix(r.URL.Path, "/resumen/")
-	if sector != "menor" && sector != "mayor" {
+	if !services.SectorValido(sector) {
 		http.Error(w, "Sector inválido", http.StatusBadRequest)
 		return
 	}
 
 	fechaStr := r.URL.Query().Get("fecha")
 	if fechaStr == "" {
-		fechaStr = time.Now().Format("2006-01-02")
+		fechaStr = utils.FormatearFechaCompleta(utils.Hoy())
 	}
 
-	fecha, err := time.Parse("2006-01-02", fechaStr)
+	fecha, err := utils.ParsearFecha(fechaStr)
 	if err != nil {
 		http.Error(w, "Fecha inválida", http.StatusBadRequest)
 		return
@@ -41,7 +42,14 @@ func (m *Controlador) ResumenSector(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fechas := generarFechasSemana(fechaStr)
+	calendario, err := m.servicio.ObtenerCalendarioSemana(fecha)
+	if err != nil {
+		log.Printf("Error al obtener calendario: %v", err)
+		http.Error(w, "Error al cargar calendario", http.StatusInternalServerError)
+		return
+	}
+
+	fechas := generarFechasSemana(fechaStr, calendario)
 
 	// Calcular total de ítems para badge en navbar
 	totalItems := 0
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"kiosco/internal/models"
+	"kiosco/internal/services"
 	"kiosco/internal/utils"
 	"kiosco/templates/pages"
 	"log"
@@ -38,13 +40,20 @@ func (m *Controlador) AgregarEstudiante(w http.ResponseWriter, r *http.Request)
 
 	apellidos := strings.TrimSpace(r.FormValue("apellidos"))
 	nombres := strings.TrimSpace(r.FormValue("nombres"))
-	idGrado, err := strconv.Atoi(r.FormValue("id_grado"))
-	if err != nil || apellidos == "" || nombres == "" {
+	idGrado, _ := strconv.Atoi(r.FormValue("id_grado"))
+	if apellidos == "" || nombres == "" {
 		http.Error(w, "Datos inválidos", http.StatusBadRequest)
 		return
 	}
 
-	est, err := m.servicio.Repo.InsertarEstudiante(nombres, apellidos, idGrado)
+	// Docentes, personal y otros clientes no llevan grado
+	tipo, idGrado, err := services.ValidarTipoCliente(r.FormValue("tipo"), idGrado)
+	if err != nil {
+		http.Error(w, "Datos inválidos: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	est, err := m.servicio.Repo.InsertarEstudiante(nombres, apellidos, idGrado, tipo)
 	if err != nil {
 		log.Printf("Error al insertar estudiante: %v", err)
 		http.Error(w, "Error al agregar estudiante", http.StatusInternalServerError)
@@ -52,6 +61,9 @@ func (m *Controlador) AgregarEstudiante(w http.ResponseWriter, r *http.Request)
 	}
 
 	est.NombreGrado = utils.NombreGrado(idGrado)
+	if !est.EsEstudiante() {
+		est.NombreGrado = models.NombreTipoCliente(tipo)
+	}
 
 	grados := utils.ObtenerGradosEstaticos()
 
@@ -80,13 +92,25 @@ func (m *Controlador) ActualizarEstudiante(w http.ResponseWriter, r *http.Reques
 	idEstudiante, err := strconv.Atoi(r.FormValue("id_estudiante"))
 	apellidos := strings.TrimSpace(r.FormValue("apellidos"))
 	nombres := strings.TrimSpace(r.FormValue("nombres"))
-	idGrado, errGrado := strconv.Atoi(r.FormValue("id_grado"))
-	if err != nil || errGrado != nil || apellidos == "" || nombres == "" {
+	idGrado, _ := strconv.Atoi(r.FormValue("id_grado"))
+	if err != nil || apellidos == "" || nombres == "" {
 		http.Error(w, "Datos inválidos", http.StatusBadRequest)
 		return
 	}
 
-	if err := m.servicio.Repo.ActualizarEstudiante(idEstudiante, nombres, apellidos, idGrado); err != nil {
+	tipo, idGrado, err := services.ValidarTipoCliente(r.FormValue("tipo"), idGrado)
+	if err != nil {
+		http.Error(w, "Datos inválidos: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codigo, err := m.servicio.ValidarCodigoEstudiante(idEstudiante, r.FormValue("codigo"))
+	if err != nil {
+		http.Error(w, "Código de carné inválido: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.Repo.ActualizarEstudiante(idEstudiante, nombres, apellidos, idGrado, codigo, tipo); err != nil {
 		log.Printf("Error al actualizar estudiante %d: %v", idEstudiante, err)
 		http.Error(w, "Error al actualizar estudiante", http.StatusInternalServerError)
 		return
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"kiosco/internal/services"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+)
+
+// Superadmin — GET /superadmin?desde=&hasta=
+// Ventas, margen, cobros y saldo por cobrar de cada colegio del binario y la
+// suma de todos. Solo para usuarios con es_superadmin = 1 en su colegio.
+func (m *Controlador) Superadmin(w http.ResponseWriter, r *http.Request) {
+	es, err := m.servicio.Repo.EsSuperadmin(usuarioActual(r))
+	if err != nil {
+		log.Printf("Error al verificar superadministrador: %v", err)
+		http.Error(w, "Error al verificar permisos", http.StatusInternalServerError)
+		return
+	}
+	if !es {
+		log.Printf("⚠️ Permission denied (not superadmin) from %s on %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+		http.Error(w, "Se requiere permiso de superadministrador", http.StatusForbidden)
+		return
+	}
+
+	desde, hasta := rangoReporte(r)
+	colegios := make([]services.ServicioColegio, 0, len(m.colegios))
+	for _, c := range m.colegios {
+		nombre := c.colegio
+		if nombre == "" {
+			nombre = "Principal"
+		}
+		colegios = append(colegios, services.ServicioColegio{Nombre: nombre, Servicio: c.servicio})
+	}
+
+	datos := services.ObtenerDatosSuperadmin(colegios, desde, hasta)
+	if err := pages.Superadmin(datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar superadmin: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"kiosco/internal/utils"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TokensAPI lista los tokens de cuentas de servicio
+func (m *Controlador) TokensAPI(w http.ResponseWriter, r *http.Request) {
+	datos, err := m.servicio.ObtenerDatosTokensAPI()
+	if err != nil {
+		log.Printf("Error al obtener tokens: %v", err)
+		http.Error(w, "Error al cargar tokens", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.TokensAPI(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar tokens: %v", err)
+	}
+}
+
+// CrearTokenAPI genera un token y muestra su valor en la misma respuesta.
+// No se redirige: el valor en claro no se puede volver a obtener.
+func (m *Controlador) CrearTokenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	var expira *time.Time
+	if v := r.FormValue("expira_en"); v != "" {
+		f, err := utils.ParsearFecha(v)
+		if err != nil {
+			http.Error(w, "Fecha de expiración inválida", http.StatusBadRequest)
+			return
+		}
+		expira = &f
+	}
+
+	nombre := r.FormValue("nombre")
+	valor, err := m.servicio.CrearTokenAPI(nombre, r.FormValue("alcance"), expira, usuarioActual(r))
+	if err != nil {
+		log.Printf("Error al crear token: %v", err)
+		http.Error(w, "Error al crear token: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	datos, err := m.servicio.ObtenerDatosTokensAPI()
+	if err != nil {
+		log.Printf("Error al obtener tokens: %v", err)
+		http.Error(w, "Error al cargar tokens", http.StatusInternalServerError)
+		return
+	}
+	datos.TokenNuevo = valor
+	datos.NombreNuevo = nombre
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := pages.TokensAPI(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar tokens: %v", err)
+	}
+}
+
+// RevocarTokenAPI invalida un token de servicio
+func (m *Controlador) RevocarTokenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idToken, err := strconv.Atoi(r.FormValue("id_token"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.Repo.RevocarTokenAPI(idToken); err != nil {
+		log.Printf("Error al revocar token %d: %v", idToken, err)
+		http.Error(w, "Error al revocar token", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/tokens", http.StatusSeeOther)
+}
+
+// VerificarTokenServicio expone la verificación de tokens de servicio al middleware de la API
+func (m *Controlador) VerificarTokenServicio(token string) (bool, bool) {
+	return m.servicio.VerificarTokenServicio(token)
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"kiosco/internal/middleware"
+	"kiosco/internal/models"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// VentaContado — GET /venta-contado?venta=
+// Modo caja para ventas en efectivo sin cuenta (docentes, visitantes). Con
+// venta muestra el vuelto de la venta recién cobrada.
+func (m *Controlador) VentaContado(w http.ResponseWriter, r *http.Request) {
+	idVenta, _ := strconv.Atoi(r.URL.Query().Get("venta"))
+
+	datos, err := m.servicio.ObtenerDatosVentaContado(idVenta, puedeEditar(r))
+	if err != nil {
+		log.Printf("Error al obtener ventas al contado: %v", err)
+		http.Error(w, "Error al cargar la venta al contado", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.VentaContado(datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar venta al contado: %v", err)
+	}
+}
+
+// CobrarVentaContado — POST /venta-contado
+// Campos cantidad_<id_producto>, recibido y clave_idempotencia. Si la venta no
+// es válida vuelve a mostrar la pantalla con lo enviado.
+func (m *Controlador) CobrarVentaContado(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	valores := make(map[int]string)
+	for campo := range r.Form {
+		sufijo, ok := strings.CutPrefix(campo, "cantidad_")
+		if !ok {
+			continue
+		}
+		idProducto, err := strconv.Atoi(sufijo)
+		if err != nil {
+			http.Error(w, "Campo de cantidad inválido: "+campo, http.StatusBadRequest)
+			return
+		}
+		valores[idProducto] = r.FormValue(campo)
+	}
+	recibido := r.FormValue("recibido")
+
+	venta, err := m.servicio.ValidarVentaContado(valores, recibido)
+	if err != nil {
+		m.mostrarErrorVentaContado(w, r, valores, recibido, "No se cobró la venta: "+err.Error()+".", http.StatusUnprocessableEntity)
+		return
+	}
+
+	clave := r.FormValue("clave_idempotencia")
+	nueva, err := m.servicio.ReclamarSolicitud(clave, r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !nueva {
+		// Ya se cobró: es un reenvío tras volver la conexión o un doble clic
+		http.Redirect(w, r, "/venta-contado", http.StatusSeeOther)
+		return
+	}
+
+	venta.IdUsuario = usuarioActual(r)
+	if err := m.servicio.RegistrarVentaContado(&venta); err != nil {
+		m.servicio.LiberarSolicitud(clave)
+		log.Printf("Error al registrar venta al contado: %v", err)
+		m.mostrarErrorVentaContado(w, r, valores, recibido, "No se cobró la venta: ocurrió un error al guardar. Intente de nuevo.", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/venta-contado?venta="+strconv.Itoa(venta.IdVenta), http.StatusSeeOther)
+}
+
+// AnularVentaContado — POST /venta-contado/anular
+// Anula una venta al contado del día y devuelve su stock. Si es de otro día o
+// su caja ya se cerró vuelve a mostrar la pantalla con el motivo.
+func (m *Controlador) AnularVentaContado(w http.ResponseWriter, r *http.Request) {
+	idVenta, err := strconv.Atoi(r.FormValue("id_venta"))
+	if err != nil {
+		http.Error(w, "ID de venta inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.ValidarAnulacionVentaContado(idVenta); err != nil {
+		m.mostrarErrorVentaContado(w, r, nil, "", "No se anuló la venta: "+err.Error()+".", http.StatusConflict)
+		return
+	}
+
+	if err := m.servicio.AnularVentaContado(idVenta); err != nil {
+		log.Printf("Error al anular venta al contado: %v", err)
+		http.Error(w, "Error al anular la venta", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/venta-contado", http.StatusSeeOther)
+}
+
+// mostrarErrorVentaContado vuelve a mostrar la pantalla de venta con el ticket
+// que se había armado y el motivo del rechazo
+func (m *Controlador) mostrarErrorVentaContado(w http.ResponseWriter, r *http.Request, valores map[int]string, recibido, aviso string, estado int) {
+	datos, err := m.servicio.ObtenerDatosVentaContado(0, puedeEditar(r))
+	if err != nil {
+		log.Printf("Error al obtener ventas al contado: %v", err)
+		http.Error(w, "Error al cargar la venta al contado", http.StatusInternalServerError)
+		return
+	}
+	datos.Error = aviso
+	datos.Recibido = recibido
+	datos.Cantidades = make(map[int]int, len(valores))
+	for idProducto, valor := range valores {
+		if cantidad, err := strconv.Atoi(strings.TrimSpace(valor)); err == nil && cantidad > 0 {
+			datos.Cantidades[idProducto] = min(cantidad, models.MaxCantidadPorProducto)
+		}
+	}
+
+	// El formulario necesita el token CSRF y esta ruta no lo inyecta
+	ctx := middleware.InyectarCSRFToken(w, r)
+	w.WriteHeader(estado)
+	if err := pages.VentaContado(datos).Render(ctx, w); err != nil {
+		log.Printf("Error al renderizar venta al contado: %v", err)
+	}
+}
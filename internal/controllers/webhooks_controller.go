@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"kiosco/internal/models"
+	"kiosco/templates/pages"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Webhooks muestra los destinos configurados y el registro de entregas (?estado=)
+func (m *Controlador) Webhooks(w http.ResponseWriter, r *http.Request) {
+	estado := r.URL.Query().Get("estado")
+	switch estado {
+	case "", models.EntregaPendiente, models.EntregaEntregada, models.EntregaFallida:
+	default:
+		http.Error(w, "Estado inválido", http.StatusBadRequest)
+		return
+	}
+
+	datos, err := m.servicio.ObtenerDatosWebhooks(estado)
+	if err != nil {
+		log.Printf("Error al obtener webhooks: %v", err)
+		http.Error(w, "Error al cargar webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pages.Webhooks(*datos).Render(r.Context(), w); err != nil {
+		log.Printf("Error al renderizar webhooks: %v", err)
+	}
+}
+
+// CrearWebhook registra un destino con sus eventos (campos "evento" repetidos)
+func (m *Controlador) CrearWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.CrearWebhook(r.FormValue("url"), r.FormValue("secreto"), r.Form["evento"]); err != nil {
+		log.Printf("Error al crear webhook: %v", err)
+		http.Error(w, "Error al guardar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/webhooks", http.StatusSeeOther)
+}
+
+// AccionWebhook pausa, reactiva, prueba o elimina un webhook (campo "accion")
+func (m *Controlador) AccionWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idWebhook, err := strconv.Atoi(r.FormValue("id_webhook"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	switch r.FormValue("accion") {
+	case "pausar":
+		err = m.servicio.Repo.CambiarEstadoWebhook(idWebhook, false)
+	case "activar":
+		err = m.servicio.Repo.CambiarEstadoWebhook(idWebhook, true)
+	case "probar":
+		err = m.servicio.ProbarWebhook(idWebhook)
+	case "eliminar":
+		err = m.servicio.Repo.EliminarWebhook(idWebhook)
+	default:
+		http.Error(w, "Acción inválida", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error en webhook %d (%s): %v", idWebhook, r.FormValue("accion"), err)
+		http.Error(w, "Error al actualizar webhook", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/webhooks", http.StatusSeeOther)
+}
+
+// ReintentarEntregaWebhook vuelve a encolar una entrega fallida
+func (m *Controlador) ReintentarEntregaWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error al procesar formulario", http.StatusBadRequest)
+		return
+	}
+
+	idEntrega, err := strconv.Atoi(r.FormValue("id_entrega"))
+	if err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.servicio.Repo.ReintentarEntregaWebhook(idEntrega); err != nil {
+		log.Printf("Error al reintentar entrega %d: %v", idEntrega, err)
+		http.Error(w, "Error al reintentar entrega", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/setup/webhooks?estado="+r.FormValue("estado"), http.StatusSeeOther)
+}
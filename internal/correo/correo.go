@@ -0,0 +1,201 @@
+// Package correo envía mensajes por SMTP. La configuración se lee al iniciar
+// (ver config.ObtenerConfiguracionCorreo); sin SMTP_HOST el envío queda
+// deshabilitado y los correos esperan en la bandeja de salida.
+package correo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuracion del servidor SMTP y de los enlaces incluidos en los correos
+type Configuracion struct {
+	Host       string
+	Puerto     int // 465 usa TLS directo; cualquier otro, STARTTLS si el servidor lo ofrece
+	Usuario    string
+	Password   string
+	Remitente  string // "Kiosco <kiosco@colegio.edu>"
+	URLPublica string // Base para enlaces de baja, sin / final ("" = sin enlace)
+}
+
+// Habilitado indica si hay servidor SMTP configurado
+func (c Configuracion) Habilitado() bool {
+	return c.Host != ""
+}
+
+var vigente Configuracion
+
+// Configurar valida y reemplaza la configuración. Debe llamarse al iniciar.
+func Configurar(c Configuracion) error {
+	if !c.Habilitado() {
+		vigente = c
+		return nil
+	}
+	if c.Puerto <= 0 || c.Puerto > 65535 {
+		return fmt.Errorf("puerto SMTP inválido: %d", c.Puerto)
+	}
+	if _, err := mail.ParseAddress(c.Remitente); err != nil {
+		return fmt.Errorf("remitente inválido %q: %v", c.Remitente, err)
+	}
+	c.URLPublica = strings.TrimRight(c.URLPublica, "/")
+	vigente = c
+	return nil
+}
+
+// Vigente retorna la configuración en uso
+func Vigente() Configuracion {
+	return vigente
+}
+
+// Mensaje es un correo con versión de texto y HTML
+type Mensaje struct {
+	Para    string
+	Asunto  string
+	Texto   string
+	HTML    string
+	URLBaja string // Se publica en List-Unsubscribe si no está vacío
+}
+
+const timeoutSMTP = 20 * time.Second
+
+// Enviar entrega el mensaje al servidor SMTP configurado
+func Enviar(m Mensaje) error {
+	c := vigente
+	if !c.Habilitado() {
+		return fmt.Errorf("SMTP no configurado")
+	}
+	remitente, err := mail.ParseAddress(c.Remitente)
+	if err != nil {
+		return fmt.Errorf("remitente inválido: %v", err)
+	}
+	destinatario, err := mail.ParseAddress(m.Para)
+	if err != nil {
+		return fmt.Errorf("destinatario inválido %q: %v", m.Para, err)
+	}
+	cuerpo, err := armarMensaje(remitente, destinatario, m)
+	if err != nil {
+		return err
+	}
+
+	direccion := net.JoinHostPort(c.Host, strconv.Itoa(c.Puerto))
+	tlsConfig := &tls.Config{ServerName: c.Host}
+	var conn net.Conn
+	if c.Puerto == 465 {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeoutSMTP}, "tcp", direccion, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", direccion, timeoutSMTP)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(2 * timeoutSMTP))
+
+	cliente, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer cliente.Close()
+
+	if c.Puerto != 465 {
+		if ok, _ := cliente.Extension("STARTTLS"); ok {
+			if err := cliente.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Usuario != "" {
+		// PlainAuth se niega a enviar la contraseña sin TLS salvo a localhost
+		if err := cliente.Auth(smtp.PlainAuth("", c.Usuario, c.Password, c.Host)); err != nil {
+			return err
+		}
+	}
+	if err := cliente.Mail(remitente.Address); err != nil {
+		return err
+	}
+	if err := cliente.Rcpt(destinatario.Address); err != nil {
+		return err
+	}
+	w, err := cliente.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(cuerpo); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return cliente.Quit()
+}
+
+// armarMensaje arma el mensaje MIME multipart/alternative (texto y HTML)
+func armarMensaje(remitente, destinatario *mail.Address, m Mensaje) ([]byte, error) {
+	var buf bytes.Buffer
+	partes := multipart.NewWriter(&buf)
+
+	cabeceras := []string{
+		"From: " + remitente.String(),
+		"To: " + destinatario.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", m.Asunto),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + idMensaje(remitente.Address),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + partes.Boundary(),
+	}
+	if m.URLBaja != "" {
+		cabeceras = append(cabeceras, "List-Unsubscribe: <"+m.URLBaja+">")
+	}
+	var salida bytes.Buffer
+	salida.WriteString(strings.Join(cabeceras, "\r\n") + "\r\n\r\n")
+
+	for _, p := range []struct{ tipo, contenido string }{
+		{"text/plain; charset=utf-8", m.Texto},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := partes.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.tipo},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(p.contenido)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := partes.Close(); err != nil {
+		return nil, err
+	}
+
+	salida.Write(buf.Bytes())
+	return salida.Bytes(), nil
+}
+
+// idMensaje genera un Message-ID único en el dominio del remitente
+func idMensaje(direccion string) string {
+	dominio := "kiosco.local"
+	if i := strings.LastIndex(direccion, "@"); i >= 0 {
+		dominio = direccion[i+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + dominio + ">"
+}
@@ -0,0 +1,76 @@
+// Package envivo reparte entre las pantallas abiertas los cambios de consumos y
+// pagos. Los servicios publican un Cambio después de cada escritura y el
+// endpoint de eventos (Server-Sent Events) lo reenvía a cada suscriptor, para
+// que varias tabletas vean lo que registran las demás sin recargar.
+//
+// Es un bus en memoria del proceso: si el kiosco corre en un solo binario,
+// todos los clientes reciben todos los cambios. Cada colegio tiene su propio
+// Bus, así las pantallas de uno no reciben los cambios de otro.
+package envivo
+
+import "sync"
+
+// Tipos de cambio
+const (
+	CambioConsumo = "consumo"
+	CambioPago    = "pago"
+)
+
+// MaxSuscriptores limita las conexiones de eventos abiertas a la vez
+const MaxSuscriptores = 100
+
+// tamanoBuffer es cuántos cambios puede acumular un suscriptor lento antes de
+// que se descarten los siguientes (la página se refresca igual con el próximo)
+const tamanoBuffer = 32
+
+// Cambio es el aviso que recibe cada pantalla abierta
+type Cambio struct {
+	Tipo         string `json:"tipo"`
+	IdEstudiante int    `json:"id_estudiante"`
+	Fecha        string `json:"fecha"` // AAAA-MM-DD del consumo o del pago
+}
+
+// Bus reparte los cambios de un colegio entre sus suscriptores
+type Bus struct {
+	mu           sync.Mutex
+	suscriptores map[chan Cambio]struct{}
+}
+
+// NuevoBus crea un bus sin suscriptores
+func NuevoBus() *Bus {
+	return &Bus{suscriptores: make(map[chan Cambio]struct{})}
+}
+
+// Suscribir registra un nuevo suscriptor. Retorna el canal de cambios y la
+// función que lo da de baja; ok es false si ya se alcanzó MaxSuscriptores.
+func (b *Bus) Suscribir() (cambios <-chan Cambio, cancelar func(), ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.suscriptores) >= MaxSuscriptores {
+		return nil, func() {}, false
+	}
+
+	c := make(chan Cambio, tamanoBuffer)
+	b.suscriptores[c] = struct{}{}
+	var una sync.Once
+	return c, func() {
+		una.Do(func() {
+			b.mu.Lock()
+			delete(b.suscriptores, c)
+			b.mu.Unlock()
+		})
+	}, true
+}
+
+// Publicar envía un cambio a todos los suscriptores sin bloquear: si el buffer de
+// uno está lleno, ese suscriptor pierde el cambio.
+func (b *Bus) Publicar(c Cambio) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.suscriptores {
+		select {
+		case s <- c:
+		default:
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"encoding/json"
+	"kiosco/internal/auth"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrorAPI es el cuerpo de todas las respuestas de error de la API JSON:
+// {"error": {"codigo": "...", "mensaje": "..."}}
+type ErrorAPI struct {
+	Error DetalleErrorAPI `json:"error"`
+}
+
+// DetalleErrorAPI describe el error con un código estable y un mensaje legible
+type DetalleErrorAPI struct {
+	Codigo  string `json:"codigo"`
+	Mensaje string `json:"mensaje"`
+}
+
+// ResponderErrorAPI escribe un error de la API con el status indicado
+func ResponderErrorAPI(w http.ResponseWriter, status int, codigo, mensaje string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(ErrorAPI{Error: DetalleErrorAPI{Codigo: codigo, Mensaje: mensaje}}); err != nil {
+		log.Printf("Error al escribir error de API: %v", err)
+	}
+}
+
+// VerificadorToken valida un token de cuenta de servicio y retorna si puede editar
+// y si es válido. Lo registra el router, porque la verificación consulta la base.
+type VerificadorToken func(token string) (puedeEditar bool, ok bool)
+
+// Un verificador por colegio: cada uno consulta los tokens de su propia base
+var (
+	muVerificadores       sync.RWMutex
+	verificadoresServicio = make(map[string]VerificadorToken)
+)
+
+// RegistrarVerificadorTokens habilita los tokens de servicio del colegio en
+// RequiereTokenAPI ("" con un solo colegio).
+func RegistrarVerificadorTokens(colegio string, v VerificadorToken) {
+	muVerificadores.Lock()
+	defer muVerificadores.Unlock()
+	verificadoresServicio[colegio] = v
+}
+
+// RequiereTokenAPI verifica el token Bearer de la cabecera Authorization.
+// Acepta tokens de sesión firmados (POST /api/v1/auth/token) y tokens de
+// cuentas de servicio creados en /setup/tokens.
+// Es independiente de la cookie de sesión: la API no usa cookies ni CSRF.
+// Si edicion es true exige además puede_editar = 1 (o alcance de edición).
+func RequiereTokenAPI(edicion bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kiosco"`)
+			ResponderErrorAPI(w, http.StatusUnauthorized, "no_autenticado", "Falta el token Bearer en la cabecera Authorization")
+			return
+		}
+
+		token = strings.TrimSpace(token)
+		_, puedeEditar, valido := auth.VerificarTokenAPI(Colegio(r), token)
+		if !valido {
+			muVerificadores.RLock()
+			verificarTokenServicio := verificadoresServicio[Colegio(r)]
+			muVerificadores.RUnlock()
+			if verificarTokenServicio != nil {
+				puedeEditar, valido = verificarTokenServicio(token)
+			}
+		}
+		if !valido {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kiosco", error="invalid_token"`)
+			ResponderErrorAPI(w, http.StatusUnauthorized, "token_invalido", "El token es inválido o ha expirado")
+			return
+		}
+
+		if edicion && !puedeEditar {
+			log.Printf("⚠️ API permission denied (no edit permission) from %s on %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+			ResponderErrorAPI(w, http.StatusForbidden, "sin_permiso", "El usuario no tiene permiso de edición")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ProtegerAPI adapta un HandlerFunc para requerir un token de la API válido.
+func ProtegerAPI(h http.HandlerFunc) http.HandlerFunc {
+	return RequiereTokenAPI(false, h).ServeHTTP
+}
+
+// ProtegerAPIEdicion adapta un HandlerFunc para requerir token con permiso de edición.
+func ProtegerAPIEdicion(h http.HandlerFunc) http.HandlerFunc {
+	return RequiereTokenAPI(true, h).ServeHTTP
+}
+
+// ProtegerTokenAPI aplica el mismo rate limiting del login a la emisión de tokens.
+func ProtegerTokenAPI(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !verificarRateLimit(r) {
+			ResponderErrorAPI(w, http.StatusTooManyRequests, "demasiados_intentos", "Demasiados intentos fallidos, intenta de nuevo en 15 minutos")
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// ColegioContextKey guarda en el context el nombre del colegio de la solicitud
+const ColegioContextKey contextKey = "colegio"
+
+// ConColegio marca las solicitudes de un colegio antes de pasarlas a sus rutas.
+// Las cookies y tokens se firman y verifican con ese colegio.
+func ConColegio(nombre string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ColegioContextKey, nombre)))
+	})
+}
+
+// Colegio retorna el colegio de la solicitud ("" con un solo colegio)
+func Colegio(r *http.Request) string {
+	nombre, _ := r.Context().Value(ColegioContextKey).(string)
+	return nombre
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
 	"kiosco/internal/auth"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // cookieInvalida borra la cookie y redirige al login
@@ -29,7 +32,7 @@ func RequiereAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		if _, _, ok := auth.VerificarToken(cookie.Value); !ok {
+		if _, _, ok := auth.VerificarToken(Colegio(r), cookie.Value); !ok {
 			cookieInvalida(w, r)
 			return
 		}
@@ -57,7 +60,7 @@ func RequiereEdicion(next http.Handler) http.Handler {
 			return
 		}
 
-		_, puedeEditar, ok := auth.VerificarToken(cookie.Value)
+		_, puedeEditar, ok := auth.VerificarToken(Colegio(r), cookie.Value)
 		if !ok {
 			cookieInvalida(w, r)
 			return
@@ -71,7 +74,7 @@ func RequiereEdicion(next http.Handler) http.Handler {
 
 		// Validar CSRF en POSTs
 		if r.Method == "POST" {
-			if err := r.ParseForm(); err != nil {
+			if err := parsearFormulario(w, r); err != nil {
 				log.Printf("⚠️ ParseForm error from %s on %s %s: %v", r.RemoteAddr, r.Method, r.URL.Path, err)
 				http.Error(w, "Invalid form data", http.StatusBadRequest)
 				return
@@ -90,6 +93,19 @@ func RequiereEdicion(next http.Handler) http.Handler {
 	})
 }
 
+// maxFormularioArchivo limita los formularios con archivos (extractos CSV)
+const maxFormularioArchivo = 10 << 20
+
+// parsearFormulario lee el formulario de un POST, también los multipart: sin esto
+// el csrf_token de un formulario con archivo no llegaría a validarCSRF.
+func parsearFormulario(w http.ResponseWriter, r *http.Request) error {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.ParseForm()
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormularioArchivo)
+	return r.ParseMultipartForm(maxFormularioArchivo)
+}
+
 // ProtegerEdicion es un helper para adaptar HandlerFunc con RequiereEdicion.
 func ProtegerEdicion(h http.HandlerFunc) http.HandlerFunc {
 	return RequiereEdicion(h).ServeHTTP
@@ -142,6 +158,60 @@ func ProtegerLogin(h http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
+// ProtegerPublico es para páginas sin sesión que tienen formulario (ej. la baja
+// de correos): valida CSRF en POST e inyecta el token en GET y POST.
+func ProtegerPublico(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			if err := r.ParseForm(); err != nil {
+				log.Printf("⚠️ ParseForm error from %s on %s %s: %v", r.RemoteAddr, r.Method, r.URL.Path, err)
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+			if !validarCSRF(r) {
+				http.Error(w, "CSRF token invalid", http.StatusForbidden)
+				return
+			}
+		}
+
+		r = r.WithContext(InyectarCSRFToken(w, r))
+		h(w, r)
+	}
+}
+
+// SesionPortalContextKey guarda la SesionPortal del apoderado en el context
+const SesionPortalContextKey contextKey = "sesion_portal"
+
+// SesionPortal identifica al apoderado con sesión en el portal
+type SesionPortal struct {
+	IdApoderado int
+	Emitida     time.Time // Una sesión anterior al último enlace generado ya no vale
+}
+
+// ProtegerPortal exige la cookie del portal de apoderados. Es independiente de
+// la sesión del personal: con ella no se entra a ninguna ruta de Proteger, y la
+// sesión del personal tampoco sirve aquí. Sin cookie válida redirige a
+// /portal/acceso, que explica cómo entrar.
+func ProtegerPortal(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(auth.CookiePortal)
+		if err != nil || cookie.Value == "" {
+			http.Redirect(w, r, "/portal/acceso", http.StatusSeeOther)
+			return
+		}
+		idApoderado, emitida, ok := auth.VerificarTokenPortal(Colegio(r), cookie.Value)
+		if !ok {
+			http.SetCookie(w, &http.Cookie{Name: auth.CookiePortal, Value: "", Path: "/portal", HttpOnly: true, MaxAge: -1})
+			http.Redirect(w, r, "/portal/acceso", http.StatusSeeOther)
+			return
+		}
+
+		sesion := SesionPortal{IdApoderado: idApoderado, Emitida: emitida}
+		ctx := context.WithValue(InyectarCSRFToken(w, r), SesionPortalContextKey, sesion)
+		h(w, r.WithContext(ctx))
+	}
+}
+
 // IncrementarIntentosLogin es llamado por el handler de login cuando falla autenticación
 func IncrementarIntentosLogin(r *http.Request) {
 	incrementarIntentosLogin(r)
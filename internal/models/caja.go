@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// SesionCaja es el turno de un cajero: abre con un fondo en efectivo y cierra
+// con el arqueo (lo contado) contra lo esperado
+type SesionCaja struct {
+	IdSesion     int
+	IdUsuario    int
+	Usuario      string // Nombre de usuario del cajero
+	MontoInicial float64
+	AbiertaEn    time.Time
+	CerradaEn    *time.Time // nil mientras está abierta
+	TotalPagos   float64    // Pagos en efectivo del turno (congelado al cerrar)
+	TotalVentas  float64    // Ventas al contado no anuladas (congelado al cerrar)
+	MontoContado float64
+	Notas        string
+}
+
+// Abierta indica si la caja sigue abierta
+func (s SesionCaja) Abierta() bool {
+	return s.CerradaEn == nil
+}
+
+// Esperado es el efectivo que debería haber en la caja
+func (s SesionCaja) Esperado() float64 {
+	return s.MontoInicial + s.TotalPagos + s.TotalVentas
+}
+
+// Diferencia es lo contado menos lo esperado: negativa si falta dinero
+func (s SesionCaja) Diferencia() float64 {
+	return s.MontoContado - s.Esperado()
+}
+
+// PagoCaja es un pago en efectivo cobrado durante una sesión de caja
+type PagoCaja struct {
+	IdPago           int
+	IdEstudiante     int
+	NombreEstudiante string
+	Monto            float64
+	FechaPago        time.Time
+}
+
+// DatosCaja contiene los datos de la pantalla de caja del cajero actual y del
+// reporte de cierre de una sesión
+type DatosCaja struct {
+	Sesion        *SesionCaja // nil si el cajero no tiene caja abierta
+	Pagos         []PagoCaja
+	Ventas        []VentaContado // Incluye las anuladas, que no suman
+	PuedeRevisar  bool           // Supervisor: ve las sesiones de todos
+	Error         string
+	MontoInicial  string // Lo enviado, para no perderlo si hubo error
+	MontoContado  string
+	Notas         string
+	UltimaCerrada *SesionCaja // Última caja cerrada del cajero, para imprimir su reporte
+}
+
+// DatosSesionesCaja contiene el listado de sesiones de caja para supervisores
+type DatosSesionesCaja struct {
+	Desde           time.Time
+	Hasta           time.Time
+	Sesiones        []SesionCaja
+	TotalDiferencia float64 // Suma de diferencias de las sesiones cerradas
+}
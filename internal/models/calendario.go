@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Tipos de día no laborable
+const (
+	CierreFeriado    = "feriado"
+	CierreVacaciones = "vacaciones"
+	CierreOtro       = "cierre"
+)
+
+// DiaNoLaborable es un feriado o un rango de días sin atención
+type DiaNoLaborable struct {
+	IdDia       int
+	FechaDesde  time.Time
+	FechaHasta  time.Time
+	Tipo        string
+	Descripcion string
+}
+
+// Incluye indica si la fecha cae dentro del rango
+func (d DiaNoLaborable) Incluye(fecha time.Time) bool {
+	dia := fecha.Format("2006-01-02")
+	return dia >= d.FechaDesde.Format("2006-01-02") && dia <= d.FechaHasta.Format("2006-01-02")
+}
+
+// Calendario reúne los días de atención de la semana y los cierres de un rango de fechas
+type Calendario struct {
+	DiasLaborables map[time.Weekday]bool
+	Cierres        []DiaNoLaborable
+}
+
+// MotivoCierre retorna por qué no se atiende en la fecha ("" = día laborable)
+func (c Calendario) MotivoCierre(fecha time.Time) string {
+	for _, cierre := range c.Cierres {
+		if cierre.Incluye(fecha) {
+			return cierre.Descripcion
+		}
+	}
+	if !c.DiasLaborables[fecha.Weekday()] {
+		return "Sin atención"
+	}
+	return ""
+}
+
+// EsLaborable indica si el kiosco atiende en la fecha
+func (c Calendario) EsLaborable(fecha time.Time) bool {
+	return c.MotivoCierre(fecha) == ""
+}
+
+// DiasAtencion filtra las fechas a los días de la semana con atención habitual,
+// sin descontar feriados ni vacaciones
+func (c Calendario) DiasAtencion(fechas []time.Time) []time.Time {
+	var dias []time.Time
+	for _, dia := range fechas {
+		if c.DiasLaborables[dia.Weekday()] {
+			dias = append(dias, dia)
+		}
+	}
+	return dias
+}
+
+// DatosCalendario contiene los datos de la página de calendario escolar
+type DatosCalendario struct {
+	Anio           int
+	DiasLaborables map[time.Weekday]bool
+	Cierres        []DiaNoLaborable
+}
@@ -0,0 +1,16 @@
+package models
+
+// Carne es el carné imprimible de un estudiante
+type Carne struct {
+	Estudiante
+	Codigo string // Lo que lleva el QR y lee el registro (ver services.CodigoEstudiante)
+	QR     string // SVG del código QR
+}
+
+// DatosCarnes contiene las hojas de carnés para imprimir
+type DatosCarnes struct {
+	Grados            []InfoGrado
+	GradoSeleccionado int       // 0 = todos los grados
+	Hojas             [][]Carne // Una hoja A4 por elemento
+	Total             int
+}
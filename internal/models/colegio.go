@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// VentasColegio resume un colegio del binario en un rango de fechas
+type VentasColegio struct {
+	Colegio        string
+	Cuentas        int     // Estudiantes y personal activos
+	Consumos       float64 // Consumos a cuenta, incluidos cargos de planes
+	Contado        float64 // Ventas al contado no anuladas
+	Margen         float64 // Margen bruto de consumos y ventas al contado
+	Cobrado        float64 // Pagos registrados en el rango
+	SaldoPorCobrar float64 // Deuda de las cuentas activas al cierre del rango
+	Error          string  // Motivo si no se pudo leer su base
+}
+
+// Ventas es lo vendido en el rango: a cuenta más al contado
+func (v VentasColegio) Ventas() float64 {
+	return v.Consumos + v.Contado
+}
+
+// DatosSuperadmin contiene las ventas de todos los colegios y su suma
+type DatosSuperadmin struct {
+	Desde    time.Time
+	Hasta    time.Time
+	Colegios []VentasColegio
+	Total    VentasColegio
+}
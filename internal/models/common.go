@@ -6,6 +6,7 @@ import "time"
 type DiaConEstado struct {
 	Fecha   time.Time
 	EsHabil bool
+	Motivo  string // Feriado, vacaciones o cierre según el calendario ("" si es hábil)
 }
 
 // DatosVistaPrincipal contiene todos los datos para la vista principal
@@ -13,14 +14,16 @@ type DatosVistaPrincipal struct {
 	Semana             string
 	FechaInicio        time.Time
 	FechaFin           time.Time
-	DiasConEstado      []DiaConEstado // Todos los días con su estado
+	DiasConEstado      []DiaConEstado // Días de atención de la semana con su estado
 	DiasHabiles        []time.Time    // Solo los días habilitados
 	Productos          []Producto
 	EstudiantesConData []EstudianteConDeuda
 	ConsumosPorDia     map[int]map[string]map[int]int // [id_estudiante][fecha][id_producto]cantidad
+	TotalesPorDia      map[int]map[string]float64     // [id_estudiante][fecha]total cobrado
 	Grados             []InfoGrado
 	GradoSeleccionado  int
-	DiasDeshabilitados string // Parámetro URL con fechas separadas por comas
+	DiasDeshabilitados string // Parámetro URL con fechas separadas por comas (además del calendario)
+	ProductosStockBajo []Producto // Productos con inventario agotado o bajo el mínimo
 }
 
 // DatosEditarConsumos contiene los datos para editar consumos de un día
@@ -31,8 +34,31 @@ type DatosEditarConsumos struct {
 	Productos         []Producto
 	Consumos          map[int]map[string]map[int]int
 	GradoSeleccionado int
-	Sector            string // "menor" | "mayor" | "" (empty = entrada desde grilla semanal)
-	PuedeEditar       bool   // Permisos del usuario autenticado
+	Sector            string         // "menor" | "mayor" | "" (empty = entrada desde grilla semanal)
+	PuedeEditar       bool           // Permisos del usuario autenticado
+	MotivoCierre      string         // Día sin atención según el calendario ("" si se atiende)
+	Version           string         // Versión de los consumos del día al abrir el formulario
+	Errores           map[int]string // Errores al guardar por producto (id_producto → mensaje)
+	ErrorGeneral      string         // Error al guardar que no corresponde a un producto del formulario
+}
+
+// FilaConflictoConsumo compara, para un producto, la cantidad enviada con la guardada por otra edición
+type FilaConflictoConsumo struct {
+	IdProducto int
+	Nombre     string
+	Enviada    int
+	Actual     int
+}
+
+// DatosConflictoConsumos contiene los datos de la página de conflicto al guardar un día
+type DatosConflictoConsumos struct {
+	IdEstudiante      int
+	NombreEstudiante  string
+	Fecha             time.Time
+	GradoSeleccionado int
+	Sector            string
+	Version           string // Versión actual: reenviar con ella sobrescribe la otra edición
+	Filas             []FilaConflictoConsumo
 }
 
 // DatosEditarPagos contiene los datos para editar pagos de una semana
@@ -59,6 +85,7 @@ type DatosConsumoSemanal struct {
 	Pagos             float64
 	Total             float64
 	GradoSeleccionado int
+	Mensual           bool // Estado de cuenta del mes (personal) en lugar de la semana
 }
 
 // DiaFecha representa un día de la semana con su fecha formateada
@@ -67,4 +94,5 @@ type DiaFecha struct {
 	Fecha        string // "2025-04-14" (para el value del select)
 	FechaFormato string // "14 Abr"     (para mostrar al usuario)
 	EsHoy        bool
+	Motivo       string // Motivo de cierre según el calendario ("" si se atiende)
 }
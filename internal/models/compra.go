@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Proveedor representa a quien se le compra mercadería
+type Proveedor struct {
+	IdProveedor int
+	Nombre      string
+	Telefono    string
+	EstaActivo  bool
+}
+
+// ItemCompra representa una línea de una compra a proveedor
+type ItemCompra struct {
+	IdProducto     int
+	NombreProducto string // Para mostrar en la vista
+	Cantidad       int
+	CostoUnitario  float64
+	TotalLinea     float64
+}
+
+// Compra representa una compra a proveedor con sus ítems
+type Compra struct {
+	IdCompra        int
+	IdProveedor     int
+	NombreProveedor string // Para mostrar en la vista
+	FechaCompra     time.Time
+	Nota            string
+	Items           []ItemCompra
+	Total           float64
+}
+
+// DatosCompras contiene los datos para la página de compras
+type DatosCompras struct {
+	Proveedores []Proveedor
+	Productos   []Producto
+	Compras     []Compra // Compras recientes
+}
+
+// MargenProducto resume ingresos, costo y margen bruto de un producto
+type MargenProducto struct {
+	IdProducto     int
+	NombreProducto string
+	Cantidad       int
+	Ingresos       float64
+	Costo          float64
+	Margen         float64
+}
+
+// MargenPeriodo resume ingresos, costo y margen bruto de una semana
+type MargenPeriodo struct {
+	Inicio       time.Time // Primer día de la semana escolar
+	Ingresos     float64
+	Costo        float64
+	Margen       float64
+	DiasAtencion int     // Días con atención según el calendario escolar
+	Contado      float64 // Ventas al contado, aparte de los consumos a cuenta
+}
+
+// IngresoDiario retorna el ingreso promedio por día de atención
+func (m MargenPeriodo) IngresoDiario() float64 {
+	if m.DiasAtencion == 0 {
+		return 0
+	}
+	return m.Ingresos / float64(m.DiasAtencion)
+}
+
+// DatosReporteMargen contiene los datos para el reporte de margen bruto
+type DatosReporteMargen struct {
+	Desde         time.Time
+	Hasta         time.Time
+	PorProducto   []MargenProducto
+	PorPeriodo    []MargenPeriodo
+	TotalIngresos float64
+	TotalCosto    float64
+	TotalMargen   float64
+	TotalCompras  float64 // Compras a proveedores registradas en el rango
+	TotalPlanes   float64 // Cargos fijos de planes de alimentación en el rango
+	Unidades      []UnidadesProducto
+
+	// Ventas al contado (sin cuenta de estudiante), aparte de los consumos
+	ContadoPorProducto []MargenProducto
+	TotalContado       float64
+	CostoContado       float64
+	MargenContado      float64
+}
+
+// PorcentajeMargen retorna el margen como porcentaje de los ingresos
+func (m MargenProducto) PorcentajeMargen() float64 {
+	if m.Ingresos == 0 {
+		return 0
+	}
+	return m.Margen / m.Ingresos * 100
+}
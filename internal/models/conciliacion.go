@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Estados de un movimiento de extracto
+const (
+	MovimientoPendiente  = "pendiente"
+	MovimientoConfirmado = "confirmado"
+	MovimientoDescartado = "descartado"
+)
+
+// Criterios con los que se sugiere el estudiante de un movimiento
+const (
+	CriterioCodigo = "codigo" // El código de pago aparece en la descripción
+	CriterioMonto  = "monto"  // El monto coincide con el saldo de un único estudiante
+)
+
+// Extracto es un archivo CSV de banco o billetera importado
+type Extracto struct {
+	IdExtracto    int
+	NombreArchivo string
+	Origen        string // banco, yape, plin u otro
+	Lineas        int    // Abonos nuevos
+	Repetidas     int    // Abonos que ya estaban en otro extracto
+	CargadoEn     time.Time
+}
+
+// MovimientoExtracto es un abono de un extracto por conciliar con un pago
+type MovimientoExtracto struct {
+	IdMovimiento     int
+	IdExtracto       int
+	Fecha            time.Time
+	Monto            float64
+	Descripcion      string
+	Ordenante        string
+	Huella           string // Identifica el abono aunque se importe en otro extracto
+	Estado           string
+	IdEstudiante     int // Sugerido o elegido por el tesorero; 0 si no hay
+	NombreEstudiante string
+	Criterio         string // "" si el tesorero lo eligió a mano
+	IdPago           int    // Pago creado al confirmar
+	ResueltoEn       *time.Time
+	Origen           string // El del extracto: define el método del pago
+}
+
+// DatosConciliacion contiene los datos para la página de conciliación de pagos
+type DatosConciliacion struct {
+	Pendientes  []MovimientoExtracto
+	Resueltos   []MovimientoExtracto // Últimos confirmados y descartados
+	Extractos   []Extracto
+	Estudiantes []Estudiante // Para elegir a mano
+	Origenes    []string
+	Aviso       string
+}
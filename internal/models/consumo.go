@@ -33,3 +33,23 @@ type ConsumoProducto struct {
 	Precio   float64
 	Total    float64
 }
+
+// MaxCantidadPorProducto es el máximo de unidades de un producto que se aceptan
+// para un estudiante en un día; evita registrar por error cantidades como 100
+const MaxCantidadPorProducto = 50
+
+// LineaConsumoDia es la cantidad que el formulario de un día fija para un producto
+type LineaConsumoDia struct {
+	IdProducto int
+	Cantidad   int
+	Precio     PrecioAplicado
+}
+
+// ResultadoConsumosDia describe cómo terminó el guardado de los consumos de un día
+type ResultadoConsumosDia struct {
+	Repetida          bool        // La clave de idempotencia ya se había usado: no se aplicó nada
+	Conflicto         bool        // Otra edición cambió el día desde que se abrió el formulario
+	Version           string      // Versión vigente tras guardar (o la actual si hubo conflicto)
+	Anteriores        map[int]int // Cantidades por producto antes de guardar (o actuales si hubo conflicto)
+	IdProductoFallido int         // Producto cuya línea falló al guardar (0 si no aplica)
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Tipos de correo a apoderados
+const (
+	CorreoEstadoCuenta = "estado_cuenta"
+	CorreoRecordatorio = "recordatorio"
+	CorreoPrueba       = "prueba"
+)
+
+// Estados de un correo en la bandeja de salida
+const (
+	CorreoPendiente = "pendiente"
+	CorreoEnviado   = "enviado"
+	CorreoFallido   = "fallido"
+)
+
+// Apoderado es un contacto de un estudiante que recibe correos y mensajes del kiosco
+type Apoderado struct {
+	IdApoderado      int
+	IdEstudiante     int
+	NombreEstudiante string // Apellidos, Nombres — para mostrar en la vista
+	Nombre           string
+	Email            string // "" si solo tiene teléfono
+	Telefono         string // Formato internacional (+51...), "" si solo tiene correo
+	RecibeCorreos    bool   // false = se dio de baja de los correos
+	RecibeMensajes   bool   // false = se dio de baja de WhatsApp/SMS
+	TokenBaja        string
+	PortalGeneradoEn *time.Time // Último enlace al portal; nil = sin acceso
+	CreadoEn         time.Time
+}
+
+// AceptaCorreos indica si se le pueden enviar correos
+func (a Apoderado) AceptaCorreos() bool {
+	return a.Email != "" && a.RecibeCorreos
+}
+
+// AceptaMensajes indica si se le pueden enviar mensajes de WhatsApp/SMS
+func (a Apoderado) AceptaMensajes() bool {
+	return a.Telefono != "" && a.RecibeMensajes
+}
+
+// Correo es un mensaje en la bandeja de salida; también sirve de registro de envíos
+type Correo struct {
+	IdCorreo       int
+	Tipo           string
+	IdApoderado    int // 0 para correos de prueba
+	IdEstudiante   int
+	Semana         *time.Time
+	Destinatario   string
+	Asunto         string
+	CuerpoTexto    string
+	CuerpoHTML     string
+	URLBaja        string // Para la cabecera List-Unsubscribe ("" si no hay URL pública)
+	Estado         string
+	Intentos       int
+	ProximoIntento time.Time
+	UltimoError    string
+	CreadoEn       time.Time
+	EnviadoEn      *time.Time
+}
+
+// DatosCorreos contiene los datos para la página de correos a apoderados
+type DatosCorreos struct {
+	SMTPConfigurado  bool
+	Remitente        string
+	Umbral           float64 // Saldo a partir del cual se envía recordatorio
+	EstadoAutomatico bool    // Enviar estados de cuenta al cerrar cada semana
+	Apoderados       []Apoderado
+	Estudiantes      []Estudiante
+	Correos          []Correo
+	Estado           string // Filtro del registro de envíos ("" = todos)
+	FechaSemana      string // Semana sugerida para los estados de cuenta (la última cerrada)
+	Aviso            string // Resultado del último envío
+	EnlacePortal     string // Enlace al portal recién generado (se muestra una vez)
+	NombrePortal     string // Apoderado del enlace recién generado
+}
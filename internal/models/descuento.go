@@ -0,0 +1,111 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Tipos de regla de descuento
+const (
+	DescuentoPorcentaje = "porcentaje" // Valor = % sobre el precio de lista
+	DescuentoMonto      = "monto"      // Valor = soles por unidad
+)
+
+// GrupoDescuento agrupa estudiantes con un mismo beneficio (p. ej. hijos del personal)
+type GrupoDescuento struct {
+	IdGrupo     int
+	Nombre      string
+	Estudiantes []Estudiante
+}
+
+// ReglaDescuento define un descuento para un estudiante o grupo, sobre un producto,
+// una categoría o todos los productos, durante un período
+type ReglaDescuento struct {
+	IdRegla       int
+	Nombre        string
+	IdEstudiante  int // 0 si aplica a un grupo
+	IdGrupo       int // 0 si aplica a un estudiante
+	IdProducto    int // 0 = no restringe por producto
+	IdCategoria   int // 0 = no restringe por categoría
+	Tipo          string
+	Valor         float64
+	VigenteDesde  time.Time
+	VigenteHasta  *time.Time // nil = sin término
+	EstaActivo    bool
+	NombreDestino string // Estudiante o grupo, para mostrar
+	NombreAlcance string // Producto o categoría, para mostrar ("" = todos)
+}
+
+// AplicaA indica si la regla cubre el producto indicado
+func (r ReglaDescuento) AplicaA(p Producto) bool {
+	switch {
+	case r.IdProducto != 0:
+		return r.IdProducto == p.IdProducto
+	case r.IdCategoria != 0:
+		return r.IdCategoria == p.IdCategoria
+	}
+	return true
+}
+
+// DescuentoSobre calcula el descuento por unidad sobre un precio de lista,
+// redondeado a céntimos y sin superar el precio
+func (r ReglaDescuento) DescuentoSobre(precio float64) float64 {
+	descuento := r.Valor
+	if r.Tipo == DescuentoPorcentaje {
+		descuento = precio * r.Valor / 100
+	}
+	descuento = math.Round(descuento*100) / 100
+	return math.Min(descuento, precio)
+}
+
+// PrecioAplicado es el precio de una línea de consumo tras aplicar descuentos o planes
+type PrecioAplicado struct {
+	PrecioLista   float64
+	Descuento     float64 // Por unidad
+	IdRegla       int     // 0 = sin descuento
+	IdSuscripcion int     // Distinto de 0 si el consumo lo cubre un plan
+}
+
+// PrecioVenta es el precio neto por unidad que se cobra; 0 si lo cubre un plan
+func (p PrecioAplicado) PrecioVenta() float64 {
+	if p.IdSuscripcion != 0 {
+		return 0
+	}
+	return p.PrecioLista - p.Descuento
+}
+
+// DescuentoEstudiante resume los descuentos otorgados a un estudiante en un período
+type DescuentoEstudiante struct {
+	IdEstudiante     int
+	NombreEstudiante string
+	NombreGrado      string
+	Bruto            float64 // Consumo a precio de lista
+	Descuento        float64
+	Neto             float64 // Lo efectivamente cobrado
+}
+
+// DescuentoRegla resume el total descontado por cada regla en un período
+type DescuentoRegla struct {
+	IdRegla   int
+	Nombre    string
+	Lineas    int
+	Descuento float64
+}
+
+// DatosDescuentos contiene los datos para la página de configuración de descuentos
+type DatosDescuentos struct {
+	Reglas      []ReglaDescuento
+	Grupos      []GrupoDescuento
+	Estudiantes []Estudiante
+	Productos   []Producto
+	Categorias  []Categoria
+}
+
+// DatosReporteDescuentos contiene los datos del reporte de descuentos otorgados
+type DatosReporteDescuentos struct {
+	Desde          time.Time
+	Hasta          time.Time
+	PorEstudiante  []DescuentoEstudiante
+	PorRegla       []DescuentoRegla
+	TotalDescuento float64
+}
@@ -1,19 +1,59 @@
 package models
 
-// Estudiante representa un estudiante
+// Tipos de cliente del kiosco; solo los estudiantes tienen grado
+const (
+	TipoEstudiante = "estudiante"
+	TipoPersonal   = "personal" // Docentes y personal del colegio
+	TipoOtro       = "otro"
+)
+
+// TiposCliente en el orden en que se ofrecen en los formularios
+var TiposCliente = []string{TipoEstudiante, TipoPersonal, TipoOtro}
+
+// Estudiante representa una cuenta del kiosco: un estudiante o, según Tipo,
+// personal del colegio u otro cliente que consume a cuenta
 type Estudiante struct {
 	IdEstudiante int
 	Nombres      string
 	Apellidos    string
-	IdGrado      int
+	IdGrado      int // 0 si no es estudiante
 	EstaActivo   bool
-	NombreGrado  string // Para mostrar en la vista
+	NombreGrado  string // Para mostrar en la vista (el tipo si no tiene grado)
+	Codigo       string // Código del carné ("" = se usa el código de pago)
+	Tipo         string
+}
+
+// EsEstudiante indica si la cuenta es de un estudiante (y tiene grado)
+func (e Estudiante) EsEstudiante() bool {
+	return e.Tipo == TipoEstudiante
+}
+
+// NombreTipoCliente retorna el nombre de un tipo de cliente para mostrar
+func NombreTipoCliente(tipo string) string {
+	switch tipo {
+	case TipoEstudiante:
+		return "Estudiante"
+	case TipoPersonal:
+		return "Personal"
+	}
+	return "Otro"
+}
+
+// TipoClienteValido indica si tipo es uno de TiposCliente
+func TipoClienteValido(tipo string) bool {
+	for _, t := range TiposCliente {
+		if t == tipo {
+			return true
+		}
+	}
+	return false
 }
 
 // EstudianteConDeuda contiene los datos del estudiante y sus cálculos
 type EstudianteConDeuda struct {
 	Estudiante
-	SubTotal      float64 // Total de consumos de la semana
+	SubTotal      float64 // Total de consumos de la semana, incluidos CargosPlan
+	CargosPlan    float64 // Cargos fijos de planes de alimentación en la semana
 	DeudaAnterior float64 // Deuda de semanas anteriores
 	Descuento     float64 // Pagos realizados
 	Total         float64 // SubTotal + DeudaAnterior - Descuento
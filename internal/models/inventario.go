@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Tipos de movimiento de stock
+const (
+	MovimientoEntrada = "entrada" // Compra o ingreso de mercadería
+	MovimientoVenta   = "venta"   // Derivado de consumos registrados
+	MovimientoMerma   = "merma"   // Pérdida, vencimiento o desperdicio
+	MovimientoAjuste  = "ajuste"  // Corrección tras conteo físico
+)
+
+// MovimientoStock representa una variación de existencias de un producto
+type MovimientoStock struct {
+	IdMovimiento   int
+	IdProducto     int
+	NombreProducto string // Para mostrar en la vista
+	Tipo           string
+	Cantidad       int // Con signo: positivo suma, negativo resta
+	Fecha          time.Time
+	Nota           string
+}
+
+// DatosInventario contiene los datos para la página de inventario
+type DatosInventario struct {
+	Productos   []Producto
+	Movimientos []MovimientoStock // Últimos movimientos registrados
+	PuedeEditar bool
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Tipos de mensaje de WhatsApp/SMS a apoderados
+const (
+	MensajeRecordatorio = "recordatorio"
+	MensajePago         = "pago"
+	MensajePrueba       = "prueba"
+)
+
+// Estados de un mensaje en la bandeja de salida (los mismos que los correos)
+const (
+	MensajePendiente = "pendiente"
+	MensajeEnviado   = "enviado"
+	MensajeFallido   = "fallido"
+)
+
+// Mensaje es un WhatsApp/SMS en la bandeja de salida; también sirve de registro
+type Mensaje struct {
+	IdMensaje      int
+	Tipo           string
+	IdApoderado    int // 0 para mensajes de prueba
+	IdEstudiante   int
+	Referencia     string // Inicio de semana (recordatorio) o id_pago (pago)
+	Telefono       string
+	Texto          string
+	Proveedor      string // Notificador que lo envió
+	Estado         string
+	Intentos       int
+	ProximoIntento time.Time
+	UltimoStatus   int // 0 si no hubo respuesta HTTP
+	UltimoError    string
+	IdExterno      string // Id en el proveedor
+	CreadoEn       time.Time
+	EnviadoEn      *time.Time
+}
+
+// DatosMensajes contiene los datos para la página de mensajes a apoderados
+type DatosMensajes struct {
+	Habilitado     bool
+	Proveedor      string
+	Umbral         float64 // Compartido con los recordatorios por correo
+	ConfirmarPagos bool    // Enviar confirmación al registrar cada pago
+	Mensajes       []Mensaje
+	Estado         string // Filtro del registro ("" = todos)
+	Aviso          string
+}
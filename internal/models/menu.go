@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// DatosMenuDia contiene los datos para armar el menú de una fecha
+type DatosMenuDia struct {
+	Fecha       time.Time
+	Categorias  []Categoria
+	Productos   []Producto   // Activos, ordenados por categoría
+	EnMenu      map[int]bool // Productos marcados para la fecha
+	PuedeEditar bool
+}
+
+// TieneMenu indica si la fecha tiene un menú definido; si no, se ofrecen todos los productos
+func (d DatosMenuDia) TieneMenu() bool {
+	return len(d.EnMenu) > 0
+}
@@ -2,10 +2,46 @@ package models
 
 import "time"
 
+// Métodos de pago; solo el efectivo entra en la caja
+const (
+	MetodoEfectivo      = "efectivo"
+	MetodoTransferencia = "transferencia"
+	MetodoBilletera     = "billetera" // Yape, Plin
+	MetodoOtro          = "otro"
+)
+
+// MetodosPago en el orden en que se ofrecen en los formularios
+var MetodosPago = []string{MetodoEfectivo, MetodoTransferencia, MetodoBilletera, MetodoOtro}
+
 // Pago representa un pago/descuento
 type Pago struct {
 	IdPago       int
 	IdEstudiante int
 	Monto        float64
 	FechaPago    time.Time
+	Metodo       string
+	IdUsuario    int // Quién lo registró (0 = API o desconocido)
+}
+
+// NombreMetodoPago retorna el nombre de un método de pago para mostrar
+func NombreMetodoPago(metodo string) string {
+	switch metodo {
+	case MetodoEfectivo:
+		return "Efectivo"
+	case MetodoTransferencia:
+		return "Transferencia"
+	case MetodoBilletera:
+		return "Yape / Plin"
+	}
+	return "Otro"
+}
+
+// MetodoPagoValido indica si metodo es uno de MetodosPago
+func MetodoPagoValido(metodo string) bool {
+	for _, m := range MetodosPago {
+		if m == metodo {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// CuentaPersonal es el estado del mes de un docente, personal u otro cliente
+// que consume a cuenta y paga a fin de mes
+type CuentaPersonal struct {
+	Estudiante
+	SaldoAnterior float64 // Deuda al inicio del mes
+	Consumos      float64 // Consumos del mes, incluidos cargos de planes
+	Pagos         float64 // Pagos del mes
+}
+
+// Saldo es lo que debe al cierre del mes (o a la fecha, si el mes está en curso)
+func (c CuentaPersonal) Saldo() float64 {
+	return c.SaldoAnterior + c.Consumos - c.Pagos
+}
+
+// DatosPersonal contiene la vista mensual de las cuentas que no son de estudiantes
+type DatosPersonal struct {
+	Mes           time.Time // Primer día del mes
+	Cuentas       []CuentaPersonal
+	TotalConsumos float64
+	TotalPagos    float64
+	TotalSaldo    float64
+}
@@ -0,0 +1,136 @@
+package models
+
+import "time"
+
+// Períodos de cobro de un plan
+const (
+	PeriodoSemanal = "semanal"
+	PeriodoMensual = "mensual"
+)
+
+// ComponenteCombo es un producto que forma parte de un combo
+type ComponenteCombo struct {
+	IdCombo          int
+	IdComponente     int
+	NombreComponente string
+	Cantidad         int
+	CostoUnitario    float64 // Costo actual del componente
+}
+
+// Combo agrupa un producto compuesto con sus componentes
+type Combo struct {
+	Producto
+	Componentes []ComponenteCombo
+}
+
+// CostoComponentes suma el costo de los componentes del combo
+func (c Combo) CostoComponentes() float64 {
+	total := 0.0
+	for _, comp := range c.Componentes {
+		total += float64(comp.Cantidad) * comp.CostoUnitario
+	}
+	return total
+}
+
+// DatosCombos contiene los datos para la página de combos
+type DatosCombos struct {
+	Combos    []Combo
+	Productos []Producto
+}
+
+// Plan es una suscripción de alimentación con monto fijo por período
+type Plan struct {
+	IdPlan          int
+	Nombre          string
+	Monto           float64
+	Periodo         string
+	IdCategoria     int    // 0 = cubre todos los productos
+	NombreCategoria string // Para mostrar en la vista
+	EstaActivo      bool
+}
+
+// Cubre indica si los consumos del producto quedan incluidos en el plan
+func (p Plan) Cubre(producto Producto) bool {
+	return p.IdCategoria == 0 || p.IdCategoria == producto.IdCategoria
+}
+
+// InicioPeriodo retorna el inicio del período de cobro que contiene la fecha:
+// el primer día de la semana escolar (inicioSemana, el de SEMANA_INICIO) o el
+// primer día del mes
+func (p Plan) InicioPeriodo(fecha time.Time, inicioSemana time.Weekday) time.Time {
+	fecha = time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, fecha.Location())
+	if p.Periodo == PeriodoSemanal {
+		return fecha.AddDate(0, 0, -((int(fecha.Weekday()) - int(inicioSemana) + 7) % 7))
+	}
+	return fecha.AddDate(0, 0, 1-fecha.Day())
+}
+
+// PrimerPeriodoDesde retorna el inicio del primer período que empieza en la
+// fecha o después de ella
+func (p Plan) PrimerPeriodoDesde(fecha time.Time, inicioSemana time.Weekday) time.Time {
+	inicio := p.InicioPeriodo(fecha, inicioSemana)
+	if inicio.Before(fecha) {
+		return p.SiguientePeriodo(inicio)
+	}
+	return inicio
+}
+
+// SiguientePeriodo retorna el inicio del período siguiente a inicio
+func (p Plan) SiguientePeriodo(inicio time.Time) time.Time {
+	if p.Periodo == PeriodoSemanal {
+		return inicio.AddDate(0, 0, 7)
+	}
+	return inicio.AddDate(0, 1, 0)
+}
+
+// Suscripcion inscribe a un estudiante en un plan
+type Suscripcion struct {
+	IdSuscripcion    int
+	Plan             Plan
+	IdEstudiante     int
+	NombreEstudiante string
+	FechaInicio      time.Time
+	FechaFin         *time.Time // nil = vigente
+}
+
+// VigenteEn indica si la suscripción cubre la fecha indicada
+func (s Suscripcion) VigenteEn(fecha time.Time) bool {
+	dia := fecha.Format("2006-01-02")
+	if dia < s.FechaInicio.Format("2006-01-02") {
+		return false
+	}
+	return s.FechaFin == nil || dia <= s.FechaFin.Format("2006-01-02")
+}
+
+// CargoPlan es el cobro fijo de un período de suscripción
+type CargoPlan struct {
+	IdCargo       int
+	IdSuscripcion int
+	IdEstudiante  int
+	NombrePlan    string
+	PeriodoInicio time.Time
+	FechaCargo    time.Time
+	Monto         float64
+}
+
+// DatosPlanes contiene los datos para la página de planes de alimentación
+type DatosPlanes struct {
+	Planes        []Plan
+	Suscripciones []Suscripcion
+	Estudiantes   []Estudiante
+	Categorias    []Categoria
+}
+
+// UnidadesProducto cuenta las unidades vendidas de un producto, incluidas las
+// vendidas como componente de combos
+type UnidadesProducto struct {
+	IdProducto     int
+	NombreProducto string
+	Directas       int
+	EnCombos       int
+}
+
+// Total retorna las unidades vendidas sumando ventas directas y en combos
+func (u UnidadesProducto) Total() int {
+	return u.Directas + u.EnCombos
+}
@@ -0,0 +1,19 @@
+package models
+
+// EstudiantePortal es un estudiante de la familia con su saldo al día
+type EstudiantePortal struct {
+	Estudiante
+	Saldo      float64 // Positivo = deuda, negativo = saldo a favor
+	CodigoPago string  // Referencia para transferencias y Yape
+}
+
+// DatosPortal contiene los datos del portal de apoderados (solo lectura)
+type DatosPortal struct {
+	NombreApoderado string
+	Estudiantes     []EstudiantePortal
+	Seleccionado    int
+	Semana          DatosConsumoSemanal // Misma nota de venta que ve el personal
+	SemanaAnterior  string              // AAAA-MM-DD para navegar
+	SemanaSiguiente string              // "" si la semana mostrada es la actual
+	Pagos           []Pago              // Historial reciente del estudiante seleccionado
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// CambioPrecio representa un precio de producto vigente desde una fecha
+type CambioPrecio struct {
+	IdPrecio       int
+	IdProducto     int
+	NombreProducto string
+	PrecioUnitario float64
+	PrecioAnterior float64 // Precio que reemplaza, para mostrar la variación
+	VigenteDesde   time.Time
+	Programado     bool // Aún no entra en vigencia
+}
+
+// DatosHistorialPrecios contiene los datos para la página de historial de precios
+type DatosHistorialPrecios struct {
+	Productos   []Producto
+	Cambios     []CambioPrecio // Más recientes primero
+	PuedeEditar bool
+}
@@ -2,8 +2,33 @@ package models
 
 // Producto representa un producto del kiosco
 type Producto struct {
-	IdProducto     int
-	Nombre         string
-	PrecioUnitario float64
-	EstaActivo     bool
+	IdProducto      int
+	Nombre          string
+	PrecioUnitario  float64
+	CostoUnitario   float64 // Último costo de compra, para el margen bruto
+	EstaActivo      bool
+	ControlaStock   bool // Si se descuenta stock al registrar consumos
+	StockActual     int
+	StockMinimo     int // Umbral para alerta de stock bajo
+	IdCategoria     int // 0 = sin categoría
+	NombreCategoria string
+	Orden           int  // Orden manual dentro de la categoría
+	EsCombo         bool // Tiene componentes (ver ComponenteCombo)
+}
+
+// Categoria agrupa productos en los formularios (menú, postres, bebidas...)
+type Categoria struct {
+	IdCategoria int
+	Nombre      string
+	Orden       int
+}
+
+// StockBajo indica si el producto lleva inventario y está en o bajo el mínimo
+func (p Producto) StockBajo() bool {
+	return p.ControlaStock && p.StockActual <= p.StockMinimo
+}
+
+// Agotado indica si el producto lleva inventario y no tiene existencias
+func (p Producto) Agotado() bool {
+	return p.ControlaStock && p.StockActual <= 0
 }
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Alcances de un token de servicio: equivalen a un usuario sin y con permiso de edición
+const (
+	AlcanceLectura = "lectura"
+	AlcanceEdicion = "edicion"
+)
+
+// TokenAPI es un token de larga duración para scripts e integraciones.
+// El valor en claro no se guarda; solo su hash y un prefijo para reconocerlo.
+type TokenAPI struct {
+	IdToken       int
+	Nombre        string
+	Prefijo       string
+	Alcance       string
+	NombreUsuario string // Quién lo creó
+	CreadoEn      time.Time
+	UltimoUso     *time.Time // nil si nunca se usó
+	ExpiraEn      *time.Time // nil si no expira; vale hasta ese día inclusive
+	RevocadoEn    *time.Time // nil si sigue vigente
+}
+
+// Revocado indica si el token fue revocado desde la administración
+func (t TokenAPI) Revocado() bool {
+	return t.RevocadoEn != nil
+}
+
+// Expirado indica si la fecha de expiración ya pasó respecto de hoy
+func (t TokenAPI) Expirado(hoy time.Time) bool {
+	return t.ExpiraEn != nil && hoy.After(*t.ExpiraEn)
+}
+
+// Vigente indica si el token puede usarse hoy
+func (t TokenAPI) Vigente(hoy time.Time) bool {
+	return !t.Revocado() && !t.Expirado(hoy)
+}
+
+// PuedeEditar indica si el alcance del token permite operaciones de edición
+func (t TokenAPI) PuedeEditar() bool {
+	return t.Alcance == AlcanceEdicion
+}
+
+// DatosTokensAPI contiene los datos para la administración de tokens de servicio
+type DatosTokensAPI struct {
+	Tokens      []TokenAPI
+	TokenNuevo  string // Valor en claro del token recién creado (se muestra una sola vez)
+	NombreNuevo string
+}
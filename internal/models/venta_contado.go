@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ItemVentaContado es una línea de una venta al contado
+type ItemVentaContado struct {
+	IdProducto     int
+	NombreProducto string // Para mostrar en la vista
+	Cantidad       int
+	PrecioUnitario float64 // Precio vigente al momento de la venta
+	TotalLinea     float64
+}
+
+// VentaContado es una venta pagada en efectivo en el momento, sin cuenta de
+// estudiante (docentes, visitantes)
+type VentaContado struct {
+	IdVenta   int
+	Fecha     time.Time
+	Total     float64
+	Recibido  float64 // Efectivo entregado por el cliente
+	IdUsuario int     // Quién cobró (0 = desconocido)
+	Anulada   bool
+	CreadoEn  time.Time
+	Items     []ItemVentaContado
+
+	IdSesionCaja int  // Caja donde se cobró (0 = sin caja abierta)
+	CajaCerrada  bool // Su caja ya se cerró: la venta quedó en el arqueo
+}
+
+// Vuelto retorna el cambio entregado al cliente
+func (v VentaContado) Vuelto() float64 {
+	return v.Recibido - v.Total
+}
+
+// DatosVentaContado contiene los datos de la pantalla de venta al contado
+type DatosVentaContado struct {
+	Fecha       time.Time
+	Productos   []Producto     // Disponibles hoy según el menú
+	Ventas      []VentaContado // Ventas del día, la más reciente primero
+	TotalDia    float64        // Sin las anuladas
+	VentasDia   int            // Sin las anuladas
+	UltimaVenta *VentaContado  // La recién cobrada, para mostrar el vuelto
+	PuedeAnular bool
+	Error       string
+	Cantidades  map[int]int // Lo enviado, para no perderlo si hubo error
+	Recibido    string
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Eventos que pueden notificarse por webhook
+const (
+	EventoConsumoCreado     = "consumo.creado"
+	EventoConsumoModificado = "consumo.modificado"
+	EventoPagoRegistrado    = "pago.registrado"
+	EventoPagoAnulado       = "pago.anulado"
+	EventoSemanaCerrada     = "semana.cerrada"
+	EventoPing              = "ping" // Solo para probar un webhook desde la administración
+)
+
+// EventosWebhook lista los eventos suscribibles, en el orden en que se muestran
+var EventosWebhook = []string{
+	EventoConsumoCreado,
+	EventoConsumoModificado,
+	EventoPagoRegistrado,
+	EventoPagoAnulado,
+	EventoSemanaCerrada,
+}
+
+// Estados de una entrega en la bandeja de salida
+const (
+	EntregaPendiente = "pendiente"
+	EntregaEntregada = "entregado"
+	EntregaFallida   = "fallido"
+)
+
+// Webhook es un destino HTTP que recibe eventos firmados con HMAC-SHA256
+type Webhook struct {
+	IdWebhook  int
+	URL        string
+	Secreto    string
+	Eventos    []string
+	EstaActivo bool
+	CreadoEn   time.Time
+}
+
+// Escucha indica si el webhook está suscrito al evento
+func (w Webhook) Escucha(evento string) bool {
+	for _, e := range w.Eventos {
+		if e == evento {
+			return true
+		}
+	}
+	return false
+}
+
+// EventosTexto retorna los eventos separados por comas, como se guardan en la base
+func (w Webhook) EventosTexto() string {
+	return strings.Join(w.Eventos, ",")
+}
+
+// EntregaWebhook es un evento en la bandeja de salida de un webhook
+type EntregaWebhook struct {
+	IdEntrega      int
+	IdWebhook      int
+	URL            string
+	Secreto        string
+	IdEvento       string
+	Evento         string
+	Payload        string
+	Estado         string
+	Intentos       int
+	ProximoIntento time.Time
+	UltimoStatus   int // 0 si no hubo respuesta HTTP
+	UltimoError    string
+	CreadoEn       time.Time
+	EntregadoEn    *time.Time
+}
+
+// DatosWebhooks contiene los datos para la página de webhooks y su registro de entregas
+type DatosWebhooks struct {
+	Webhooks []Webhook
+	Entregas []EntregaWebhook
+	Eventos  []string
+	Estado   string // Filtro del registro de entregas ("" = todas)
+}
@@ -0,0 +1,144 @@
+// Package notificador envía mensajes cortos (WhatsApp o SMS) a teléfonos.
+// Cada proveedor implementa Notificador; el incluido es una pasarela HTTP
+// genérica (ver Pasarela) que se configura al iniciar con
+// config.ObtenerConfiguracionMensajeria. Sin configuración el envío queda
+// deshabilitado y los mensajes esperan en la bandeja de salida.
+package notificador
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mensaje es un texto para un teléfono en formato internacional (+51...)
+type Mensaje struct {
+	Telefono   string
+	Texto      string
+	Referencia string // Identificador interno, para que el proveedor descarte duplicados
+}
+
+// Resultado es la respuesta del proveedor a un envío
+type Resultado struct {
+	Status    int    // Status HTTP (0 si el proveedor no es HTTP o no respondió)
+	IdExterno string // Id del mensaje en el proveedor, si lo informa
+}
+
+// Notificador es un canal de mensajería
+type Notificador interface {
+	Nombre() string
+	Enviar(ctx context.Context, m Mensaje) (Resultado, error)
+}
+
+var (
+	vigente    Notificador
+	codigoPais = "51"
+)
+
+// Configuracion es la mensajería leída del entorno al iniciar
+type Configuracion struct {
+	Pasarela   ConfiguracionPasarela // Sin URL la mensajería queda deshabilitada
+	PorMinuto  int                   // Límite de envíos por minuto (0 = sin límite)
+	CodigoPais string                // Para teléfonos sin código de país
+}
+
+// Configurar arma la pasarela HTTP y la deja como notificador vigente.
+// Debe llamarse al iniciar, antes de atender solicitudes.
+func Configurar(c Configuracion) error {
+	if c.CodigoPais != "" {
+		ConfigurarCodigoPais(c.CodigoPais)
+	}
+	if c.Pasarela.URL == "" {
+		Usar(nil, 0)
+		return nil
+	}
+	if c.PorMinuto < 0 {
+		return fmt.Errorf("límite por minuto inválido: %d", c.PorMinuto)
+	}
+	pasarela, err := NuevaPasarela(c.Pasarela)
+	if err != nil {
+		return err
+	}
+	Usar(pasarela, c.PorMinuto)
+	return nil
+}
+
+// Usar reemplaza el notificador vigente. porMinuto limita los envíos (0 = sin límite).
+// Debe llamarse al iniciar, antes de atender solicitudes.
+func Usar(n Notificador, porMinuto int) {
+	if n != nil && porMinuto > 0 {
+		n = &conLimite{Notificador: n, intervalo: time.Minute / time.Duration(porMinuto)}
+	}
+	vigente = n
+}
+
+// Vigente retorna el notificador en uso (nil = deshabilitado)
+func Vigente() Notificador {
+	return vigente
+}
+
+// Habilitado indica si hay un notificador configurado
+func Habilitado() bool {
+	return vigente != nil
+}
+
+// ConfigurarCodigoPais define el prefijo para teléfonos sin código de país
+func ConfigurarCodigoPais(codigo string) {
+	codigoPais = strings.TrimPrefix(codigo, "+")
+}
+
+// NormalizarTelefono deja solo dígitos con + y código de país. Un número sin
+// código de país (9 dígitos en Perú) recibe el configurado.
+func NormalizarTelefono(telefono string) (string, error) {
+	var digitos strings.Builder
+	for _, r := range strings.TrimSpace(telefono) {
+		switch {
+		case r >= '0' && r <= '9':
+			digitos.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.' || (r == '+' && digitos.Len() == 0):
+		default:
+			return "", fmt.Errorf("teléfono inválido: %q", telefono)
+		}
+	}
+	numero := digitos.String()
+	conCodigo := strings.HasPrefix(strings.TrimSpace(telefono), "+") || strings.HasPrefix(numero, "00")
+	numero = strings.TrimPrefix(numero, "00")
+	if !conCodigo && len(numero) <= 10 {
+		numero = codigoPais + strings.TrimPrefix(numero, "0")
+	}
+	if len(numero) < 8 || len(numero) > 15 {
+		return "", fmt.Errorf("teléfono inválido: %q", telefono)
+	}
+	return "+" + numero, nil
+}
+
+// conLimite espacia los envíos para no superar la tasa del proveedor
+type conLimite struct {
+	Notificador
+	intervalo time.Duration
+
+	mu        sync.Mutex
+	siguiente time.Time
+}
+
+func (l *conLimite) Enviar(ctx context.Context, m Mensaje) (Resultado, error) {
+	l.mu.Lock()
+	ahora := time.Now()
+	turno := l.siguiente
+	if turno.Before(ahora) {
+		turno = ahora
+	}
+	l.siguiente = turno.Add(l.intervalo)
+	l.mu.Unlock()
+
+	if espera := time.Until(turno); espera > 0 {
+		select {
+		case <-time.After(espera):
+		case <-ctx.Done():
+			return Resultado{}, ctx.Err()
+		}
+	}
+	return l.Notificador.Enviar(ctx, m)
+}
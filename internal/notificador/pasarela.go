@@ -0,0 +1,131 @@
+package notificador
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ConfiguracionPasarela describe cómo llamar a un proveedor HTTP. La URL y el
+// cuerpo son plantillas de text/template con .Telefono, .Texto y .Referencia;
+// la función json escribe un string JSON con comillas y urlquery lo escapa
+// para la URL. Así sirve para Twilio, la API de WhatsApp Cloud, pasarelas
+// locales de SMS o un servidor de prueba.
+type ConfiguracionPasarela struct {
+	URL           string
+	Metodo        string // POST por defecto
+	Plantilla     string // Cuerpo; vacío = sin cuerpo (ej. GET con parámetros en la URL)
+	TipoContenido string // application/json por defecto
+	Cabeceras     map[string]string
+	CampoId       string // Campo de la respuesta JSON con el id del mensaje ("" = no se lee)
+}
+
+// PlantillaPorDefecto es el cuerpo JSON que se envía si no se configura otro
+const PlantillaPorDefecto = `{"telefono": {{json .Telefono}}, "texto": {{json .Texto}}, "referencia": {{json .Referencia}}}`
+
+var clientePasarela = &http.Client{Timeout: 15 * time.Second}
+
+// Pasarela envía mensajes con una solicitud HTTP por mensaje
+type Pasarela struct {
+	config    ConfiguracionPasarela
+	url       *template.Template
+	plantilla *template.Template
+	host      string
+}
+
+var funcionesPlantilla = template.FuncMap{
+	"json": func(v string) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// NuevaPasarela valida la configuración y compila las plantillas
+func NuevaPasarela(c ConfiguracionPasarela) (*Pasarela, error) {
+	if c.Metodo == "" {
+		c.Metodo = http.MethodPost
+	}
+	c.Metodo = strings.ToUpper(c.Metodo)
+	if c.TipoContenido == "" {
+		c.TipoContenido = "application/json"
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("URL de pasarela inválida: %q", c.URL)
+	}
+	tURL, err := template.New("url").Funcs(funcionesPlantilla).Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("plantilla de URL inválida: %v", err)
+	}
+	tCuerpo, err := template.New("cuerpo").Funcs(funcionesPlantilla).Parse(c.Plantilla)
+	if err != nil {
+		return nil, fmt.Errorf("plantilla de cuerpo inválida: %v", err)
+	}
+	return &Pasarela{config: c, url: tURL, plantilla: tCuerpo, host: u.Host}, nil
+}
+
+// Nombre identifica al proveedor en el registro de mensajes
+func (p *Pasarela) Nombre() string {
+	return "pasarela " + p.host
+}
+
+// Enviar hace la solicitud. Cualquier respuesta 2xx cuenta como enviado.
+func (p *Pasarela) Enviar(ctx context.Context, m Mensaje) (Resultado, error) {
+	var direccion, cuerpo bytes.Buffer
+	if err := p.url.Execute(&direccion, m); err != nil {
+		return Resultado{}, fmt.Errorf("error al armar URL: %v", err)
+	}
+	if err := p.plantilla.Execute(&cuerpo, m); err != nil {
+		return Resultado{}, fmt.Errorf("error al armar cuerpo: %v", err)
+	}
+
+	var lector io.Reader
+	if cuerpo.Len() > 0 {
+		lector = &cuerpo
+	}
+	req, err := http.NewRequestWithContext(ctx, p.config.Metodo, direccion.String(), lector)
+	if err != nil {
+		return Resultado{}, err
+	}
+	if lector != nil {
+		req.Header.Set("Content-Type", p.config.TipoContenido)
+	}
+	req.Header.Set("User-Agent", "kiosco-mensajes/1")
+	for clave, valor := range p.config.Cabeceras {
+		req.Header.Set(clave, valor)
+	}
+
+	resp, err := clientePasarela.Do(req)
+	if err != nil {
+		return Resultado{}, err
+	}
+	defer resp.Body.Close()
+
+	respuesta, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resultado := Resultado{Status: resp.StatusCode}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detalle := strings.TrimSpace(string(respuesta))
+		if len(detalle) > 512 {
+			detalle = detalle[:512]
+		}
+		return resultado, fmt.Errorf("HTTP %d: %s", resp.StatusCode, detalle)
+	}
+
+	if p.config.CampoId != "" {
+		var datos map[string]any
+		if json.Unmarshal(respuesta, &datos) == nil {
+			if id, ok := datos[p.config.CampoId]; ok {
+				resultado.IdExterno = fmt.Sprint(id)
+			}
+		}
+	}
+	return resultado, nil
+}
@@ -0,0 +1,428 @@
+// Package qr genera códigos QR en Go puro para los carnés de los estudiantes.
+//
+// Solo cubre lo que necesitan los carnés: modo byte, nivel de corrección M
+// (15 %) y versiones 1 a 6 (hasta 106 bytes), sin dependencias externas. El
+// resultado se dibuja como SVG, que se imprime nítido a cualquier tamaño.
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDemasiadoLargo indica que el texto no cabe en la versión más grande soportada
+var ErrDemasiadoLargo = errors.New("texto demasiado largo para el código QR")
+
+// zonaSilenciosa es el margen en módulos que exige la norma alrededor del símbolo
+const zonaSilenciosa = 4
+
+// version describe la capacidad de una versión con corrección M
+type version struct {
+	bloques     int // bloques de Reed-Solomon (todos del mismo tamaño hasta la 6)
+	datosBloque int // palabras de datos por bloque
+	ecBloque    int // palabras de corrección por bloque
+	alineacion  int // posición del patrón de alineación (0 = sin patrón)
+}
+
+var versiones = []version{
+	1: {1, 16, 10, 0},
+	2: {1, 28, 16, 18},
+	3: {1, 44, 26, 22},
+	4: {2, 32, 18, 26},
+	5: {2, 43, 24, 30},
+	6: {4, 27, 16, 34},
+}
+
+// Codigo es un símbolo QR listo para dibujar
+type Codigo struct {
+	Tamano   int // módulos por lado, sin la zona silenciosa
+	modulos  [][]bool
+	funcion  [][]bool // módulos fijos (buscadores, sincronía, formato): no llevan datos ni máscara
+	version  int
+	palabras []byte
+}
+
+// Codificar genera el QR más pequeño que contiene el texto
+func Codificar(texto string) (*Codigo, error) {
+	datos := []byte(texto)
+	for v := 1; v < len(versiones); v++ {
+		capacidad := versiones[v].bloques * versiones[v].datosBloque
+		// Modo (4 bits) + longitud (8 bits) + datos
+		if 12+len(datos)*8 <= capacidad*8 {
+			c := nuevoCodigo(v)
+			c.palabras = c.agregarCorreccion(codificarDatos(datos, capacidad))
+			c.dibujarDatos()
+			c.elegirMascara()
+			return c, nil
+		}
+	}
+	return nil, ErrDemasiadoLargo
+}
+
+// Oscuro indica si el módulo de la columna x y la fila y es oscuro
+func (c *Codigo) Oscuro(x, y int) bool {
+	return c.modulos[y][x]
+}
+
+// SVG dibuja el código con su zona silenciosa como un único path, un módulo por
+// unidad del viewBox: el tamaño final lo fija el CSS de quien lo muestra
+func (c *Codigo) SVG() string {
+	lado := c.Tamano + 2*zonaSilenciosa
+	var d strings.Builder
+	for y := 0; y < c.Tamano; y++ {
+		for x := 0; x < c.Tamano; x++ {
+			if c.modulos[y][x] {
+				fmt.Fprintf(&d, "M%d %dh1v1h-1z", x+zonaSilenciosa, y+zonaSilenciosa)
+			}
+		}
+	}
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`, lado, lado, d.String())
+}
+
+// ---------------------------------------------------------------------------
+// Datos y corrección de errores
+// ---------------------------------------------------------------------------
+
+// codificarDatos arma el flujo de bits en modo byte y lo rellena hasta la capacidad
+func codificarDatos(datos []byte, capacidad int) []byte {
+	var bits []bool
+	agregar := func(valor, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (valor>>i)&1 == 1)
+		}
+	}
+	agregar(0b0100, 4) // modo byte
+	agregar(len(datos), 8)
+	for _, b := range datos {
+		agregar(int(b), 8)
+	}
+	// Terminador de hasta 4 ceros y relleno hasta el byte
+	agregar(0, min(4, capacidad*8-len(bits)))
+	agregar(0, (8-len(bits)%8)%8)
+
+	palabras := make([]byte, 0, capacidad)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		palabras = append(palabras, b)
+	}
+	for relleno := byte(0xEC); len(palabras) < capacidad; relleno ^= 0xEC ^ 0x11 {
+		palabras = append(palabras, relleno)
+	}
+	return palabras
+}
+
+// agregarCorreccion divide los datos en bloques, calcula la corrección
+// Reed-Solomon de cada uno y los intercala como exige la norma
+func (c *Codigo) agregarCorreccion(datos []byte) []byte {
+	v := versiones[c.version]
+	divisor := divisorReedSolomon(v.ecBloque)
+	var bloques, correcciones [][]byte
+	for i := 0; i < v.bloques; i++ {
+		bloque := datos[i*v.datosBloque : (i+1)*v.datosBloque]
+		bloques = append(bloques, bloque)
+		correcciones = append(correcciones, restoReedSolomon(bloque, divisor))
+	}
+
+	var resultado []byte
+	for i := 0; i < v.datosBloque; i++ {
+		for _, b := range bloques {
+			resultado = append(resultado, b[i])
+		}
+	}
+	for i := 0; i < v.ecBloque; i++ {
+		for _, e := range correcciones {
+			resultado = append(resultado, e[i])
+		}
+	}
+	return resultado
+}
+
+// multiplicarGF multiplica en GF(2^8) con el polinomio 0x11D de la norma
+func multiplicarGF(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// divisorReedSolomon retorna los coeficientes del polinomio generador de grado n
+// (sin el coeficiente principal, que es 1)
+func divisorReedSolomon(n int) []byte {
+	resultado := make([]byte, n)
+	resultado[n-1] = 1
+	raiz := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range resultado {
+			resultado[j] = multiplicarGF(resultado[j], raiz)
+			if j+1 < n {
+				resultado[j] ^= resultado[j+1]
+			}
+		}
+		raiz = multiplicarGF(raiz, 0x02)
+	}
+	return resultado
+}
+
+func restoReedSolomon(datos, divisor []byte) []byte {
+	resto := make([]byte, len(divisor))
+	for _, b := range datos {
+		factor := b ^ resto[0]
+		copy(resto, resto[1:])
+		resto[len(resto)-1] = 0
+		for i, coef := range divisor {
+			resto[i] ^= multiplicarGF(coef, factor)
+		}
+	}
+	return resto
+}
+
+// ---------------------------------------------------------------------------
+// Dibujo de la matriz
+// ---------------------------------------------------------------------------
+
+func nuevoCodigo(v int) *Codigo {
+	tamano := 17 + 4*v
+	c := &Codigo{Tamano: tamano, version: v}
+	c.modulos = make([][]bool, tamano)
+	c.funcion = make([][]bool, tamano)
+	for i := range c.modulos {
+		c.modulos[i] = make([]bool, tamano)
+		c.funcion[i] = make([]bool, tamano)
+	}
+
+	// Patrones de sincronía (los buscadores los tapan en las esquinas)
+	for i := 0; i < tamano; i++ {
+		c.fijar(6, i, i%2 == 0)
+		c.fijar(i, 6, i%2 == 0)
+	}
+	c.dibujarBuscador(3, 3)
+	c.dibujarBuscador(tamano-4, 3)
+	c.dibujarBuscador(3, tamano-4)
+	if p := versiones[v].alineacion; p > 0 {
+		// Hasta la versión 6 hay un solo patrón de alineación: los demás
+		// coincidirían con los buscadores
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				c.fijar(p+dx, p+dy, max(abs(dx), abs(dy)) != 1)
+			}
+		}
+	}
+	// Reserva las zonas del formato; se completan al elegir la máscara
+	c.dibujarFormato(0)
+	return c
+}
+
+// fijar pone un módulo fijo, que no lleva datos ni se enmascara
+func (c *Codigo) fijar(x, y int, oscuro bool) {
+	c.modulos[y][x] = oscuro
+	c.funcion[y][x] = true
+}
+
+// dibujarBuscador dibuja un patrón de búsqueda de 7×7 con su separador claro
+func (c *Codigo) dibujarBuscador(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= c.Tamano || y >= c.Tamano {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			c.fijar(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+// dibujarFormato escribe el nivel de corrección (M) y la máscara, con su código
+// BCH, en las dos copias que lleva el símbolo
+func (c *Codigo) dibujarFormato(mascara int) {
+	datos := mascara // nivel M = 00
+	resto := datos
+	for i := 0; i < 10; i++ {
+		resto = (resto << 1) ^ ((resto >> 9) * 0x537)
+	}
+	bits := (datos<<10 | resto) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	// Primera copia, junto al buscador superior izquierdo
+	for i := 0; i <= 5; i++ {
+		c.fijar(8, i, bit(i))
+	}
+	c.fijar(8, 7, bit(6))
+	c.fijar(8, 8, bit(7))
+	c.fijar(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.fijar(14-i, 8, bit(i))
+	}
+
+	// Segunda copia, repartida entre los otros dos buscadores
+	for i := 0; i < 8; i++ {
+		c.fijar(c.Tamano-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.fijar(8, c.Tamano-15+i, bit(i))
+	}
+	c.fijar(8, c.Tamano-8, true) // módulo oscuro fijo
+}
+
+// dibujarDatos recorre la matriz en zigzag de a dos columnas, de abajo a la
+// derecha hacia arriba, llenando los módulos libres con las palabras
+func (c *Codigo) dibujarDatos() {
+	i := 0
+	total := len(c.palabras) * 8
+	for derecha := c.Tamano - 1; derecha >= 1; derecha -= 2 {
+		if derecha == 6 {
+			derecha = 5 // la columna de sincronía no lleva datos
+		}
+		subiendo := (derecha+1)&2 == 0
+		for vert := 0; vert < c.Tamano; vert++ {
+			y := vert
+			if subiendo {
+				y = c.Tamano - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := derecha - j
+				if c.funcion[y][x] {
+					continue
+				}
+				// Los bits que sobran al final (resto) quedan claros
+				if i < total {
+					c.modulos[y][x] = (c.palabras[i>>3]>>(7-i&7))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// aplicarMascara invierte los módulos de datos según el patrón de la máscara;
+// aplicarla dos veces la deshace
+func (c *Codigo) aplicarMascara(mascara int) {
+	for y := 0; y < c.Tamano; y++ {
+		for x := 0; x < c.Tamano; x++ {
+			if c.funcion[y][x] {
+				continue
+			}
+			var invertir bool
+			switch mascara {
+			case 0:
+				invertir = (x+y)%2 == 0
+			case 1:
+				invertir = y%2 == 0
+			case 2:
+				invertir = x%3 == 0
+			case 3:
+				invertir = (x+y)%3 == 0
+			case 4:
+				invertir = (x/3+y/2)%2 == 0
+			case 5:
+				invertir = x*y%2+x*y%3 == 0
+			case 6:
+				invertir = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invertir = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invertir {
+				c.modulos[y][x] = !c.modulos[y][x]
+			}
+		}
+	}
+}
+
+// elegirMascara prueba las ocho máscaras y deja la de menor penalización, que
+// es la que mejor leen los lectores
+func (c *Codigo) elegirMascara() {
+	mejor, menor := 0, -1
+	for m := 0; m < 8; m++ {
+		c.aplicarMascara(m)
+		c.dibujarFormato(m)
+		if p := c.penalizacion(); menor < 0 || p < menor {
+			mejor, menor = m, p
+		}
+		c.aplicarMascara(m)
+	}
+	c.aplicarMascara(mejor)
+	c.dibujarFormato(mejor)
+}
+
+// penalizacion aplica las cuatro reglas de la norma: tramos largos del mismo
+// color, bloques de 2×2, patrones parecidos a los buscadores y desbalance entre
+// módulos claros y oscuros
+func (c *Codigo) penalizacion() int {
+	n := c.Tamano
+	puntos := 0
+	modulo := func(x, y int, vertical bool) bool {
+		if vertical {
+			return c.modulos[x][y]
+		}
+		return c.modulos[y][x]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			tramo := 0
+			var patron int
+			for x := 0; x < n; x++ {
+				if x > 0 && modulo(x, y, vertical) == modulo(x-1, y, vertical) {
+					tramo++
+				} else {
+					tramo = 1
+				}
+				if tramo == 5 {
+					puntos += 3
+				} else if tramo > 5 {
+					puntos++
+				}
+
+				// 1:1:3:1:1 con cuatro claros a un lado (fuera del símbolo cuenta como claro)
+				patron = (patron << 1) & 0x7FF
+				if modulo(x, y, vertical) {
+					patron |= 1
+				}
+				if x >= 10 && (patron == 0b10111010000 || patron == 0b00001011101) {
+					puntos += 40
+				}
+			}
+			for extra := 0; extra < 4; extra++ {
+				patron = (patron << 1) & 0x7FF
+				if patron == 0b10111010000 {
+					puntos += 40
+				}
+			}
+		}
+	}
+
+	oscuros := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if c.modulos[y][x] {
+				oscuros++
+			}
+			if x+1 < n && y+1 < n {
+				m := c.modulos[y][x]
+				if m == c.modulos[y][x+1] && m == c.modulos[y+1][x] && m == c.modulos[y+1][x+1] {
+					puntos += 3
+				}
+			}
+		}
+	}
+	// 10 puntos por cada 5 % de desvío respecto a la mitad
+	desvio := abs(oscuros*20-n*n*10) / (n * n)
+	puntos += desvio * 10
+	return puntos
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
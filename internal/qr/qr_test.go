@@ -0,0 +1,68 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Palabras de datos y de corrección de "HELLO WORLD" en 1-M del tutorial de
+// thonky.com (https://www.thonky.com/qr-code-tutorial/error-correction-coding)
+func TestRestoReedSolomon(t *testing.T) {
+	datos := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	esperado := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	resto := restoReedSolomon(datos, divisorReedSolomon(versiones[1].ecBloque))
+	if !bytes.Equal(resto, esperado) {
+		t.Errorf("resto = %v, se esperaba %v", resto, esperado)
+	}
+}
+
+// Matriz de "hello world" (modo byte, 1-M) generada con
+// github.com/skip2/go-qrcode, sin zona silenciosa
+func TestCodificarVersion1(t *testing.T) {
+	esperado := []string{
+		"#######..#.##.#######",
+		"#.....#...#...#.....#",
+		"#.###.#.####..#.###.#",
+		"#.###.#.###.#.#.###.#",
+		"#.###.#.#.#.#.#.###.#",
+		"#.....#.#..#..#.....#",
+		"#######.#.#.#.#######",
+		"........#.#..........",
+		"#.#####..#.#..#####..",
+		".##.##.#.#.########.#",
+		"#.#.####.##.###..###.",
+		"#.#..#...#.###..###..",
+		"...#.#####..###.....#",
+		"........#.#.#...##..#",
+		"#######....#..#...##.",
+		"#.....#.#....#.#.####",
+		"#.###.#.#..#..##....#",
+		"#.###.#.##..######...",
+		"#.###.#.##..#..#..#..",
+		"#.....#..##.##..###..",
+		"#######.##.##.#.#..#.",
+	}
+
+	c, err := Codificar("hello world")
+	if err != nil {
+		t.Fatalf("Codificar: %v", err)
+	}
+	if c.Tamano != len(esperado) {
+		t.Fatalf("Tamano = %d, se esperaba %d", c.Tamano, len(esperado))
+	}
+	for y, fila := range esperado {
+		var obtenida strings.Builder
+		for x := 0; x < c.Tamano; x++ {
+			if c.Oscuro(x, y) {
+				obtenida.WriteByte('#')
+			} else {
+				obtenida.WriteByte('.')
+			}
+		}
+		if obtenida.String() != fila {
+			t.Errorf("fila %d = %s, se esperaba %s", y, obtenida.String(), fila)
+		}
+	}
+}
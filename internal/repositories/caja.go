@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"database/sql"
+	"kiosco/internal/models"
+	"time"
+)
+
+// sesionCajaAbiertaSQL es la caja abierta del usuario del parámetro indicado
+// (ej. "?4"), o NULL si no tiene una
+func sesionCajaAbiertaSQL(param string) string {
+	return `(SELECT id_sesion FROM sesiones_caja WHERE id_usuario = ` + param + ` AND cerrada_en IS NULL)`
+}
+
+// Mientras la caja está abierta los totales se calculan; al cerrar quedan guardados
+const selectSesionesCaja = `
+	SELECT s.id_sesion, s.id_usuario, u.usuario, s.monto_inicial, s.abierta_en, s.cerrada_en,
+	       COALESCE(s.total_pagos, (SELECT COALESCE(SUM(monto), 0) FROM pagos WHERE id_sesion_caja = s.id_sesion)),
+	       COALESCE(s.total_ventas, (SELECT COALESCE(SUM(total), 0) FROM ventas_contado WHERE id_sesion_caja = s.id_sesion AND anulada = 0)),
+	       COALESCE(s.monto_contado, 0), s.notas
+	FROM sesiones_caja s
+	JOIN usuarios u ON s.id_usuario = u.id_usuario`
+
+func escanearSesionesCaja(rows *sql.Rows) ([]models.SesionCaja, error) {
+	defer rows.Close()
+
+	var sesiones []models.SesionCaja
+	for rows.Next() {
+		var s models.SesionCaja
+		var cerrada sql.NullTime
+		if err := rows.Scan(&s.IdSesion, &s.IdUsuario, &s.Usuario, &s.MontoInicial, &s.AbiertaEn, &cerrada,
+			&s.TotalPagos, &s.TotalVentas, &s.MontoContado, &s.Notas); err != nil {
+			return nil, err
+		}
+		if cerrada.Valid {
+			s.CerradaEn = &cerrada.Time
+		}
+		sesiones = append(sesiones, s)
+	}
+	return sesiones, rows.Err()
+}
+
+func (r *Repositorio) obtenerSesionCaja(filtro string, args ...any) (models.SesionCaja, error) {
+	rows, err := r.db.Query(selectSesionesCaja+` WHERE `+filtro+` ORDER BY s.id_sesion DESC LIMIT 1`, args...)
+	if err != nil {
+		return models.SesionCaja{}, err
+	}
+	sesiones, err := escanearSesionesCaja(rows)
+	if err != nil {
+		return models.SesionCaja{}, err
+	}
+	if len(sesiones) == 0 {
+		return models.SesionCaja{}, sql.ErrNoRows
+	}
+	return sesiones[0], nil
+}
+
+// AbrirSesionCaja abre una caja para el usuario con su fondo inicial. Falla si
+// ya tiene una abierta (índice único).
+func (r *Repositorio) AbrirSesionCaja(idUsuario int, montoInicial float64) (int, error) {
+	res, err := r.db.Exec(`INSERT INTO sesiones_caja (id_usuario, monto_inicial) VALUES (?, ?)`, idUsuario, montoInicial)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// ObtenerSesionCajaAbierta retorna la caja abierta del usuario o sql.ErrNoRows
+func (r *Repositorio) ObtenerSesionCajaAbierta(idUsuario int) (models.SesionCaja, error) {
+	return r.obtenerSesionCaja(`s.id_usuario = ? AND s.cerrada_en IS NULL`, idUsuario)
+}
+
+// ObtenerUltimaSesionCajaCerrada retorna la última caja que cerró el usuario o sql.ErrNoRows
+func (r *Repositorio) ObtenerUltimaSesionCajaCerrada(idUsuario int) (models.SesionCaja, error) {
+	return r.obtenerSesionCaja(`s.id_usuario = ? AND s.cerrada_en IS NOT NULL`, idUsuario)
+}
+
+// ObtenerSesionCaja retorna una sesión de caja por su ID o sql.ErrNoRows
+func (r *Repositorio) ObtenerSesionCaja(idSesion int) (models.SesionCaja, error) {
+	return r.obtenerSesionCaja(`s.id_sesion = ?`, idSesion)
+}
+
+// CerrarSesionCaja cierra la caja abierta con el arqueo y congela sus totales.
+// Retorna false si la sesión no es del usuario o ya estaba cerrada.
+func (r *Repositorio) CerrarSesionCaja(idSesion, idUsuario int, montoContado float64, notas string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE sesiones_caja SET
+			cerrada_en = CURRENT_TIMESTAMP,
+			total_pagos = (SELECT COALESCE(SUM(monto), 0) FROM pagos WHERE id_sesion_caja = ?1),
+			total_ventas = (SELECT COALESCE(SUM(total), 0) FROM ventas_contado WHERE id_sesion_caja = ?1 AND anulada = 0),
+			monto_contado = ?3,
+			notas = ?4
+		WHERE id_sesion = ?1 AND id_usuario = ?2 AND cerrada_en IS NULL
+	`, idSesion, idUsuario, montoContado, notas)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ObtenerSesionesCaja retorna las sesiones abiertas en un rango de instantes,
+// la más reciente primero
+func (r *Repositorio) ObtenerSesionesCaja(desde, hasta time.Time) ([]models.SesionCaja, error) {
+	rows, err := r.db.Query(selectSesionesCaja+`
+		WHERE s.abierta_en >= ? AND s.abierta_en < ?
+		ORDER BY s.abierta_en DESC, s.id_sesion DESC
+	`, desde.UTC().Format("2006-01-02 15:04:05"), hasta.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	return escanearSesionesCaja(rows)
+}
+
+// ObtenerPagosSesionCaja retorna los pagos en efectivo cobrados en una sesión de caja
+func (r *Repositorio) ObtenerPagosSesionCaja(idSesion int) ([]models.PagoCaja, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id_pago, p.id_estudiante, e.apellidos || ', ' || e.nombres, p.monto, p.fecha_pago
+		FROM pagos p
+		JOIN estudiantes e ON p.id_estudiante = e.id_estudiante
+		WHERE p.id_sesion_caja = ?
+		ORDER BY p.id_pago
+	`, idSesion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pagos []models.PagoCaja
+	for rows.Next() {
+		var p models.PagoCaja
+		if err := rows.Scan(&p.IdPago, &p.IdEstudiante, &p.NombreEstudiante, &p.Monto, &p.FechaPago); err != nil {
+			return nil, err
+		}
+		pagos = append(pagos, p)
+	}
+	return pagos, rows.Err()
+}
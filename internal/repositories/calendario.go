@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"kiosco/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ObtenerDiasLaborables retorna los días de la semana con atención
+func (r *Repositorio) ObtenerDiasLaborables() (map[time.Weekday]bool, error) {
+	var valor string
+	err := r.db.QueryRow(`
+		SELECT valor FROM configuracion WHERE clave = 'dias_laborables'
+	`).Scan(&valor)
+	if err != nil {
+		return nil, err
+	}
+
+	dias := make(map[time.Weekday]bool)
+	for _, d := range strings.Split(valor, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(d))
+		if err != nil {
+			continue
+		}
+		dias[time.Weekday(n)] = true
+	}
+	return dias, nil
+}
+
+// GuardarDiasLaborables reemplaza los días de la semana con atención
+func (r *Repositorio) GuardarDiasLaborables(dias []time.Weekday) error {
+	valores := make([]string, len(dias))
+	for i, d := range dias {
+		valores[i] = strconv.Itoa(int(d))
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO configuracion (clave, valor) VALUES ('dias_laborables', ?)
+		ON CONFLICT (clave) DO UPDATE SET valor = excluded.valor
+	`, strings.Join(valores, ","))
+	return err
+}
+
+// ObtenerDiasNoLaborables retorna los cierres que se superponen con el rango indicado
+func (r *Repositorio) ObtenerDiasNoLaborables(desde, hasta time.Time) ([]models.DiaNoLaborable, error) {
+	rows, err := r.db.Query(`
+		SELECT id_dia, fecha_desde, fecha_hasta, tipo, descripcion
+		FROM dias_no_laborables
+		WHERE fecha_desde <= ? AND fecha_hasta >= ?
+		ORDER BY fecha_desde
+	`, hasta.Format("2006-01-02"), desde.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dias []models.DiaNoLaborable
+	for rows.Next() {
+		var d models.DiaNoLaborable
+		if err := rows.Scan(&d.IdDia, &d.FechaDesde, &d.FechaHasta, &d.Tipo, &d.Descripcion); err != nil {
+			return nil, err
+		}
+		dias = append(dias, d)
+	}
+	return dias, rows.Err()
+}
+
+// InsertarDiaNoLaborable registra un feriado o un rango de cierre
+func (r *Repositorio) InsertarDiaNoLaborable(dia models.DiaNoLaborable) error {
+	_, err := r.db.Exec(`
+		INSERT INTO dias_no_laborables (fecha_desde, fecha_hasta, tipo, descripcion)
+		VALUES (?, ?, ?, ?)
+	`, dia.FechaDesde.Format("2006-01-02"), dia.FechaHasta.Format("2006-01-02"), dia.Tipo, dia.Descripcion)
+	return err
+}
+
+// EliminarDiaNoLaborable quita un cierre del calendario
+func (r *Repositorio) EliminarDiaNoLaborable(idDia int) error {
+	_, err := r.db.Exec(`DELETE FROM dias_no_laborables WHERE id_dia = ?`, idDia)
+	return err
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"kiosco/internal/models"
+	"time"
+)
+
+// ObtenerComponentesCombos retorna los componentes de todos los combos agrupados por combo
+func (r *Repositorio) ObtenerComponentesCombos() (map[int][]models.ComponenteCombo, error) {
+	rows, err := r.db.Query(`
+		SELECT cp.id_combo, cp.id_componente, p.nombre, cp.cantidad, p.costo_unitario
+		FROM componentes_producto cp
+		JOIN productos p ON cp.id_componente = p.id_producto
+		ORDER BY cp.id_combo, p.nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	componentes := make(map[int][]models.ComponenteCombo)
+	for rows.Next() {
+		var c models.ComponenteCombo
+		if err := rows.Scan(&c.IdCombo, &c.IdComponente, &c.NombreComponente, &c.Cantidad, &c.CostoUnitario); err != nil {
+			return nil, err
+		}
+		componentes[c.IdCombo] = append(componentes[c.IdCombo], c)
+	}
+	return componentes, rows.Err()
+}
+
+// GuardarComponenteCombo agrega un componente a un combo o actualiza su cantidad
+func (r *Repositorio) GuardarComponenteCombo(idCombo, idComponente, cantidad int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO componentes_producto (id_combo, id_componente, cantidad)
+		VALUES (?, ?, ?)
+		ON CONFLICT (id_combo, id_componente) DO UPDATE SET cantidad = excluded.cantidad
+	`, idCombo, idComponente, cantidad)
+	return err
+}
+
+// QuitarComponenteCombo elimina un componente de un combo
+func (r *Repositorio) QuitarComponenteCombo(idCombo, idComponente int) error {
+	_, err := r.db.Exec(`
+		DELETE FROM componentes_producto WHERE id_combo = ? AND id_componente = ?
+	`, idCombo, idComponente)
+	return err
+}
+
+// EsComponente indica si un producto forma parte de algún combo
+func (r *Repositorio) EsComponente(idProducto int) (bool, error) {
+	var es bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM componentes_producto WHERE id_componente = ?)
+	`, idProducto).Scan(&es)
+	return es, err
+}
+
+// ObtenerUnidadesPorProducto cuenta las unidades vendidas de cada producto en un
+// rango de fechas, separando las vendidas solas de las vendidas dentro de combos
+func (r *Repositorio) ObtenerUnidadesPorProducto(desde, hasta time.Time) ([]models.UnidadesProducto, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id_producto, p.nombre, SUM(u.directas), SUM(u.en_combos)
+		FROM (
+			SELECT c.id_producto, c.cantidad AS directas, 0 AS en_combos
+			FROM consumos c
+			WHERE c.fecha_consumo BETWEEN ?1 AND ?2
+			  AND NOT EXISTS (SELECT 1 FROM componentes_producto WHERE id_combo = c.id_producto)
+			UNION ALL
+			SELECT cp.id_componente, 0, c.cantidad * cp.cantidad
+			FROM consumos c
+			JOIN componentes_producto cp ON cp.id_combo = c.id_producto
+			WHERE c.fecha_consumo BETWEEN ?1 AND ?2
+		) u
+		JOIN productos p ON u.id_producto = p.id_producto
+		GROUP BY p.id_producto
+		ORDER BY SUM(u.directas) + SUM(u.en_combos) DESC
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var unidades []models.UnidadesProducto
+	for rows.Next() {
+		var u models.UnidadesProducto
+		if err := rows.Scan(&u.IdProducto, &u.NombreProducto, &u.Directas, &u.EnCombos); err != nil {
+			return nil, err
+		}
+		unidades = append(unidades, u)
+	}
+	return unidades, rows.Err()
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// ObtenerProveedoresActivos retorna los proveedores activos ordenados por nombre
+func (r *Repositorio) ObtenerProveedoresActivos() ([]models.Proveedor, error) {
+	rows, err := r.db.Query(`
+		SELECT id_proveedor, nombre, telefono, esta_activo
+		FROM proveedores
+		WHERE esta_activo = 1
+		ORDER BY nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var proveedores []models.Proveedor
+	for rows.Next() {
+		var p models.Proveedor
+		if err := rows.Scan(&p.IdProveedor, &p.Nombre, &p.Telefono, &p.EstaActivo); err != nil {
+			return nil, err
+		}
+		proveedores = append(proveedores, p)
+	}
+	return proveedores, rows.Err()
+}
+
+// InsertarProveedor agrega un nuevo proveedor activo
+func (r *Repositorio) InsertarProveedor(nombre, telefono string) (models.Proveedor, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO proveedores (nombre, telefono, esta_activo)
+		VALUES (?, ?, 1)
+	`, nombre, telefono)
+	if err != nil {
+		return models.Proveedor{}, err
+	}
+	id, _ := result.LastInsertId()
+	return models.Proveedor{
+		IdProveedor: int(id),
+		Nombre:      nombre,
+		Telefono:    telefono,
+		EstaActivo:  true,
+	}, nil
+}
+
+// RegistrarCompra inserta una compra con sus ítems en una transacción atómica.
+// Actualiza el costo unitario de cada producto y suma la entrada al stock
+// de los productos que llevan inventario.
+func (r *Repositorio) RegistrarCompra(compra models.Compra) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO compras (id_proveedor, fecha_compra, nota)
+		VALUES (?, ?, ?)
+	`, compra.IdProveedor, compra.FechaCompra.Format("2006-01-02"), compra.Nota)
+	if err != nil {
+		return 0, err
+	}
+	idCompra, _ := result.LastInsertId()
+	nota := fmt.Sprintf("Compra #%d", idCompra)
+
+	for _, item := range compra.Items {
+		if _, err := tx.Exec(`
+			INSERT INTO compra_items (id_compra, id_producto, cantidad, costo_unitario)
+			VALUES (?, ?, ?, ?)
+		`, idCompra, item.IdProducto, item.Cantidad, item.CostoUnitario); err != nil {
+			return 0, err
+		}
+
+		var controlaStock bool
+		if err := tx.QueryRow(`
+			UPDATE productos SET costo_unitario = ? WHERE id_producto = ?
+			RETURNING controla_stock
+		`, item.CostoUnitario, item.IdProducto).Scan(&controlaStock); err != nil {
+			return 0, fmt.Errorf("producto %d: %v", item.IdProducto, err)
+		}
+
+		if controlaStock {
+			if err := registrarMovimientoTx(tx, item.IdProducto, models.MovimientoEntrada, item.Cantidad, compra.FechaCompra, nota); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return int(idCompra), tx.Commit()
+}
+
+// ObtenerComprasRecientes retorna las últimas compras con sus ítems
+func (r *Repositorio) ObtenerComprasRecientes(limite int) ([]models.Compra, error) {
+	rows, err := r.db.Query(`
+		SELECT c.id_compra, c.id_proveedor, pr.nombre, c.fecha_compra, c.nota,
+		       i.id_producto, p.nombre, i.cantidad, i.costo_unitario, i.total_linea
+		FROM (SELECT * FROM compras ORDER BY fecha_compra DESC, id_compra DESC LIMIT ?) c
+		JOIN proveedores pr ON c.id_proveedor = pr.id_proveedor
+		JOIN compra_items i ON i.id_compra = c.id_compra
+		JOIN productos p ON i.id_producto = p.id_producto
+		ORDER BY c.fecha_compra DESC, c.id_compra DESC, i.id_item
+	`, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Agrupación en memoria: slice para preservar orden de inserción
+	var compras []models.Compra
+	for rows.Next() {
+		var c models.Compra
+		var item models.ItemCompra
+		if err := rows.Scan(&c.IdCompra, &c.IdProveedor, &c.NombreProveedor, &c.FechaCompra, &c.Nota,
+			&item.IdProducto, &item.NombreProducto, &item.Cantidad, &item.CostoUnitario, &item.TotalLinea); err != nil {
+			return nil, err
+		}
+
+		if n := len(compras); n == 0 || compras[n-1].IdCompra != c.IdCompra {
+			compras = append(compras, c)
+		}
+		ultima := &compras[len(compras)-1]
+		ultima.Items = append(ultima.Items, item)
+		ultima.Total += item.TotalLinea
+	}
+	return compras, rows.Err()
+}
+
+// ObtenerTotalCompras retorna el monto comprado a proveedores en un rango de fechas
+func (r *Repositorio) ObtenerTotalCompras(desde, hasta time.Time) (float64, error) {
+	var total sql.NullFloat64
+	err := r.db.QueryRow(`
+		SELECT SUM(i.total_linea)
+		FROM compras c
+		JOIN compra_items i ON i.id_compra = c.id_compra
+		WHERE c.fecha_compra BETWEEN ? AND ?
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02")).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total.Float64, nil
+}
@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"database/sql"
+	"kiosco/internal/models"
+)
+
+// InsertarExtracto guarda un extracto con sus abonos. Los abonos cuya huella ya
+// estaba registrada (extractos que se solapan) se omiten y se cuentan como repetidos.
+func (r *Repositorio) InsertarExtracto(extracto models.Extracto, movimientos []models.MovimientoExtracto) (models.Extracto, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return extracto, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO extractos (nombre_archivo, origen) VALUES (?, ?)`, extracto.NombreArchivo, extracto.Origen)
+	if err != nil {
+		return extracto, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return extracto, err
+	}
+	extracto.IdExtracto = int(id)
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO movimientos_extracto (id_extracto, fecha, monto, descripcion, ordenante, huella, id_estudiante, criterio)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (huella) DO NOTHING
+	`)
+	if err != nil {
+		return extracto, err
+	}
+	defer stmt.Close()
+
+	for _, m := range movimientos {
+		res, err := stmt.Exec(extracto.IdExtracto, m.Fecha.Format("2006-01-02"), m.Monto, m.Descripcion, m.Ordenante,
+			m.Huella, nuloSiCero(m.IdEstudiante), nuloSiVacio(m.Criterio))
+		if err != nil {
+			return extracto, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			extracto.Lineas++
+		} else {
+			extracto.Repetidas++
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE extractos SET lineas = ?, repetidas = ? WHERE id_extracto = ?`,
+		extracto.Lineas, extracto.Repetidas, extracto.IdExtracto); err != nil {
+		return extracto, err
+	}
+	return extracto, tx.Commit()
+}
+
+// ObtenerExtractos retorna los últimos extractos importados
+func (r *Repositorio) ObtenerExtractos(limite int) ([]models.Extracto, error) {
+	rows, err := r.db.Query(`
+		SELECT id_extracto, nombre_archivo, origen, lineas, repetidas, cargado_en
+		FROM extractos
+		ORDER BY id_extracto DESC
+		LIMIT ?
+	`, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var extractos []models.Extracto
+	for rows.Next() {
+		var e models.Extracto
+		if err := rows.Scan(&e.IdExtracto, &e.NombreArchivo, &e.Origen, &e.Lineas, &e.Repetidas, &e.CargadoEn); err != nil {
+			return nil, err
+		}
+		extractos = append(extractos, e)
+	}
+	return extractos, rows.Err()
+}
+
+const selectMovimientosExtracto = `
+	SELECT m.id_movimiento, m.id_extracto, m.fecha, m.monto, m.descripcion, m.ordenante, m.huella,
+	       m.estado, COALESCE(m.id_estudiante, 0), COALESCE(e.apellidos || ', ' || e.nombres, ''),
+	       COALESCE(m.criterio, ''), COALESCE(m.id_pago, 0), m.resuelto_en, x.origen
+	FROM movimientos_extracto m
+	JOIN extractos x ON m.id_extracto = x.id_extracto
+	LEFT JOIN estudiantes e ON m.id_estudiante = e.id_estudiante`
+
+func escanearMovimientosExtracto(rows *sql.Rows) ([]models.MovimientoExtracto, error) {
+	defer rows.Close()
+
+	var movimientos []models.MovimientoExtracto
+	for rows.Next() {
+		var m models.MovimientoExtracto
+		var resuelto sql.NullTime
+		if err := rows.Scan(&m.IdMovimiento, &m.IdExtracto, &m.Fecha, &m.Monto, &m.Descripcion, &m.Ordenante,
+			&m.Huella, &m.Estado, &m.IdEstudiante, &m.NombreEstudiante, &m.Criterio, &m.IdPago, &resuelto, &m.Origen); err != nil {
+			return nil, err
+		}
+		if resuelto.Valid {
+			m.ResueltoEn = &resuelto.Time
+		}
+		movimientos = append(movimientos, m)
+	}
+	return movimientos, rows.Err()
+}
+
+// ObtenerMovimientosPendientes retorna los abonos por conciliar, del más antiguo al más reciente
+func (r *Repositorio) ObtenerMovimientosPendientes() ([]models.MovimientoExtracto, error) {
+	rows, err := r.db.Query(selectMovimientosExtracto + `
+		WHERE m.estado = 'pendiente'
+		ORDER BY m.fecha, m.id_movimiento
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return escanearMovimientosExtracto(rows)
+}
+
+// ObtenerMovimientosResueltos retorna los últimos abonos confirmados o descartados
+func (r *Repositorio) ObtenerMovimientosResueltos(limite int) ([]models.MovimientoExtracto, error) {
+	rows, err := r.db.Query(selectMovimientosExtracto+`
+		WHERE m.estado <> 'pendiente'
+		ORDER BY m.resuelto_en DESC, m.id_movimiento DESC
+		LIMIT ?
+	`, limite)
+	if err != nil {
+		return nil, err
+	}
+	return escanearMovimientosExtracto(rows)
+}
+
+// ObtenerMovimientoExtracto retorna un abono por su id
+func (r *Repositorio) ObtenerMovimientoExtracto(idMovimiento int) (models.MovimientoExtracto, error) {
+	rows, err := r.db.Query(selectMovimientosExtracto+` WHERE m.id_movimiento = ?`, idMovimiento)
+	if err != nil {
+		return models.MovimientoExtracto{}, err
+	}
+	movimientos, err := escanearMovimientosExtracto(rows)
+	if err != nil {
+		return models.MovimientoExtracto{}, err
+	}
+	if len(movimientos) == 0 {
+		return models.MovimientoExtracto{}, sql.ErrNoRows
+	}
+	return movimientos[0], nil
+}
+
+// ReservarMovimiento pasa un abono pendiente a confirmado con el estudiante elegido,
+// antes de crear el pago. Retorna false si ya no estaba pendiente (doble clic, otra
+// pestaña), así el mismo abono nunca genera dos pagos.
+func (r *Repositorio) ReservarMovimiento(idMovimiento, idEstudiante int, criterio string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE movimientos_extracto
+		SET estado = 'confirmado', id_estudiante = ?, criterio = ?, resuelto_en = CURRENT_TIMESTAMP
+		WHERE id_movimiento = ? AND estado = 'pendiente'
+	`, idEstudiante, nuloSiVacio(criterio), idMovimiento)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GuardarPagoMovimiento asocia al abono confirmado el pago que se creó
+func (r *Repositorio) GuardarPagoMovimiento(idMovimiento, idPago int) error {
+	_, err := r.db.Exec(`UPDATE movimientos_extracto SET id_pago = ? WHERE id_movimiento = ?`, idPago, idMovimiento)
+	return err
+}
+
+// CambiarEstadoMovimiento descarta un abono pendiente o devuelve a pendiente uno
+// descartado. Retorna false si el abono no estaba en el estado esperado.
+func (r *Repositorio) CambiarEstadoMovimiento(idMovimiento int, desde, hacia string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE movimientos_extracto
+		SET estado = ?2,
+		    resuelto_en = CASE WHEN ?2 = 'pendiente' THEN NULL ELSE CURRENT_TIMESTAMP END
+		WHERE id_movimiento = ?3 AND estado = ?1
+	`, desde, hacia, idMovimiento)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import "database/sql"
+
+// ObtenerConfiguracion retorna el valor de un parámetro general ("" si no existe)
+func (r *Repositorio) ObtenerConfiguracion(clave string) (string, error) {
+	var valor string
+	err := r.db.QueryRow(`SELECT valor FROM configuracion WHERE clave = ?`, clave).Scan(&valor)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return valor, err
+}
+
+// GuardarConfiguracion crea o reemplaza un parámetro general
+func (r *Repositorio) GuardarConfiguracion(clave, valor string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO configuracion (clave, valor) VALUES (?, ?)
+		ON CONFLICT (clave) DO UPDATE SET valor = excluded.valor
+	`, clave, valor)
+	return err
+}
@@ -39,7 +39,17 @@ func (r *Repositorio) ObtenerConsumosSemana(fechaInicio, fechaFin time.Time) ([]
 	return consumos, rows.Err()
 }
 
-// ObtenerDeudaAnterior calcula la deuda anterior de un estudiante hasta una fecha
+// costoProductoSQL es el costo unitario de un producto (parámetros: id dos veces):
+// la suma del costo de sus componentes si es combo, o su propio costo
+const costoProductoSQL = `COALESCE(
+	(SELECT SUM(cp.cantidad * pc.costo_unitario)
+	 FROM componentes_producto cp
+	 JOIN productos pc ON cp.id_componente = pc.id_producto
+	 WHERE cp.id_combo = ?),
+	(SELECT costo_unitario FROM productos WHERE id_producto = ?))`
+
+// ObtenerDeudaAnterior calcula la deuda anterior de un estudiante hasta una fecha.
+// Incluye los cargos de planes de alimentación anteriores a la fecha.
 func (r *Repositorio) ObtenerDeudaAnterior(idEstudiante int, fechaLimite time.Time) (float64, error) {
 	fechaLimiteStr := fechaLimite.Format("2006-01-02")
 
@@ -52,6 +62,15 @@ func (r *Repositorio) ObtenerDeudaAnterior(idEstudiante int, fechaLimite time.Ti
 		return 0, err
 	}
 
+	var totalCargos sql.NullFloat64
+	err = r.db.QueryRow(`
+		SELECT SUM(monto) FROM cargos_plan
+		WHERE id_estudiante = ? AND fecha_cargo < ?
+	`, idEstudiante, fechaLimiteStr).Scan(&totalCargos)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
 	var totalPagos sql.NullFloat64
 	err = r.db.QueryRow(`
 		SELECT SUM(monto) FROM pagos
@@ -65,6 +84,9 @@ func (r *Repositorio) ObtenerDeudaAnterior(idEstudiante int, fechaLimite time.Ti
 	if totalConsumos.Valid {
 		consumos = totalConsumos.Float64
 	}
+	if totalCargos.Valid {
+		consumos += totalCargos.Float64
+	}
 	pagos := 0.0
 	if totalPagos.Valid {
 		pagos = totalPagos.Float64
@@ -87,42 +109,69 @@ func (r *Repositorio) RegistrarConsumo(consumo models.Consumo) error {
 // ActualizarConsumo actualiza, inserta o elimina un consumo según la cantidad.
 // UPSERT: safe for idempotent resubmission — SELECT → INSERT (qty>0) | UPDATE (row exists, qty>0) | DELETE (qty<=0) | noop (no row, qty<=0).
 // Two identical submissions always produce exactly 1 row; qty=0 deletes the row.
-func (r *Repositorio) ActualizarConsumo(idEstudiante, idProducto int, fecha time.Time, cantidad int, precioUnitario float64) error {
+// La diferencia de cantidad se descuenta del stock del producto en la misma transacción.
+// precio_unitario_venta guarda el precio neto; el descuento por unidad y su regla van aparte.
+func (r *Repositorio) ActualizarConsumo(idEstudiante, idProducto int, fecha time.Time, cantidad int, precio models.PrecioAplicado) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := actualizarConsumoTx(tx, idEstudiante, idProducto, fecha, cantidad, precio); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// actualizarConsumoTx fija la cantidad de un producto en el día (0 borra la línea)
+// y ajusta el stock. Retorna la cantidad que había antes.
+func actualizarConsumoTx(tx *sql.Tx, idEstudiante, idProducto int, fecha time.Time, cantidad int, precio models.PrecioAplicado) (int, error) {
 	fechaStr := fecha.Format("2006-01-02")
 
 	var idConsumo int64
-	err := r.db.QueryRow(`
-		SELECT id_consumo FROM consumos
+	var cantidadAnterior int
+	err := tx.QueryRow(`
+		SELECT id_consumo, cantidad FROM consumos
 		WHERE id_estudiante = ? AND id_producto = ? AND fecha_consumo = ?
 		LIMIT 1
-	`, idEstudiante, idProducto, fechaStr).Scan(&idConsumo)
+	`, idEstudiante, idProducto, fechaStr).Scan(&idConsumo, &cantidadAnterior)
 
-	if err == sql.ErrNoRows {
-		if cantidad > 0 {
-			return r.RegistrarConsumo(models.Consumo{
-				IdEstudiante:        idEstudiante,
-				IdProducto:          idProducto,
-				Cantidad:            cantidad,
-				PrecioUnitarioVenta: precioUnitario,
-				FechaConsumo:        fecha,
-			})
+	switch {
+	case err == sql.ErrNoRows:
+		if cantidad <= 0 {
+			return 0, nil
 		}
-		return nil
-	} else if err != nil {
-		return err
+		_, err = tx.Exec(`
+			INSERT INTO consumos (id_estudiante, id_producto, cantidad, precio_unitario_venta, fecha_consumo,
+			                      costo_unitario, descuento_unitario, id_regla_descuento, id_suscripcion)
+			VALUES (?, ?, ?, ?, ?, `+costoProductoSQL+`, ?, ?, ?)
+		`, idEstudiante, idProducto, cantidad, precio.PrecioVenta(), fechaStr, idProducto, idProducto,
+			precio.Descuento, nuloSiCero(precio.IdRegla), nuloSiCero(precio.IdSuscripcion))
+	case err != nil:
+		return 0, err
+	case cantidad <= 0:
+		cantidad = 0
+		_, err = tx.Exec(`DELETE FROM consumos WHERE id_consumo = ?`, idConsumo)
+	default:
+		// total_linea es GENERATED, solo actualizamos cantidad y precio
+		_, err = tx.Exec(`
+			UPDATE consumos SET cantidad = ?, precio_unitario_venta = ?,
+			                    descuento_unitario = ?, id_regla_descuento = ?, id_suscripcion = ?
+			WHERE id_consumo = ?
+		`, cantidad, precio.PrecioVenta(), precio.Descuento, nuloSiCero(precio.IdRegla),
+			nuloSiCero(precio.IdSuscripcion), idConsumo)
 	}
-
-	if cantidad <= 0 {
-		_, err = r.db.Exec(`DELETE FROM consumos WHERE id_consumo = ?`, idConsumo)
-		return err
+	if err != nil {
+		return 0, err
 	}
 
-	// total_linea es GENERATED, solo actualizamos cantidad y precio
-	_, err = r.db.Exec(`
-		UPDATE consumos SET cantidad = ?, precio_unitario_venta = ?
-		WHERE id_consumo = ?
-	`, cantidad, precioUnitario, idConsumo)
-	return err
+	if delta := cantidad - cantidadAnterior; delta != 0 {
+		if err := descontarStockVentaTx(tx, idProducto, delta, fecha); err != nil {
+			return 0, err
+		}
+	}
+	return cantidadAnterior, nil
 }
 
 // ObtenerConsumoExistente verifica si existe un consumo y retorna la cantidad
@@ -141,30 +190,23 @@ func (r *Repositorio) ObtenerConsumoExistente(idEstudiante, idProducto int, fech
 	return cantidad, err
 }
 
-// ObtenerResumenDiario retorna los consumos del día agrupados por estudiante para un sector.
-// sector: "menor" (id_grado IN 1,2,3,4) | "mayor" (id_grado IN 5,6,7)
+// ObtenerResumenDiario retorna los consumos del día agrupados por estudiante para un
+// sector del registro (ver filtroSector)
 func (r *Repositorio) ObtenerResumenDiario(sector string, fecha time.Time) ([]models.ResumenEstudiante, error) {
-	var gradoList string
-	if sector == "menor" {
-		gradoList = "1,2,3,4"
-	} else {
-		gradoList = "5,6,7"
-	}
-
 	query := `
 		SELECT
 			e.id_estudiante,
 			e.nombres,
 			e.apellidos,
-			g.anio_grado || ' ' || g.nivel_grado AS nombre_grado,
+			COALESCE(g.anio_grado || ' ' || g.nivel_grado, '') AS nombre_grado,
 			p.nombre AS nombre_producto,
 			c.cantidad
 		FROM consumos c
 		JOIN estudiantes e ON c.id_estudiante = e.id_estudiante
-		JOIN grados g ON e.id_grado = g.id_grado
+		LEFT JOIN grados g ON e.id_grado = g.id_grado
 		JOIN productos p ON c.id_producto = p.id_producto
 		WHERE c.fecha_consumo = ?
-		  AND e.id_grado IN (` + gradoList + `)
+		  AND ` + filtroSector(sector) + `
 		ORDER BY e.apellidos, e.nombres, p.nombre
 	`
 
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// consultor es lo que comparten *sql.DB y *sql.Tx para leer
+type consultor interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// cantidadesDia retorna las cantidades por producto de un estudiante en un día y
+// la versión de ese día: un hash de las cantidades, que cambia con cualquier edición.
+func cantidadesDia(q consultor, idEstudiante int, fecha time.Time) (map[int]int, string, error) {
+	rows, err := q.Query(`
+		SELECT id_producto, SUM(cantidad) FROM consumos
+		WHERE id_estudiante = ? AND fecha_consumo = ?
+		GROUP BY id_producto
+		ORDER BY id_producto
+	`, idEstudiante, fecha.Format("2006-01-02"))
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	cantidades := make(map[int]int)
+	h := sha256.New()
+	for rows.Next() {
+		var idProducto, cantidad int
+		if err := rows.Scan(&idProducto, &cantidad); err != nil {
+			return nil, "", err
+		}
+		cantidades[idProducto] = cantidad
+		fmt.Fprintf(h, "%d:%d;", idProducto, cantidad)
+	}
+	return cantidades, hex.EncodeToString(h.Sum(nil)[:8]), rows.Err()
+}
+
+// ObtenerVersionConsumosDia retorna la versión de los consumos de un estudiante en un día
+func (r *Repositorio) ObtenerVersionConsumosDia(idEstudiante int, fecha time.Time) (string, error) {
+	_, version, err := cantidadesDia(r.db, idEstudiante, fecha)
+	return version, err
+}
+
+// GuardarConsumosDia aplica en una sola transacción las cantidades del formulario
+// de un día. Si la clave de idempotencia ya se usó no hace nada (Repetida). Si
+// version no es "" y no coincide con la actual, otra edición se adelantó: no
+// guarda nada y retorna las cantidades actuales (Conflicto). Si falla una línea
+// no se guarda ninguna e IdProductoFallido indica cuál fue.
+func (r *Repositorio) GuardarConsumosDia(idEstudiante int, fecha time.Time, version, clave, ruta string, lineas []models.LineaConsumoDia) (models.ResultadoConsumosDia, error) {
+	var resultado models.ResultadoConsumosDia
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return resultado, err
+	}
+	defer tx.Rollback()
+
+	// Tomar el bloqueo de escritura antes de leer la versión; si no, dos cajeras
+	// podrían leer la misma versión y guardar las dos
+	if _, err := tx.Exec(`UPDATE estudiantes SET id_estudiante = id_estudiante WHERE id_estudiante = ?`, idEstudiante); err != nil {
+		return resultado, err
+	}
+
+	if clave != "" {
+		res, err := tx.Exec(`
+			INSERT INTO claves_idempotencia (clave, ruta) VALUES (?, ?)
+			ON CONFLICT (clave) DO NOTHING
+		`, clave, ruta)
+		if err != nil {
+			return resultado, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			resultado.Repetida = true
+			return resultado, err
+		}
+	}
+
+	actuales, actual, err := cantidadesDia(tx, idEstudiante, fecha)
+	if err != nil {
+		return resultado, err
+	}
+	if version != "" && version != actual {
+		resultado.Conflicto, resultado.Version, resultado.Anteriores = true, actual, actuales
+		return resultado, nil
+	}
+
+	resultado.Anteriores = make(map[int]int, len(lineas))
+	for _, l := range lineas {
+		anterior, err := actualizarConsumoTx(tx, idEstudiante, l.IdProducto, fecha, l.Cantidad, l.Precio)
+		if err != nil {
+			resultado.IdProductoFallido = l.IdProducto
+			return resultado, fmt.Errorf("producto %d: %v", l.IdProducto, err)
+		}
+		resultado.Anteriores[l.IdProducto] = anterior
+	}
+
+	if _, resultado.Version, err = cantidadesDia(tx, idEstudiante, fecha); err != nil {
+		return resultado, err
+	}
+	return resultado, tx.Commit()
+}
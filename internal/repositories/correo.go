@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+)
+
+// ObtenerApoderados retorna todos los apoderados con el nombre de su estudiante
+func (r *Repositorio) ObtenerApoderados() ([]models.Apoderado, error) {
+	rows, err := r.db.Query(`
+		SELECT a.id_apoderado, a.id_estudiante, e.apellidos || ', ' || e.nombres,
+		       a.nombre, COALESCE(a.email, ''), COALESCE(a.telefono, ''),
+		       a.recibe_correos, a.recibe_mensajes, a.token_baja, a.portal_generado_en, a.creado_en
+		FROM apoderados a
+		JOIN estudiantes e ON a.id_estudiante = e.id_estudiante
+		ORDER BY e.apellidos, e.nombres, a.nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apoderados []models.Apoderado
+	for rows.Next() {
+		var a models.Apoderado
+		var portal sql.NullTime
+		if err := rows.Scan(&a.IdApoderado, &a.IdEstudiante, &a.NombreEstudiante, &a.Nombre,
+			&a.Email, &a.Telefono, &a.RecibeCorreos, &a.RecibeMensajes, &a.TokenBaja, &portal, &a.CreadoEn); err != nil {
+			return nil, err
+		}
+		if portal.Valid {
+			a.PortalGeneradoEn = &portal.Time
+		}
+		apoderados = append(apoderados, a)
+	}
+	return apoderados, rows.Err()
+}
+
+// ObtenerApoderadoPorTokenBaja busca al apoderado del enlace de baja
+func (r *Repositorio) ObtenerApoderadoPorTokenBaja(token string) (models.Apoderado, error) {
+	var a models.Apoderado
+	err := r.db.QueryRow(`
+		SELECT a.id_apoderado, a.id_estudiante, e.apellidos || ', ' || e.nombres,
+		       a.nombre, COALESCE(a.email, ''), COALESCE(a.telefono, ''),
+		       a.recibe_correos, a.recibe_mensajes, a.token_baja, a.creado_en
+		FROM apoderados a
+		JOIN estudiantes e ON a.id_estudiante = e.id_estudiante
+		WHERE a.token_baja = ?
+	`, token).Scan(&a.IdApoderado, &a.IdEstudiante, &a.NombreEstudiante, &a.Nombre,
+		&a.Email, &a.Telefono, &a.RecibeCorreos, &a.RecibeMensajes, &a.TokenBaja, &a.CreadoEn)
+	return a, err
+}
+
+// InsertarApoderado registra un apoderado de un estudiante
+func (r *Repositorio) InsertarApoderado(a models.Apoderado) error {
+	_, err := r.db.Exec(`
+		INSERT INTO apoderados (id_estudiante, nombre, email, telefono, token_baja)
+		VALUES (?, ?, ?, ?, ?)
+	`, a.IdEstudiante, a.Nombre, nuloSiVacio(a.Email), nuloSiVacio(a.Telefono), a.TokenBaja)
+	return err
+}
+
+// nuloSiVacio guarda NULL en lugar de "" para columnas opcionales con UNIQUE
+func nuloSiVacio(valor string) any {
+	if valor == "" {
+		return nil
+	}
+	return valor
+}
+
+// CambiarRecepcionApoderado da de baja (false) o reactiva (true) los correos de
+// un apoderado. La baja cancela también los correos que seguían en cola.
+func (r *Repositorio) CambiarRecepcionApoderado(idApoderado int, recibe bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE apoderados SET recibe_correos = ? WHERE id_apoderado = ?`, recibe, idApoderado); err != nil {
+		return err
+	}
+	if !recibe {
+		if _, err := tx.Exec(`
+			UPDATE correos SET estado = 'fallido', ultimo_error = 'Apoderado dado de baja'
+			WHERE id_apoderado = ? AND estado = 'pendiente'
+		`, idApoderado); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// EliminarApoderado borra un apoderado; sus correos quedan en el registro sin vínculo
+func (r *Repositorio) EliminarApoderado(idApoderado int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Los pendientes no deben salir después de eliminar el contacto
+	for _, tabla := range []string{"correos", "mensajes"} {
+		if _, err := tx.Exec(`
+			UPDATE `+tabla+` SET estado = 'fallido', ultimo_error = 'Apoderado eliminado'
+			WHERE id_apoderado = ? AND estado = 'pendiente'
+		`, idApoderado); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE `+tabla+` SET id_apoderado = NULL WHERE id_apoderado = ?`, idApoderado); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM apoderados WHERE id_apoderado = ?`, idApoderado); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EncolarCorreo agrega un correo a la bandeja de salida. Retorna false si el
+// apoderado ya tenía un correo del mismo tipo para esa semana.
+func (r *Repositorio) EncolarCorreo(c models.Correo) (bool, error) {
+	var semana any
+	if c.Semana != nil {
+		semana = c.Semana.Format("2006-01-02")
+	}
+	res, err := r.db.Exec(`
+		INSERT INTO correos (tipo, id_apoderado, id_estudiante, semana, destinatario,
+		                     asunto, cuerpo_texto, cuerpo_html, url_baja)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tipo, id_apoderado, semana) DO NOTHING
+	`, c.Tipo, nuloSiCero(c.IdApoderado), nuloSiCero(c.IdEstudiante), semana, c.Destinatario,
+		c.Asunto, c.CuerpoTexto, c.CuerpoHTML, c.URLBaja)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+const selectCorreos = `
+	SELECT id_correo, tipo, COALESCE(id_apoderado, 0), COALESCE(id_estudiante, 0), semana,
+	       destinatario, asunto, cuerpo_texto, cuerpo_html, url_baja, estado, intentos,
+	       proximo_intento, COALESCE(ultimo_error, ''), creado_en, enviado_en
+	FROM correos`
+
+func escanearCorreos(rows *sql.Rows) ([]models.Correo, error) {
+	defer rows.Close()
+
+	var correos []models.Correo
+	for rows.Next() {
+		var c models.Correo
+		var semana, enviado sql.NullTime
+		if err := rows.Scan(&c.IdCorreo, &c.Tipo, &c.IdApoderado, &c.IdEstudiante, &semana,
+			&c.Destinatario, &c.Asunto, &c.CuerpoTexto, &c.CuerpoHTML, &c.URLBaja, &c.Estado,
+			&c.Intentos, &c.ProximoIntento, &c.UltimoError, &c.CreadoEn, &enviado); err != nil {
+			return nil, err
+		}
+		if semana.Valid {
+			c.Semana = &semana.Time
+		}
+		if enviado.Valid {
+			c.EnviadoEn = &enviado.Time
+		}
+		correos = append(correos, c)
+	}
+	return correos, rows.Err()
+}
+
+// ObtenerCorreosPendientes retorna los correos cuyo próximo intento ya venció
+func (r *Repositorio) ObtenerCorreosPendientes(limite int) ([]models.Correo, error) {
+	rows, err := r.db.Query(selectCorreos+`
+		WHERE estado = 'pendiente' AND proximo_intento <= CURRENT_TIMESTAMP
+		ORDER BY proximo_intento, id_correo
+		LIMIT ?
+	`, limite)
+	if err != nil {
+		return nil, err
+	}
+	return escanearCorreos(rows)
+}
+
+// ObtenerCorreos retorna los últimos correos para el registro, opcionalmente por estado
+func (r *Repositorio) ObtenerCorreos(estado string, limite int) ([]models.Correo, error) {
+	rows, err := r.db.Query(selectCorreos+`
+		WHERE ? = '' OR estado = ?
+		ORDER BY id_correo DESC
+		LIMIT ?
+	`, estado, estado, limite)
+	if err != nil {
+		return nil, err
+	}
+	return escanearCorreos(rows)
+}
+
+// MarcarCorreoEnviado registra que el servidor SMTP aceptó el correo
+func (r *Repositorio) MarcarCorreoEnviado(idCorreo int) error {
+	_, err := r.db.Exec(`
+		UPDATE correos
+		SET estado = 'enviado', intentos = intentos + 1, ultimo_error = NULL,
+		    enviado_en = CURRENT_TIMESTAMP
+		WHERE id_correo = ?
+	`, idCorreo)
+	return err
+}
+
+// MarcarCorreoFallido registra un intento fallido. Si esperaSegundos es 0 el
+// correo queda como fallido; si no, se reprograma para dentro de esa espera.
+func (r *Repositorio) MarcarCorreoFallido(idCorreo int, mensaje string, esperaSegundos int) error {
+	estado := models.CorreoPendiente
+	if esperaSegundos == 0 {
+		estado = models.CorreoFallido
+	}
+	_, err := r.db.Exec(`
+		UPDATE correos
+		SET estado = ?, intentos = intentos + 1, ultimo_error = ?,
+		    proximo_intento = datetime('now', ?)
+		WHERE id_correo = ?
+	`, estado, mensaje, fmt.Sprintf("+%d seconds", esperaSegundos), idCorreo)
+	return err
+}
+
+// ReintentarCorreo vuelve a poner en cola un correo fallido. No reintenta los
+// de apoderados eliminados o dados de baja; retorna false en ese caso.
+func (r *Repositorio) ReintentarCorreo(idCorreo int) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE correos
+		SET estado = 'pendiente', intentos = 0, proximo_intento = CURRENT_TIMESTAMP
+		WHERE id_correo = ? AND estado = 'fallido'
+		  AND (tipo = 'prueba' OR id_apoderado IN (
+		      SELECT id_apoderado FROM apoderados WHERE recibe_correos = 1))
+	`, idCorreo)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
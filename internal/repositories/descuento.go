@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"database/sql"
+	"kiosco/internal/models"
+	"time"
+)
+
+// selectReglasDescuento es la consulta base que espera escanearReglaDescuento
+const selectReglasDescuento = `
+	SELECT r.id_regla, r.nombre, COALESCE(r.id_estudiante, 0), COALESCE(r.id_grupo, 0),
+	       COALESCE(r.id_producto, 0), COALESCE(r.id_categoria, 0),
+	       r.tipo, r.valor, r.vigente_desde, r.vigente_hasta, r.esta_activo,
+	       COALESCE(e.apellidos || ', ' || e.nombres, g.nombre, ''),
+	       COALESCE(p.nombre, cat.nombre, '')
+	FROM reglas_descuento r
+	LEFT JOIN estudiantes e ON r.id_estudiante = e.id_estudiante
+	LEFT JOIN grupos_descuento g ON r.id_grupo = g.id_grupo
+	LEFT JOIN productos p ON r.id_producto = p.id_producto
+	LEFT JOIN categorias cat ON r.id_categoria = cat.id_categoria`
+
+func escanearReglasDescuento(rows *sql.Rows) ([]models.ReglaDescuento, error) {
+	defer rows.Close()
+
+	var reglas []models.ReglaDescuento
+	for rows.Next() {
+		var rg models.ReglaDescuento
+		var hasta sql.NullTime
+		if err := rows.Scan(&rg.IdRegla, &rg.Nombre, &rg.IdEstudiante, &rg.IdGrupo,
+			&rg.IdProducto, &rg.IdCategoria, &rg.Tipo, &rg.Valor, &rg.VigenteDesde, &hasta,
+			&rg.EstaActivo, &rg.NombreDestino, &rg.NombreAlcance); err != nil {
+			return nil, err
+		}
+		if hasta.Valid {
+			rg.VigenteHasta = &hasta.Time
+		}
+		reglas = append(reglas, rg)
+	}
+	return reglas, rows.Err()
+}
+
+// ObtenerReglasDescuento retorna todas las reglas, las activas primero
+func (r *Repositorio) ObtenerReglasDescuento() ([]models.ReglaDescuento, error) {
+	rows, err := r.db.Query(selectReglasDescuento + `
+		ORDER BY r.esta_activo DESC, r.vigente_desde DESC, r.nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return escanearReglasDescuento(rows)
+}
+
+// ObtenerReglasVigentes retorna las reglas activas que alcanzan a un estudiante
+// (directamente o por sus grupos) en la fecha indicada
+func (r *Repositorio) ObtenerReglasVigentes(idEstudiante int, fecha time.Time) ([]models.ReglaDescuento, error) {
+	rows, err := r.db.Query(selectReglasDescuento+`
+		WHERE r.esta_activo = 1
+		  AND r.vigente_desde <= ?1
+		  AND (r.vigente_hasta IS NULL OR r.vigente_hasta >= ?1)
+		  AND (r.id_estudiante = ?2
+		       OR r.id_grupo IN (SELECT id_grupo FROM estudiantes_grupo WHERE id_estudiante = ?2))
+	`, fecha.Format("2006-01-02"), idEstudiante)
+	if err != nil {
+		return nil, err
+	}
+	return escanearReglasDescuento(rows)
+}
+
+// InsertarReglaDescuento agrega una regla activa
+func (r *Repositorio) InsertarReglaDescuento(regla models.ReglaDescuento) error {
+	var hasta interface{}
+	if regla.VigenteHasta != nil {
+		hasta = regla.VigenteHasta.Format("2006-01-02")
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO reglas_descuento (nombre, id_estudiante, id_grupo, id_producto, id_categoria,
+		                              tipo, valor, vigente_desde, vigente_hasta)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, regla.Nombre, nuloSiCero(regla.IdEstudiante), nuloSiCero(regla.IdGrupo),
+		nuloSiCero(regla.IdProducto), nuloSiCero(regla.IdCategoria),
+		regla.Tipo, regla.Valor, regla.VigenteDesde.Format("2006-01-02"), hasta)
+	return err
+}
+
+// CambiarEstadoReglaDescuento activa o desactiva una regla
+func (r *Repositorio) CambiarEstadoReglaDescuento(idRegla int, activa bool) error {
+	estado := 0
+	if activa {
+		estado = 1
+	}
+	_, err := r.db.Exec(`UPDATE reglas_descuento SET esta_activo = ? WHERE id_regla = ?`, estado, idRegla)
+	return err
+}
+
+// ObtenerGruposDescuento retorna los grupos con sus estudiantes
+func (r *Repositorio) ObtenerGruposDescuento() ([]models.GrupoDescuento, error) {
+	rows, err := r.db.Query(`
+		SELECT g.id_grupo, g.nombre, e.id_estudiante, e.nombres, e.apellidos
+		FROM grupos_descuento g
+		LEFT JOIN estudiantes_grupo eg ON g.id_grupo = eg.id_grupo
+		LEFT JOIN estudiantes e ON eg.id_estudiante = e.id_estudiante
+		ORDER BY g.nombre, e.apellidos, e.nombres
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grupos []models.GrupoDescuento
+	for rows.Next() {
+		var idGrupo int
+		var nombre string
+		var idEst sql.NullInt64
+		var nombres, apellidos sql.NullString
+		if err := rows.Scan(&idGrupo, &nombre, &idEst, &nombres, &apellidos); err != nil {
+			return nil, err
+		}
+		if len(grupos) == 0 || grupos[len(grupos)-1].IdGrupo != idGrupo {
+			grupos = append(grupos, models.GrupoDescuento{IdGrupo: idGrupo, Nombre: nombre})
+		}
+		if idEst.Valid {
+			g := &grupos[len(grupos)-1]
+			g.Estudiantes = append(g.Estudiantes, models.Estudiante{
+				IdEstudiante: int(idEst.Int64),
+				Nombres:      nombres.String,
+				Apellidos:    apellidos.String,
+			})
+		}
+	}
+	return grupos, rows.Err()
+}
+
+// InsertarGrupoDescuento crea un grupo de estudiantes vacío
+func (r *Repositorio) InsertarGrupoDescuento(nombre string) error {
+	_, err := r.db.Exec(`INSERT INTO grupos_descuento (nombre) VALUES (?)`, nombre)
+	return err
+}
+
+// AgregarEstudianteGrupo incluye un estudiante en un grupo (idempotente)
+func (r *Repositorio) AgregarEstudianteGrupo(idGrupo, idEstudiante int) error {
+	_, err := r.db.Exec(`
+		INSERT OR IGNORE INTO estudiantes_grupo (id_grupo, id_estudiante) VALUES (?, ?)
+	`, idGrupo, idEstudiante)
+	return err
+}
+
+// QuitarEstudianteGrupo retira un estudiante de un grupo
+func (r *Repositorio) QuitarEstudianteGrupo(idGrupo, idEstudiante int) error {
+	_, err := r.db.Exec(`
+		DELETE FROM estudiantes_grupo WHERE id_grupo = ? AND id_estudiante = ?
+	`, idGrupo, idEstudiante)
+	return err
+}
+
+// ObtenerDescuentosPorEstudiante resume bruto, descuento y neto por estudiante
+// en un rango de fechas; solo incluye estudiantes con algún descuento
+func (r *Repositorio) ObtenerDescuentosPorEstudiante(desde, hasta time.Time) ([]models.DescuentoEstudiante, error) {
+	rows, err := r.db.Query(`
+		SELECT e.id_estudiante, e.apellidos || ', ' || e.nombres,
+		       COALESCE(g.anio_grado || ' ' || g.nivel_grado, ''),
+		       SUM(c.cantidad * (c.precio_unitario_venta + c.descuento_unitario)),
+		       SUM(c.cantidad * c.descuento_unitario),
+		       SUM(c.total_linea)
+		FROM consumos c
+		JOIN estudiantes e ON c.id_estudiante = e.id_estudiante
+		LEFT JOIN grados g ON e.id_grado = g.id_grado
+		WHERE c.fecha_consumo BETWEEN ? AND ?
+		GROUP BY e.id_estudiante
+		HAVING SUM(c.descuento_unitario) > 0
+		ORDER BY 5 DESC
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resultado []models.DescuentoEstudiante
+	for rows.Next() {
+		var d models.DescuentoEstudiante
+		if err := rows.Scan(&d.IdEstudiante, &d.NombreEstudiante, &d.NombreGrado,
+			&d.Bruto, &d.Descuento, &d.Neto); err != nil {
+			return nil, err
+		}
+		resultado = append(resultado, d)
+	}
+	return resultado, rows.Err()
+}
+
+// ObtenerDescuentosPorRegla resume el total descontado por regla en un rango de fechas
+func (r *Repositorio) ObtenerDescuentosPorRegla(desde, hasta time.Time) ([]models.DescuentoRegla, error) {
+	rows, err := r.db.Query(`
+		SELECT rd.id_regla, rd.nombre, COUNT(*), SUM(c.cantidad * c.descuento_unitario)
+		FROM consumos c
+		JOIN reglas_descuento rd ON c.id_regla_descuento = rd.id_regla
+		WHERE c.fecha_consumo BETWEEN ? AND ?
+		GROUP BY rd.id_regla
+		ORDER BY 4 DESC
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resultado []models.DescuentoRegla
+	for rows.Next() {
+		var d models.DescuentoRegla
+		if err := rows.Scan(&d.IdRegla, &d.Nombre, &d.Lineas, &d.Descuento); err != nil {
+			return nil, err
+		}
+		resultado = append(resultado, d)
+	}
+	return resultado, rows.Err()
+}
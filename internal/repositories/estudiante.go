@@ -1,43 +1,107 @@
 package repositories
 
 import (
+	"database/sql"
 	"kiosco/internal/models"
 	"time"
 )
 
-// ObtenerEstudiantesActivos retorna todos los estudiantes activos
+// selectEstudiantes trae las cuentas con su grado; quien no es estudiante no tiene
+const selectEstudiantes = `
+		SELECT e.id_estudiante, e.nombres, e.apellidos, COALESCE(e.id_grado, 0), e.esta_activo,
+		       COALESCE(g.anio_grado || ' ' || g.nivel_grado, '') as nombre_grado, COALESCE(e.codigo, ''), e.tipo
+		FROM estudiantes e
+		LEFT JOIN grados g ON e.id_grado = g.id_grado`
+
+// escanearEstudiante lee una fila de selectEstudiantes. Sin grado, se muestra el tipo.
+func escanearEstudiante(fila interface{ Scan(...any) error }) (models.Estudiante, error) {
+	var e models.Estudiante
+	if err := fila.Scan(&e.IdEstudiante, &e.Nombres, &e.Apellidos, &e.IdGrado, &e.EstaActivo, &e.NombreGrado, &e.Codigo, &e.Tipo); err != nil {
+		return e, err
+	}
+	if e.NombreGrado == "" {
+		e.NombreGrado = models.NombreTipoCliente(e.Tipo)
+	}
+	return e, nil
+}
+
+func (r *Repositorio) consultarEstudiantes(query string, args ...any) ([]models.Estudiante, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var estudiantes []models.Estudiante
+	for rows.Next() {
+		e, err := escanearEstudiante(rows)
+		if err != nil {
+			return nil, err
+		}
+		estudiantes = append(estudiantes, e)
+	}
+	return estudiantes, rows.Err()
+}
+
+// filtroSector es la condición de las cuentas de un sector del registro:
+// "menor" (grados 1,2,3,4), "mayor" (grados 5,6,7) o "personal" (docentes,
+// personal y otros clientes que no son estudiantes)
+func filtroSector(sector string) string {
+	switch sector {
+	case "menor":
+		return `e.tipo = 'estudiante' AND e.id_grado IN (1,2,3,4)`
+	case "personal":
+		return `e.tipo <> 'estudiante'`
+	}
+	return `e.tipo = 'estudiante' AND e.id_grado IN (5,6,7)`
+}
+
+// ObtenerEstudiantesActivos retorna todas las cuentas activas, estudiantes o no
 func (r *Repositorio) ObtenerEstudiantesActivos() ([]models.Estudiante, error) {
-	return r.ObtenerEstudiantesPorGrado(0)
+	return r.consultarEstudiantes(selectEstudiantes + `
+		WHERE e.esta_activo = 1
+		ORDER BY e.tipo <> 'estudiante', g.nivel_grado, g.nombre_grado, e.apellidos, e.nombres
+	`)
 }
 
-// InsertarEstudiante agrega un nuevo estudiante activo
-func (r *Repositorio) InsertarEstudiante(nombres, apellidos string, idGrado int) (models.Estudiante, error) {
-	result, err := r.db.Exec(`
-		INSERT INTO estudiantes (nombres, apellidos, id_grado, esta_activo)
-		VALUES (?, ?, ?, 1)
-	`, nombres, apellidos, idGrado)
+// InsertarEstudiante agrega una cuenta activa (idGrado 0 si no es estudiante)
+func (r *Repositorio) InsertarEstudiante(nombres, apellidos string, idGrado int, tipo string) (models.Estudiante, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Estudiante{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO estudiantes (nombres, apellidos, id_grado, esta_activo, tipo)
+		VALUES (?, ?, ?, 1, ?)
+	`, nombres, apellidos, nuloSiCero(idGrado), tipo)
 	if err != nil {
 		return models.Estudiante{}, err
 	}
 
 	id, _ := result.LastInsertId()
+	if err := indexarEstudianteTx(tx, int(id)); err != nil {
+		return models.Estudiante{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Estudiante{}, err
+	}
 	return models.Estudiante{
 		IdEstudiante: int(id),
 		Nombres:      nombres,
 		Apellidos:    apellidos,
 		IdGrado:      idGrado,
 		EstaActivo:   true,
+		Tipo:         tipo,
 	}, nil
 }
 
-// ObtenerEstudiantesPorGrado retorna los estudiantes activos filtrados por grado (0 = todos)
+// ObtenerEstudiantesPorGrado retorna los estudiantes activos filtrados por grado
+// (0 = todos). Las cuentas que no son de estudiantes no aparecen.
 func (r *Repositorio) ObtenerEstudiantesPorGrado(idGrado int) ([]models.Estudiante, error) {
-	query := `
-		SELECT e.id_estudiante, e.nombres, e.apellidos, e.id_grado, e.esta_activo,
-		       g.anio_grado || ' ' || g.nivel_grado as nombre_grado
-		FROM estudiantes e
-		LEFT JOIN grados g ON e.id_grado = g.id_grado
-		WHERE e.esta_activo = 1
+	query := selectEstudiantes + `
+		WHERE e.esta_activo = 1 AND e.tipo = 'estudiante'
 	`
 
 	var args []interface{}
@@ -48,69 +112,42 @@ func (r *Repositorio) ObtenerEstudiantesPorGrado(idGrado int) ([]models.Estudian
 
 	query += " ORDER BY g.nivel_grado, g.nombre_grado, e.apellidos, e.nombres"
 
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var estudiantes []models.Estudiante
-	for rows.Next() {
-		var e models.Estudiante
-		if err := rows.Scan(&e.IdEstudiante, &e.Nombres, &e.Apellidos, &e.IdGrado, &e.EstaActivo, &e.NombreGrado); err != nil {
-			return nil, err
-		}
-		estudiantes = append(estudiantes, e)
-	}
-
-	return estudiantes, rows.Err()
+	return r.consultarEstudiantes(query, args...)
 }
 
-// ObtenerTodosEstudiantes retorna todos los estudiantes (activos e inactivos)
+// ObtenerTodosEstudiantes retorna todas las cuentas (activas e inactivas)
 func (r *Repositorio) ObtenerTodosEstudiantes() ([]models.Estudiante, error) {
-	rows, err := r.db.Query(`
-		SELECT e.id_estudiante, e.nombres, e.apellidos, e.id_grado, e.esta_activo,
-		       g.anio_grado || ' ' || g.nivel_grado as nombre_grado
-		FROM estudiantes e
-		LEFT JOIN grados g ON e.id_grado = g.id_grado
-		ORDER BY e.esta_activo DESC, g.nivel_grado, g.nombre_grado, e.apellidos, e.nombres
+	return r.consultarEstudiantes(selectEstudiantes + `
+		ORDER BY e.esta_activo DESC, e.tipo <> 'estudiante', g.nivel_grado, g.nombre_grado, e.apellidos, e.nombres
 	`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var estudiantes []models.Estudiante
-	for rows.Next() {
-		var e models.Estudiante
-		if err := rows.Scan(&e.IdEstudiante, &e.Nombres, &e.Apellidos, &e.IdGrado, &e.EstaActivo, &e.NombreGrado); err != nil {
-			return nil, err
-		}
-		estudiantes = append(estudiantes, e)
-	}
-	return estudiantes, rows.Err()
 }
 
 // ObtenerEstudiantePorId retorna un estudiante por su ID
 func (r *Repositorio) ObtenerEstudiantePorId(id int) (models.Estudiante, error) {
-	var e models.Estudiante
-	err := r.db.QueryRow(`
-		SELECT e.id_estudiante, e.nombres, e.apellidos, e.id_grado, e.esta_activo,
-		       g.anio_grado || ' ' || g.nivel_grado as nombre_grado
-		FROM estudiantes e
-		LEFT JOIN grados g ON e.id_grado = g.id_grado
+	return escanearEstudiante(r.db.QueryRow(selectEstudiantes+`
 		WHERE e.id_estudiante = ?
-	`, id).Scan(&e.IdEstudiante, &e.Nombres, &e.Apellidos, &e.IdGrado, &e.EstaActivo, &e.NombreGrado)
-	return e, err
+	`, id))
 }
 
-// ActualizarEstudiante modifica los datos de un estudiante
-func (r *Repositorio) ActualizarEstudiante(id int, nombres, apellidos string, idGrado int) error {
-	_, err := r.db.Exec(`
-		UPDATE estudiantes SET nombres = ?, apellidos = ?, id_grado = ?
+// ActualizarEstudiante modifica los datos de una cuenta ("" en codigo lo quita;
+// idGrado 0 si no es estudiante)
+func (r *Repositorio) ActualizarEstudiante(id int, nombres, apellidos string, idGrado int, codigo, tipo string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE estudiantes SET nombres = ?, apellidos = ?, id_grado = ?, codigo = NULLIF(?, ''), tipo = ?
 		WHERE id_estudiante = ?
-	`, nombres, apellidos, idGrado, id)
-	return err
+	`, nombres, apellidos, nuloSiCero(idGrado), codigo, tipo, id); err != nil {
+		return err
+	}
+	if err := indexarEstudianteTx(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // CambiarEstadoEstudiante habilita o deshabilita un estudiante
@@ -125,14 +162,18 @@ func (r *Repositorio) CambiarEstadoEstudiante(id int, activo bool) error {
 	return err
 }
 
-// ObtenerDeudasAnterioresBatch obtiene deudas anteriores para todos los estudiantes de un grado
+// ObtenerDeudasAnterioresBatch obtiene deudas anteriores para todos los estudiantes de un grado.
+// Incluye los cargos de planes de alimentación anteriores a la fecha.
 func (r *Repositorio) ObtenerDeudasAnterioresBatch(idGrado int, fechaLimite time.Time) (map[int]float64, error) {
 	fechaLimiteStr := fechaLimite.Format("2006-01-02")
 
 	rows, err := r.db.Query(`
 		SELECT
 			e.id_estudiante,
-			COALESCE(SUM(c.total_linea), 0) - COALESCE((
+			COALESCE(SUM(c.total_linea), 0) + COALESCE((
+				SELECT SUM(cp.monto) FROM cargos_plan cp
+				WHERE cp.id_estudiante = e.id_estudiante AND cp.fecha_cargo < ?
+			), 0) - COALESCE((
 				SELECT SUM(p.monto) FROM pagos p
 				WHERE p.id_estudiante = e.id_estudiante AND p.fecha_pago < ?
 			), 0) as deuda_anterior
@@ -140,7 +181,7 @@ func (r *Repositorio) ObtenerDeudasAnterioresBatch(idGrado int, fechaLimite time
 		LEFT JOIN consumos c ON e.id_estudiante = c.id_estudiante AND c.fecha_consumo < ?
 		WHERE e.esta_activo = 1 AND (? = 0 OR e.id_grado = ?)
 		GROUP BY e.id_estudiante
-	`, fechaLimiteStr, fechaLimiteStr, idGrado, idGrado)
+	`, fechaLimiteStr, fechaLimiteStr, fechaLimiteStr, idGrado, idGrado)
 	if err != nil {
 		return nil, err
 	}
@@ -159,39 +200,48 @@ func (r *Repositorio) ObtenerDeudasAnterioresBatch(idGrado int, fechaLimite time
 	return deudas, rows.Err()
 }
 
-// ObtenerEstudiantesActivosPorSector retorna estudiantes activos filtrados por sector
-// sector: "menor" (grados 1,2,3,4) o "mayor" (grados 5,6,7)
+// ObtenerEstudiantesActivosPorSector retorna las cuentas activas de un sector
+// del registro (ver filtroSector)
 func (r *Repositorio) ObtenerEstudiantesActivosPorSector(sector string) ([]models.Estudiante, error) {
-	var gradoList string
-	if sector == "menor" {
-		gradoList = "1,2,3,4"
-	} else {
-		gradoList = "5,6,7"
-	}
-
-	query := `
-		SELECT e.id_estudiante, e.nombres, e.apellidos, e.id_grado, e.esta_activo,
-		       g.anio_grado || ' ' || g.nivel_grado as nombre_grado
-		FROM estudiantes e
-		LEFT JOIN grados g ON e.id_grado = g.id_grado
-		WHERE e.esta_activo = 1 AND e.id_grado IN (` + gradoList + `)
+	return r.consultarEstudiantes(selectEstudiantes + `
+		WHERE e.esta_activo = 1 AND ` + filtroSector(sector) + `
 		ORDER BY e.apellidos, e.nombres
-	`
+	`)
+}
 
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, err
+// indexarEstudianteTx actualiza la fila de estudiantes_fts de un estudiante con
+// sus apellidos, nombres y código actuales
+func indexarEstudianteTx(tx *sql.Tx, idEstudiante int) error {
+	if _, err := tx.Exec(`DELETE FROM estudiantes_fts WHERE rowid = ?`, idEstudiante); err != nil {
+		return err
 	}
-	defer rows.Close()
+	_, err := tx.Exec(`
+		INSERT INTO estudiantes_fts (rowid, apellidos, nombres, codigo)
+		SELECT id_estudiante, apellidos, nombres, COALESCE(codigo, '')
+		FROM estudiantes WHERE id_estudiante = ?
+	`, idEstudiante)
+	return err
+}
 
-	var estudiantes []models.Estudiante
-	for rows.Next() {
-		var e models.Estudiante
-		if err := rows.Scan(&e.IdEstudiante, &e.Nombres, &e.Apellidos, &e.IdGrado, &e.EstaActivo, &e.NombreGrado); err != nil {
-			return nil, err
-		}
-		estudiantes = append(estudiantes, e)
-	}
+// BuscarEstudiantes retorna las cuentas activas del sector que coinciden con
+// una consulta FTS5 (ver services.consultaFTS), las más relevantes primero
+func (r *Repositorio) BuscarEstudiantes(consulta, sector string, limite int) ([]models.Estudiante, error) {
+	return r.consultarEstudiantes(`
+		SELECT e.id_estudiante, e.nombres, e.apellidos, COALESCE(e.id_grado, 0), e.esta_activo,
+		       COALESCE(g.anio_grado || ' ' || g.nivel_grado, '') as nombre_grado, COALESCE(e.codigo, ''), e.tipo
+		FROM estudiantes_fts f
+		JOIN estudiantes e ON e.id_estudiante = f.rowid
+		LEFT JOIN grados g ON e.id_grado = g.id_grado
+		WHERE estudiantes_fts MATCH ? AND e.esta_activo = 1 AND `+filtroSector(sector)+`
+		ORDER BY f.rank, e.apellidos, e.nombres
+		LIMIT ?
+	`, consulta, limite)
+}
 
-	return estudiantes, rows.Err()
+// ObtenerEstudiantePorCodigo busca un estudiante por el código de su carné (sin
+// distinguir mayúsculas). Retorna sql.ErrNoRows si ninguno lo tiene.
+func (r *Repositorio) ObtenerEstudiantePorCodigo(codigo string) (models.Estudiante, error) {
+	return escanearEstudiante(r.db.QueryRow(selectEstudiantes+`
+		WHERE e.codigo = ? COLLATE NOCASE
+	`, codigo))
 }
@@ -0,0 +1,30 @@
+package repositories
+
+import "fmt"
+
+// ReclamarClaveIdempotencia registra la clave de una solicitud. Retorna false si la
+// clave ya estaba registrada, es decir, si la solicitud es una repetición.
+func (r *Repositorio) ReclamarClaveIdempotencia(clave, ruta string) (bool, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO claves_idempotencia (clave, ruta) VALUES (?, ?)
+		ON CONFLICT (clave) DO NOTHING
+	`, clave, ruta)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// LiberarClaveIdempotencia borra la clave de una solicitud que falló, para que
+// un reintento con la misma clave vuelva a procesarse
+func (r *Repositorio) LiberarClaveIdempotencia(clave string) error {
+	_, err := r.db.Exec(`DELETE FROM claves_idempotencia WHERE clave = ?`, clave)
+	return err
+}
+
+// PurgarClavesIdempotencia borra las claves con más de dias días de antigüedad
+func (r *Repositorio) PurgarClavesIdempotencia(dias int) error {
+	_, err := r.db.Exec(`DELETE FROM claves_idempotencia WHERE creado_en < datetime('now', ?)`, fmt.Sprintf("-%d days", dias))
+	return err
+}
@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// registrarMovimientoTx inserta un movimiento y aplica su cantidad al stock del producto
+func registrarMovimientoTx(tx *sql.Tx, idProducto int, tipo string, cantidad int, fecha time.Time, nota string) error {
+	if _, err := tx.Exec(`
+		INSERT INTO movimientos_stock (id_producto, tipo, cantidad, fecha, nota)
+		VALUES (?, ?, ?, ?, ?)
+	`, idProducto, tipo, cantidad, fecha.Format("2006-01-02"), nota); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE productos SET stock_actual = stock_actual + ? WHERE id_producto = ?
+	`, cantidad, idProducto)
+	if err != nil {
+		return err
+	}
+	// Sin producto no hay stock que mover: la transacción descarta el movimiento
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+	return err
+}
+
+// descontarStockVentaTx registra la venta derivada de un cambio de consumo.
+// delta es la variación de cantidad consumida; solo aplica a productos con inventario.
+// Si el producto es un combo se descuentan sus componentes en lugar del combo.
+func descontarStockVentaTx(tx *sql.Tx, idProducto, delta int, fecha time.Time) error {
+	componentes, err := componentesConStockTx(tx, idProducto)
+	if err != nil {
+		return err
+	}
+	if componentes != nil {
+		nota := fmt.Sprintf("Combo #%d", idProducto)
+		for _, c := range componentes {
+			if err := registrarMovimientoTx(tx, c.IdComponente, models.MovimientoVenta, -delta*c.Cantidad, fecha, nota); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var controlaStock bool
+	err = tx.QueryRow(`
+		SELECT controla_stock FROM productos WHERE id_producto = ?
+	`, idProducto).Scan(&controlaStock)
+	if err != nil || !controlaStock {
+		return err
+	}
+	return registrarMovimientoTx(tx, idProducto, models.MovimientoVenta, -delta, fecha, "")
+}
+
+// componentesConStockTx retorna los componentes con inventario de un combo.
+// Retorna nil si el producto no es combo y un slice vacío si es combo sin
+// componentes que controlen stock.
+func componentesConStockTx(tx *sql.Tx, idCombo int) ([]models.ComponenteCombo, error) {
+	rows, err := tx.Query(`
+		SELECT cp.id_componente, cp.cantidad, p.controla_stock
+		FROM componentes_producto cp
+		JOIN productos p ON cp.id_componente = p.id_producto
+		WHERE cp.id_combo = ?
+	`, idCombo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var componentes []models.ComponenteCombo
+	for rows.Next() {
+		c := models.ComponenteCombo{IdCombo: idCombo}
+		var controlaStock bool
+		if err := rows.Scan(&c.IdComponente, &c.Cantidad, &controlaStock); err != nil {
+			return nil, err
+		}
+		if componentes == nil {
+			componentes = []models.ComponenteCombo{}
+		}
+		if controlaStock {
+			componentes = append(componentes, c)
+		}
+	}
+	return componentes, rows.Err()
+}
+
+// RegistrarMovimientoStock guarda una entrada, merma o ajuste y actualiza el
+// stock. Retorna sql.ErrNoRows si el producto no existe.
+func (r *Repositorio) RegistrarMovimientoStock(idProducto int, tipo string, cantidad int, fecha time.Time, nota string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := registrarMovimientoTx(tx, idProducto, tipo, cantidad, fecha, nota); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AjustarStock fija el stock de un producto al conteo físico indicado,
+// registrando la diferencia como movimiento de ajuste. Retorna sql.ErrNoRows si
+// el producto no existe.
+func (r *Repositorio) AjustarStock(idProducto, conteo int, fecha time.Time, nota string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stockActual int
+	if err := tx.QueryRow(`
+		SELECT stock_actual FROM productos WHERE id_producto = ?
+	`, idProducto).Scan(&stockActual); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return fmt.Errorf("producto %d: %v", idProducto, err)
+	}
+
+	if diferencia := conteo - stockActual; diferencia != 0 {
+		if err := registrarMovimientoTx(tx, idProducto, models.MovimientoAjuste, diferencia, fecha, nota); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ConfigurarStockProducto activa o desactiva el control de inventario y fija el
+// mínimo. Retorna sql.ErrNoRows si el producto no existe.
+func (r *Repositorio) ConfigurarStockProducto(idProducto int, controlaStock bool, stockMinimo int) error {
+	controla := 0
+	if controlaStock {
+		controla = 1
+	}
+	res, err := r.db.Exec(`
+		UPDATE productos SET controla_stock = ?, stock_minimo = ? WHERE id_producto = ?
+	`, controla, stockMinimo, idProducto)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+	return err
+}
+
+// ObtenerProductosStockBajo retorna los productos activos con inventario en o bajo su mínimo
+func (r *Repositorio) ObtenerProductosStockBajo() ([]models.Producto, error) {
+	rows, err := r.db.Query(selectProductos() + `
+		WHERE p.esta_activo = 1 AND p.controla_stock = 1 AND p.stock_actual <= p.stock_minimo
+		ORDER BY p.stock_actual, p.nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return escanearProductos(rows)
+}
+
+// ObtenerMovimientosRecientes retorna los últimos movimientos de stock de todos los productos
+func (r *Repositorio) ObtenerMovimientosRecientes(limite int) ([]models.MovimientoStock, error) {
+	rows, err := r.db.Query(`
+		SELECT m.id_movimiento, m.id_producto, p.nombre, m.tipo, m.cantidad, m.fecha, m.nota
+		FROM movimientos_stock m
+		JOIN productos p ON m.id_producto = p.id_producto
+		ORDER BY m.id_movimiento DESC
+		LIMIT ?
+	`, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movimientos []models.MovimientoStock
+	for rows.Next() {
+		var m models.MovimientoStock
+		if err := rows.Scan(&m.IdMovimiento, &m.IdProducto, &m.NombreProducto, &m.Tipo, &m.Cantidad, &m.Fecha, &m.Nota); err != nil {
+			return nil, err
+		}
+		movimientos = append(movimientos, m)
+	}
+	return movimientos, rows.Err()
+}
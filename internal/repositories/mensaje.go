@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+)
+
+// CambiarRecepcionMensajes da de baja (false) o reactiva (true) los mensajes de
+// WhatsApp/SMS de un apoderado. La baja cancela también los que seguían en cola.
+func (r *Repositorio) CambiarRecepcionMensajes(idApoderado int, recibe bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE apoderados SET recibe_mensajes = ? WHERE id_apoderado = ?`, recibe, idApoderado); err != nil {
+		return err
+	}
+	if !recibe {
+		if _, err := tx.Exec(`
+			UPDATE mensajes SET estado = 'fallido', ultimo_error = 'Apoderado dado de baja'
+			WHERE id_apoderado = ? AND estado = 'pendiente'
+		`, idApoderado); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// EncolarMensaje agrega un mensaje a la bandeja de salida. Retorna false si el
+// apoderado ya tenía un mensaje del mismo tipo con esa referencia.
+func (r *Repositorio) EncolarMensaje(m models.Mensaje) (bool, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO mensajes (tipo, id_apoderado, id_estudiante, referencia, telefono, texto)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tipo, id_apoderado, referencia) DO NOTHING
+	`, m.Tipo, nuloSiCero(m.IdApoderado), nuloSiCero(m.IdEstudiante), nuloSiVacio(m.Referencia), m.Telefono, m.Texto)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+const selectMensajes = `
+	SELECT id_mensaje, tipo, COALESCE(id_apoderado, 0), COALESCE(id_estudiante, 0),
+	       COALESCE(referencia, ''), telefono, texto, proveedor, estado, intentos,
+	       proximo_intento, COALESCE(ultimo_status, 0), COALESCE(ultimo_error, ''),
+	       COALESCE(id_externo, ''), creado_en, enviado_en
+	FROM mensajes`
+
+func escanearMensajes(rows *sql.Rows) ([]models.Mensaje, error) {
+	defer rows.Close()
+
+	var mensajes []models.Mensaje
+	for rows.Next() {
+		var m models.Mensaje
+		var enviado sql.NullTime
+		if err := rows.Scan(&m.IdMensaje, &m.Tipo, &m.IdApoderado, &m.IdEstudiante, &m.Referencia,
+			&m.Telefono, &m.Texto, &m.Proveedor, &m.Estado, &m.Intentos, &m.ProximoIntento,
+			&m.UltimoStatus, &m.UltimoError, &m.IdExterno, &m.CreadoEn, &enviado); err != nil {
+			return nil, err
+		}
+		if enviado.Valid {
+			m.EnviadoEn = &enviado.Time
+		}
+		mensajes = append(mensajes, m)
+	}
+	return mensajes, rows.Err()
+}
+
+// ObtenerMensajesPendientes retorna los mensajes cuyo próximo intento ya venció
+func (r *Repositorio) ObtenerMensajesPendientes(limite int) ([]models.Mensaje, error) {
+	rows, err := r.db.Query(selectMensajes+`
+		WHERE estado = 'pendiente' AND proximo_intento <= CURRENT_TIMESTAMP
+		ORDER BY proximo_intento, id_mensaje
+		LIMIT ?
+	`, limite)
+	if err != nil {
+		return nil, err
+	}
+	return escanearMensajes(rows)
+}
+
+// ObtenerMensajes retorna los últimos mensajes para el registro, opcionalmente por estado
+func (r *Repositorio) ObtenerMensajes(estado string, limite int) ([]models.Mensaje, error) {
+	rows, err := r.db.Query(selectMensajes+`
+		WHERE ? = '' OR estado = ?
+		ORDER BY id_mensaje DESC
+		LIMIT ?
+	`, estado, estado, limite)
+	if err != nil {
+		return nil, err
+	}
+	return escanearMensajes(rows)
+}
+
+// MarcarMensajeEnviado registra que el proveedor aceptó el mensaje
+func (r *Repositorio) MarcarMensajeEnviado(idMensaje int, proveedor string, status int, idExterno string) error {
+	_, err := r.db.Exec(`
+		UPDATE mensajes
+		SET estado = 'enviado', intentos = intentos + 1, proveedor = ?, ultimo_status = ?,
+		    ultimo_error = NULL, id_externo = ?, enviado_en = CURRENT_TIMESTAMP
+		WHERE id_mensaje = ?
+	`, proveedor, nuloSiCero(status), nuloSiVacio(idExterno), idMensaje)
+	return err
+}
+
+// MarcarMensajeFallido registra un intento fallido. Si esperaSegundos es 0 el
+// mensaje queda como fallido; si no, se reprograma para dentro de esa espera.
+func (r *Repositorio) MarcarMensajeFallido(idMensaje int, proveedor string, status int, mensaje string, esperaSegundos int) error {
+	estado := models.MensajePendiente
+	if esperaSegundos == 0 {
+		estado = models.MensajeFallido
+	}
+	_, err := r.db.Exec(`
+		UPDATE mensajes
+		SET estado = ?, intentos = intentos + 1, proveedor = ?, ultimo_status = ?,
+		    ultimo_error = ?, proximo_intento = datetime('now', ?)
+		WHERE id_mensaje = ?
+	`, estado, proveedor, nuloSiCero(status), mensaje, fmt.Sprintf("+%d seconds", esperaSegundos), idMensaje)
+	return err
+}
+
+// ReintentarMensaje vuelve a poner en cola un mensaje fallido. No reintenta los
+// de apoderados eliminados o dados de baja; retorna false en ese caso.
+func (r *Repositorio) ReintentarMensaje(idMensaje int) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE mensajes
+		SET estado = 'pendiente', intentos = 0, proximo_intento = CURRENT_TIMESTAMP
+		WHERE id_mensaje = ? AND estado = 'fallido'
+		  AND (tipo = 'prueba' OR id_apoderado IN (
+		      SELECT id_apoderado FROM apoderados WHERE recibe_mensajes = 1))
+	`, idMensaje)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
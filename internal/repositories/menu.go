@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"kiosco/internal/models"
+	"time"
+)
+
+// ObtenerCategorias retorna las categorías de productos en su orden de presentación
+func (r *Repositorio) ObtenerCategorias() ([]models.Categoria, error) {
+	rows, err := r.db.Query(`
+		SELECT id_categoria, nombre, orden
+		FROM categorias
+		ORDER BY orden, nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categorias []models.Categoria
+	for rows.Next() {
+		var c models.Categoria
+		if err := rows.Scan(&c.IdCategoria, &c.Nombre, &c.Orden); err != nil {
+			return nil, err
+		}
+		categorias = append(categorias, c)
+	}
+	return categorias, rows.Err()
+}
+
+// InsertarCategoria agrega una categoría al final del orden actual
+func (r *Repositorio) InsertarCategoria(nombre string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO categorias (nombre, orden)
+		VALUES (?, (SELECT COALESCE(MAX(orden), 0) + 1 FROM categorias))
+	`, nombre)
+	return err
+}
+
+// ObtenerMenuDia retorna los ids de productos del menú de una fecha (vacío = sin menú)
+func (r *Repositorio) ObtenerMenuDia(fecha time.Time) (map[int]bool, error) {
+	rows, err := r.db.Query(`
+		SELECT id_producto FROM menu_dia WHERE fecha = ?
+	`, fecha.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	menu := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		menu[id] = true
+	}
+	return menu, rows.Err()
+}
+
+// GuardarMenuDia reemplaza el menú de una fecha; sin productos se ofrecen todos los activos
+func (r *Repositorio) GuardarMenuDia(fecha time.Time, idsProducto []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	fechaStr := fecha.Format("2006-01-02")
+	if _, err := tx.Exec(`DELETE FROM menu_dia WHERE fecha = ?`, fechaStr); err != nil {
+		return err
+	}
+	for _, id := range idsProducto {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO menu_dia (fecha, id_producto) VALUES (?, ?)
+		`, fechaStr, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ObtenerProductosParaFecha retorna los productos activos del menú de la fecha,
+// o todos los activos si no se definió menú para ese día
+func (r *Repositorio) ObtenerProductosParaFecha(fecha time.Time) ([]models.Producto, error) {
+	rows, err := r.db.Query(selectProductos()+`
+		WHERE p.esta_activo = 1
+		  AND (NOT EXISTS (SELECT 1 FROM menu_dia WHERE fecha = ?1)
+		       OR p.id_producto IN (SELECT id_producto FROM menu_dia WHERE fecha = ?1))
+		ORDER BY `+ordenProductos, fecha.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return escanearProductos(rows)
+}
@@ -25,14 +25,19 @@ func (r *Repositorio) ObtenerPagosSemana(idEstudiante int, fechaInicio, fechaFin
 	return 0, nil
 }
 
-// RegistrarPago inserta un nuevo pago
-func (r *Repositorio) RegistrarPago(pago models.Pago) error {
+// RegistrarPago inserta un nuevo pago y retorna su ID. Un pago en efectivo
+// queda en la caja abierta de quien lo registró, si tiene una.
+func (r *Repositorio) RegistrarPago(pago models.Pago) (int, error) {
 	fechaStr := pago.FechaPago.Format("2006-01-02")
-	_, err := r.db.Exec(`
-		INSERT INTO pagos (id_estudiante, monto, fecha_pago)
-		VALUES (?, ?, ?)
-	`, pago.IdEstudiante, pago.Monto, fechaStr)
-	return err
+	res, err := r.db.Exec(`
+		INSERT INTO pagos (id_estudiante, monto, fecha_pago, metodo, id_usuario, id_sesion_caja)
+		VALUES (?1, ?2, ?3, ?4, ?5, CASE WHEN ?4 = 'efectivo' THEN `+sesionCajaAbiertaSQL("?5")+` END)
+	`, pago.IdEstudiante, pago.Monto, fechaStr, pago.Metodo, nuloSiCero(pago.IdUsuario))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
 }
 
 // ObtenerPagosSemanaDetalle retorna todos los pagos de un estudiante en una semana
@@ -41,7 +46,7 @@ func (r *Repositorio) ObtenerPagosSemanaDetalle(idEstudiante int, fechaInicio, f
 	fechaFinStr := fechaFin.Format("2006-01-02")
 
 	rows, err := r.db.Query(`
-		SELECT id_pago, id_estudiante, monto, fecha_pago
+		SELECT id_pago, id_estudiante, monto, fecha_pago, metodo, COALESCE(id_usuario, 0)
 		FROM pagos
 		WHERE id_estudiante = ? AND fecha_pago BETWEEN ? AND ?
 		ORDER BY fecha_pago DESC
@@ -54,7 +59,7 @@ func (r *Repositorio) ObtenerPagosSemanaDetalle(idEstudiante int, fechaInicio, f
 	var pagos []models.Pago
 	for rows.Next() {
 		var p models.Pago
-		if err := rows.Scan(&p.IdPago, &p.IdEstudiante, &p.Monto, &p.FechaPago); err != nil {
+		if err := rows.Scan(&p.IdPago, &p.IdEstudiante, &p.Monto, &p.FechaPago, &p.Metodo, &p.IdUsuario); err != nil {
 			return nil, err
 		}
 		pagos = append(pagos, p)
@@ -63,6 +68,41 @@ func (r *Repositorio) ObtenerPagosSemanaDetalle(idEstudiante int, fechaInicio, f
 	return pagos, rows.Err()
 }
 
+// ObtenerPagosRango retorna los pagos de todos los estudiantes entre dos fechas
+func (r *Repositorio) ObtenerPagosRango(fechaInicio, fechaFin time.Time) ([]models.Pago, error) {
+	rows, err := r.db.Query(`
+		SELECT id_pago, id_estudiante, monto, fecha_pago, metodo, COALESCE(id_usuario, 0)
+		FROM pagos
+		WHERE fecha_pago BETWEEN ? AND ?
+		ORDER BY fecha_pago, id_pago
+	`, fechaInicio.Format("2006-01-02"), fechaFin.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pagos []models.Pago
+	for rows.Next() {
+		var p models.Pago
+		if err := rows.Scan(&p.IdPago, &p.IdEstudiante, &p.Monto, &p.FechaPago, &p.Metodo, &p.IdUsuario); err != nil {
+			return nil, err
+		}
+		pagos = append(pagos, p)
+	}
+
+	return pagos, rows.Err()
+}
+
+// ObtenerPagoPorId retorna un pago por su ID
+func (r *Repositorio) ObtenerPagoPorId(idPago int) (models.Pago, error) {
+	var p models.Pago
+	err := r.db.QueryRow(`
+		SELECT id_pago, id_estudiante, monto, fecha_pago, metodo, COALESCE(id_usuario, 0)
+		FROM pagos WHERE id_pago = ?
+	`, idPago).Scan(&p.IdPago, &p.IdEstudiante, &p.Monto, &p.FechaPago, &p.Metodo, &p.IdUsuario)
+	return p, err
+}
+
 // EliminarPago elimina un pago específico
 func (r *Repositorio) EliminarPago(idPago int) error {
 	_, err := r.db.Exec(`DELETE FROM pagos WHERE id_pago = ?`, idPago)
@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"database/sql"
+	"kiosco/internal/models"
+	"time"
+)
+
+// ObtenerPlanes retorna todos los planes, los activos primero
+func (r *Repositorio) ObtenerPlanes() ([]models.Plan, error) {
+	rows, err := r.db.Query(`
+		SELECT pl.id_plan, pl.nombre, pl.monto, pl.periodo,
+		       COALESCE(pl.id_categoria, 0), COALESCE(cat.nombre, ''), pl.esta_activo
+		FROM planes pl
+		LEFT JOIN categorias cat ON pl.id_categoria = cat.id_categoria
+		ORDER BY pl.esta_activo DESC, pl.nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var planes []models.Plan
+	for rows.Next() {
+		var p models.Plan
+		if err := rows.Scan(&p.IdPlan, &p.Nombre, &p.Monto, &p.Periodo,
+			&p.IdCategoria, &p.NombreCategoria, &p.EstaActivo); err != nil {
+			return nil, err
+		}
+		planes = append(planes, p)
+	}
+	return planes, rows.Err()
+}
+
+// InsertarPlan agrega un plan activo
+func (r *Repositorio) InsertarPlan(plan models.Plan) error {
+	_, err := r.db.Exec(`
+		INSERT INTO planes (nombre, monto, periodo, id_categoria) VALUES (?, ?, ?, ?)
+	`, plan.Nombre, plan.Monto, plan.Periodo, nuloSiCero(plan.IdCategoria))
+	return err
+}
+
+// selectSuscripciones es la consulta base que espera escanearSuscripciones
+const selectSuscripciones = `
+	SELECT s.id_suscripcion, s.id_estudiante, e.apellidos || ', ' || e.nombres,
+	       s.fecha_inicio, s.fecha_fin,
+	       pl.id_plan, pl.nombre, pl.monto, pl.periodo,
+	       COALESCE(pl.id_categoria, 0), COALESCE(cat.nombre, ''), pl.esta_activo
+	FROM suscripciones_plan s
+	JOIN planes pl ON s.id_plan = pl.id_plan
+	JOIN estudiantes e ON s.id_estudiante = e.id_estudiante
+	LEFT JOIN categorias cat ON pl.id_categoria = cat.id_categoria`
+
+func escanearSuscripciones(rows *sql.Rows) ([]models.Suscripcion, error) {
+	defer rows.Close()
+
+	var suscripciones []models.Suscripcion
+	for rows.Next() {
+		var s models.Suscripcion
+		var fin sql.NullTime
+		if err := rows.Scan(&s.IdSuscripcion, &s.IdEstudiante, &s.NombreEstudiante,
+			&s.FechaInicio, &fin,
+			&s.Plan.IdPlan, &s.Plan.Nombre, &s.Plan.Monto, &s.Plan.Periodo,
+			&s.Plan.IdCategoria, &s.Plan.NombreCategoria, &s.Plan.EstaActivo); err != nil {
+			return nil, err
+		}
+		if fin.Valid {
+			s.FechaFin = &fin.Time
+		}
+		suscripciones = append(suscripciones, s)
+	}
+	return suscripciones, rows.Err()
+}
+
+// ObtenerSuscripciones retorna todas las suscripciones, las vigentes primero
+func (r *Repositorio) ObtenerSuscripciones() ([]models.Suscripcion, error) {
+	rows, err := r.db.Query(selectSuscripciones + `
+		ORDER BY s.fecha_fin IS NOT NULL, e.apellidos, e.nombres
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return escanearSuscripciones(rows)
+}
+
+// ObtenerSuscripcionesEstudiante retorna las suscripciones de un estudiante vigentes en una fecha
+func (r *Repositorio) ObtenerSuscripcionesEstudiante(idEstudiante int, fecha time.Time) ([]models.Suscripcion, error) {
+	rows, err := r.db.Query(selectSuscripciones+`
+		WHERE s.id_estudiante = ?1
+		  AND s.fecha_inicio <= ?2
+		  AND (s.fecha_fin IS NULL OR s.fecha_fin >= ?2)
+	`, idEstudiante, fecha.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return escanearSuscripciones(rows)
+}
+
+// InsertarSuscripcion inscribe a un estudiante en un plan desde una fecha
+func (r *Repositorio) InsertarSuscripcion(idPlan, idEstudiante int, fechaInicio time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO suscripciones_plan (id_plan, id_estudiante, fecha_inicio) VALUES (?, ?, ?)
+	`, idPlan, idEstudiante, fechaInicio.Format("2006-01-02"))
+	return err
+}
+
+// FinalizarSuscripcion cierra una suscripción vigente en la fecha indicada
+func (r *Repositorio) FinalizarSuscripcion(idSuscripcion int, fechaFin time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE suscripciones_plan SET fecha_fin = ?
+		WHERE id_suscripcion = ? AND fecha_fin IS NULL
+	`, fechaFin.Format("2006-01-02"), idSuscripcion)
+	return err
+}
+
+// InsertarCargoPlan registra el cargo de un período; si ya existe no hace nada
+func (r *Repositorio) InsertarCargoPlan(cargo models.CargoPlan) error {
+	_, err := r.db.Exec(`
+		INSERT OR IGNORE INTO cargos_plan (id_suscripcion, id_estudiante, periodo_inicio, fecha_cargo, monto)
+		VALUES (?, ?, ?, ?, ?)
+	`, cargo.IdSuscripcion, cargo.IdEstudiante, cargo.PeriodoInicio.Format("2006-01-02"),
+		cargo.FechaCargo.Format("2006-01-02"), cargo.Monto)
+	return err
+}
+
+// ObtenerCargosPlanSemana retorna los cargos de planes con fecha en el rango indicado
+func (r *Repositorio) ObtenerCargosPlanSemana(fechaInicio, fechaFin time.Time) ([]models.CargoPlan, error) {
+	rows, err := r.db.Query(`
+		SELECT c.id_cargo, c.id_suscripcion, c.id_estudiante, pl.nombre,
+		       c.periodo_inicio, c.fecha_cargo, c.monto
+		FROM cargos_plan c
+		JOIN suscripciones_plan s ON c.id_suscripcion = s.id_suscripcion
+		JOIN planes pl ON s.id_plan = pl.id_plan
+		WHERE c.fecha_cargo BETWEEN ? AND ?
+		ORDER BY c.fecha_cargo
+	`, fechaInicio.Format("2006-01-02"), fechaFin.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cargos []models.CargoPlan
+	for rows.Next() {
+		var c models.CargoPlan
+		if err := rows.Scan(&c.IdCargo, &c.IdSuscripcion, &c.IdEstudiante, &c.NombrePlan,
+			&c.PeriodoInicio, &c.FechaCargo, &c.Monto); err != nil {
+			return nil, err
+		}
+		cargos = append(cargos, c)
+	}
+	return cargos, rows.Err()
+}
+
+// ObtenerTotalCargosPlan suma los cargos de planes en un rango de fechas
+func (r *Repositorio) ObtenerTotalCargosPlan(desde, hasta time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(monto), 0) FROM cargos_plan WHERE fecha_cargo BETWEEN ? AND ?
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02")).Scan(&total)
+	return total, err
+}
+
+// ObtenerUltimosPeriodosCargados retorna, por suscripción, el inicio del último período cobrado
+func (r *Repositorio) ObtenerUltimosPeriodosCargados() (map[int]time.Time, error) {
+	rows, err := r.db.Query(`
+		SELECT id_suscripcion, MAX(periodo_inicio) FROM cargos_plan GROUP BY id_suscripcion
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ultimos := make(map[int]time.Time)
+	for rows.Next() {
+		var id int
+		var periodo string
+		if err := rows.Scan(&id, &periodo); err != nil {
+			return nil, err
+		}
+		t, err := time.Parse("2006-01-02", periodo[:10])
+		if err != nil {
+			return nil, err
+		}
+		ultimos[id] = t
+	}
+	return ultimos, rows.Err()
+}
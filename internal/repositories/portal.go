@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"kiosco/internal/models"
+	"time"
+)
+
+// GuardarAccesoPortal reemplaza el hash del enlace al portal de un apoderado.
+// Con hash "" el apoderado pierde el acceso. Retorna false si el apoderado no existe.
+func (r *Repositorio) GuardarAccesoPortal(idApoderado int, hash string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE apoderados
+		SET hash_portal = ?1,
+		    portal_generado_en = CASE WHEN ?1 IS NULL THEN NULL ELSE CURRENT_TIMESTAMP END
+		WHERE id_apoderado = ?2
+	`, nuloSiVacio(hash), idApoderado)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ObtenerIdApoderadoPorHashPortal busca al apoderado dueño de un enlace al portal
+func (r *Repositorio) ObtenerIdApoderadoPorHashPortal(hash string) (int, error) {
+	var id int
+	err := r.db.QueryRow(`SELECT id_apoderado FROM apoderados WHERE hash_portal = ?`, hash).Scan(&id)
+	return id, err
+}
+
+// ObtenerApoderadoPortal retorna el apoderado si su acceso al portal sigue vigente
+// (sql.ErrNoRows si fue revocado o eliminado)
+func (r *Repositorio) ObtenerApoderadoPortal(idApoderado int) (models.Apoderado, error) {
+	var a models.Apoderado
+	var generado time.Time
+	err := r.db.QueryRow(`
+		SELECT id_apoderado, id_estudiante, nombre, portal_generado_en
+		FROM apoderados
+		WHERE id_apoderado = ? AND hash_portal IS NOT NULL
+	`, idApoderado).Scan(&a.IdApoderado, &a.IdEstudiante, &a.Nombre, &generado)
+	a.PortalGeneradoEn = &generado
+	return a, err
+}
+
+// ObtenerEstudiantesFamilia retorna los estudiantes de la familia de un apoderado:
+// el suyo y los de cualquier apoderado registrado con el mismo correo o teléfono.
+func (r *Repositorio) ObtenerEstudiantesFamilia(idApoderado int) ([]models.Estudiante, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT e.id_estudiante, e.nombres, e.apellidos, e.id_grado, e.esta_activo,
+		       COALESCE(g.anio_grado || ' ' || g.nivel_grado, '')
+		FROM apoderados f
+		JOIN apoderados a ON a.id_apoderado = f.id_apoderado
+		                  OR (f.email IS NOT NULL AND a.email = f.email)
+		                  OR (f.telefono IS NOT NULL AND a.telefono = f.telefono)
+		JOIN estudiantes e ON a.id_estudiante = e.id_estudiante
+		LEFT JOIN grados g ON e.id_grado = g.id_grado
+		WHERE f.id_apoderado = ?
+		ORDER BY e.apellidos, e.nombres
+	`, idApoderado)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var estudiantes []models.Estudiante
+	for rows.Next() {
+		var e models.Estudiante
+		if err := rows.Scan(&e.IdEstudiante, &e.Nombres, &e.Apellidos, &e.IdGrado, &e.EstaActivo, &e.NombreGrado); err != nil {
+			return nil, err
+		}
+		estudiantes = append(estudiantes, e)
+	}
+	return estudiantes, rows.Err()
+}
+
+// ObtenerPagosRecientes retorna los últimos pagos de un estudiante, del más reciente al más antiguo
+func (r *Repositorio) ObtenerPagosRecientes(idEstudiante, limite int) ([]models.Pago, error) {
+	rows, err := r.db.Query(`
+		SELECT id_pago, id_estudiante, monto, fecha_pago, metodo, COALESCE(id_usuario, 0)
+		FROM pagos
+		WHERE id_estudiante = ?
+		ORDER BY fecha_pago DESC, id_pago DESC
+		LIMIT ?
+	`, idEstudiante, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pagos []models.Pago
+	for rows.Next() {
+		var p models.Pago
+		if err := rows.Scan(&p.IdPago, &p.IdEstudiante, &p.Monto, &p.FechaPago, &p.Metodo, &p.IdUsuario); err != nil {
+			return nil, err
+		}
+		pagos = append(pagos, p)
+	}
+	return pagos, rows.Err()
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"time"
+)
+
+// ObtenerPrecioVigente retorna el precio de un producto vigente en la fecha indicada
+func (r *Repositorio) ObtenerPrecioVigente(idProducto int, fecha time.Time) (float64, error) {
+	var precio float64
+	err := r.db.QueryRow(`
+		SELECT `+precioVigenteSQL("?")+`
+		FROM productos p
+		WHERE p.id_producto = ?
+	`, fecha.Format("2006-01-02"), idProducto).Scan(&precio)
+	return precio, err
+}
+
+// ObtenerPreciosVigentes retorna el precio de cada producto vigente en la fecha indicada
+func (r *Repositorio) ObtenerPreciosVigentes(fecha time.Time) (map[int]float64, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id_producto, `+precioVigenteSQL("?")+`
+		FROM productos p
+	`, fecha.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	precios := make(map[int]float64)
+	for rows.Next() {
+		var id int
+		var precio float64
+		if err := rows.Scan(&id, &precio); err != nil {
+			return nil, err
+		}
+		precios[id] = precio
+	}
+	return precios, rows.Err()
+}
+
+// ProgramarPrecio registra un precio vigente desde una fecha; si ya había un
+// cambio para esa misma fecha lo reemplaza
+func (r *Repositorio) ProgramarPrecio(idProducto int, precio float64, vigenteDesde time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO precios_producto (id_producto, precio_unitario, vigente_desde)
+		VALUES (?, ?, ?)
+		ON CONFLICT (id_producto, vigente_desde) DO UPDATE SET
+			precio_unitario = excluded.precio_unitario,
+			creado_en = CURRENT_TIMESTAMP
+	`, idProducto, precio, vigenteDesde.Format("2006-01-02"))
+	return err
+}
+
+// EliminarPrecioProgramado cancela un cambio de precio que aún no entra en vigencia.
+// Retorna false si el cambio no existe o ya está vigente.
+func (r *Repositorio) EliminarPrecioProgramado(idPrecio int) (bool, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM precios_producto
+		WHERE id_precio = ? AND vigente_desde > ?
+	`, idPrecio, utils.FormatearFechaCompleta(utils.Hoy()))
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ObtenerHistorialPrecios retorna todos los cambios de precio, los más recientes primero,
+// con el precio anterior de cada uno (el precio base para el primer cambio)
+func (r *Repositorio) ObtenerHistorialPrecios() ([]models.CambioPrecio, error) {
+	rows, err := r.db.Query(`
+		SELECT pp.id_precio, pp.id_producto, p.nombre, pp.precio_unitario,
+		       LAG(pp.precio_unitario, 1, p.precio_unitario)
+		           OVER (PARTITION BY pp.id_producto ORDER BY pp.vigente_desde),
+		       pp.vigente_desde,
+		       pp.vigente_desde > ?
+		FROM precios_producto pp
+		JOIN productos p ON pp.id_producto = p.id_producto
+		ORDER BY pp.vigente_desde DESC, p.nombre
+	`, utils.FormatearFechaCompleta(utils.Hoy()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cambios []models.CambioPrecio
+	for rows.Next() {
+		var c models.CambioPrecio
+		if err := rows.Scan(&c.IdPrecio, &c.IdProducto, &c.NombreProducto, &c.PrecioUnitario,
+			&c.PrecioAnterior, &c.VigenteDesde, &c.Programado); err != nil {
+			return nil, err
+		}
+		cambios = append(cambios, c)
+	}
+	return cambios, rows.Err()
+}
@@ -1,23 +1,63 @@
 package repositories
 
-import "kiosco/internal/models"
+import (
+	"database/sql"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+)
 
-// ObtenerTodosProductos retorna todos los productos (activos e inactivos)
-func (r *Repositorio) ObtenerTodosProductos() ([]models.Producto, error) {
-	rows, err := r.db.Query(`
-		SELECT id_producto, nombre, precio_unitario, esta_activo
-		FROM productos
-		ORDER BY esta_activo DESC, id_producto
-	`)
-	if err != nil {
-		return nil, err
+// precioVigenteSQL arma la expresión SQL del precio del producto p vigente en fecha
+// (otra expresión SQL): el último cambio con vigente_desde <= fecha o, si no hay, el precio base
+func precioVigenteSQL(fecha string) string {
+	return `COALESCE((
+		SELECT pp.precio_unitario FROM precios_producto pp
+		WHERE pp.id_producto = p.id_producto AND pp.vigente_desde <= ` + fecha + `
+		ORDER BY pp.vigente_desde DESC LIMIT 1
+	), p.precio_unitario)`
+}
+
+// selectProductos retorna la consulta base (alias p y cat) que espera escanearProducto.
+// El precio es el vigente hoy en la zona horaria del colegio, considerando cambios programados.
+func selectProductos() string {
+	hoy := "'" + utils.FormatearFechaCompleta(utils.Hoy()) + "'"
+	return `
+	SELECT p.id_producto, p.nombre, ` + precioVigenteSQL(hoy) + `,
+	       p.costo_unitario, p.esta_activo,
+	       p.controla_stock, p.stock_actual, p.stock_minimo,
+	       COALESCE(p.id_categoria, 0), COALESCE(cat.nombre, ''), p.orden,
+	       EXISTS (SELECT 1 FROM componentes_producto cp WHERE cp.id_combo = p.id_producto)
+	FROM productos p
+	LEFT JOIN categorias cat ON p.id_categoria = cat.id_categoria`
+}
+
+// ordenProductos ordena por categoría, orden manual y nombre; sin categoría al final
+const ordenProductos = `cat.id_categoria IS NULL, cat.orden, p.orden, p.nombre`
+
+// escanearProducto lee una fila con las columnas de selectProductos
+func escanearProducto(row interface{ Scan(...any) error }) (models.Producto, error) {
+	var p models.Producto
+	err := row.Scan(&p.IdProducto, &p.Nombre, &p.PrecioUnitario, &p.CostoUnitario, &p.EstaActivo,
+		&p.ControlaStock, &p.StockActual, &p.StockMinimo,
+		&p.IdCategoria, &p.NombreCategoria, &p.Orden, &p.EsCombo)
+	return p, err
+}
+
+// nuloSiCero convierte un id 0 en NULL para columnas de clave foránea opcionales
+func nuloSiCero(id int) interface{} {
+	if id == 0 {
+		return nil
 	}
+	return id
+}
+
+// escanearProductos recorre el resultado completo de una consulta de productos
+func escanearProductos(rows *sql.Rows) ([]models.Producto, error) {
 	defer rows.Close()
 
 	var productos []models.Producto
 	for rows.Next() {
-		var p models.Producto
-		if err := rows.Scan(&p.IdProducto, &p.Nombre, &p.PrecioUnitario, &p.EstaActivo); err != nil {
+		p, err := escanearProducto(rows)
+		if err != nil {
 			return nil, err
 		}
 		productos = append(productos, p)
@@ -25,29 +65,43 @@ func (r *Repositorio) ObtenerTodosProductos() ([]models.Producto, error) {
 	return productos, rows.Err()
 }
 
-// InsertarProducto agrega un nuevo producto activo
-func (r *Repositorio) InsertarProducto(nombre string, precio float64) (models.Producto, error) {
+// ObtenerTodosProductos retorna todos los productos (activos e inactivos)
+func (r *Repositorio) ObtenerTodosProductos() ([]models.Producto, error) {
+	rows, err := r.db.Query(selectProductos() + `
+		ORDER BY p.esta_activo DESC, ` + ordenProductos)
+	if err != nil {
+		return nil, err
+	}
+	return escanearProductos(rows)
+}
+
+// InsertarProducto agrega un nuevo producto activo y lo retorna con su categoría
+func (r *Repositorio) InsertarProducto(producto models.Producto) (models.Producto, error) {
 	result, err := r.db.Exec(`
-		INSERT INTO productos (nombre, precio_unitario, esta_activo)
-		VALUES (?, ?, 1)
-	`, nombre, precio)
+		INSERT INTO productos (nombre, precio_unitario, costo_unitario, id_categoria, orden, esta_activo)
+		VALUES (?, ?, ?, ?, ?, 1)
+	`, producto.Nombre, producto.PrecioUnitario, producto.CostoUnitario,
+		nuloSiCero(producto.IdCategoria), producto.Orden)
 	if err != nil {
 		return models.Producto{}, err
 	}
 	id, _ := result.LastInsertId()
-	return models.Producto{
-		IdProducto:     int(id),
-		Nombre:         nombre,
-		PrecioUnitario: precio,
-		EstaActivo:     true,
-	}, nil
+	p, err := r.ObtenerProductoPorId(int(id))
+	if err != nil {
+		return models.Producto{}, err
+	}
+	return *p, nil
 }
 
-// ActualizarProducto modifica nombre y precio de un producto
-func (r *Repositorio) ActualizarProducto(id int, nombre string, precio float64) error {
+// ActualizarProducto modifica nombre, costo, categoría y orden de un producto.
+// El precio no se toca aquí: los cambios se registran con ProgramarPrecio.
+func (r *Repositorio) ActualizarProducto(producto models.Producto) error {
 	_, err := r.db.Exec(`
-		UPDATE productos SET nombre = ?, precio_unitario = ? WHERE id_producto = ?
-	`, nombre, precio, id)
+		UPDATE productos
+		SET nombre = ?, costo_unitario = ?, id_categoria = ?, orden = ?
+		WHERE id_producto = ?
+	`, producto.Nombre, producto.CostoUnitario,
+		nuloSiCero(producto.IdCategoria), producto.Orden, producto.IdProducto)
 	return err
 }
 
@@ -63,38 +117,22 @@ func (r *Repositorio) CambiarEstadoProducto(id int, activo bool) error {
 	return err
 }
 
-// ObtenerProductosActivos retorna todos los productos activos
+// ObtenerProductosActivos retorna todos los productos activos por categoría y orden
 func (r *Repositorio) ObtenerProductosActivos() ([]models.Producto, error) {
-	rows, err := r.db.Query(`
-		SELECT id_producto, nombre, precio_unitario, esta_activo
-		FROM productos
-		WHERE esta_activo = 1
-		ORDER BY id_producto
-	`)
+	rows, err := r.db.Query(selectProductos() + `
+		WHERE p.esta_activo = 1
+		ORDER BY ` + ordenProductos)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var productos []models.Producto
-	for rows.Next() {
-		var p models.Producto
-		if err := rows.Scan(&p.IdProducto, &p.Nombre, &p.PrecioUnitario, &p.EstaActivo); err != nil {
-			return nil, err
-		}
-		productos = append(productos, p)
-	}
-
-	return productos, rows.Err()
+	return escanearProductos(rows)
 }
 
 // ObtenerProductoPorId retorna un producto por su ID
 func (r *Repositorio) ObtenerProductoPorId(idProducto int) (*models.Producto, error) {
-	var p models.Producto
-	err := r.db.QueryRow(`
-		SELECT id_producto, nombre, precio_unitario, esta_activo
-		FROM productos WHERE id_producto = ?
-	`, idProducto).Scan(&p.IdProducto, &p.Nombre, &p.PrecioUnitario, &p.EstaActivo)
+	p, err := escanearProducto(r.db.QueryRow(selectProductos()+`
+		WHERE p.id_producto = ?
+	`, idProducto))
 	if err != nil {
 		return nil, err
 	}
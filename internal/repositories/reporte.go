@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"strconv"
+	"time"
+)
+
+// ObtenerMargenPorProducto retorna ingresos, costo y margen bruto por producto en un rango.
+// El costo usa el costo unitario guardado en cada consumo al momento de registrarlo.
+func (r *Repositorio) ObtenerMargenPorProducto(desde, hasta time.Time) ([]models.MargenProducto, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id_producto, p.nombre,
+		       SUM(c.cantidad),
+		       SUM(c.total_linea),
+		       SUM(c.cantidad * c.costo_unitario)
+		FROM consumos c
+		JOIN productos p ON c.id_producto = p.id_producto
+		WHERE c.fecha_consumo BETWEEN ? AND ?
+		GROUP BY p.id_producto
+		ORDER BY SUM(c.total_linea) DESC
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var margenes []models.MargenProducto
+	for rows.Next() {
+		var m models.MargenProducto
+		if err := rows.Scan(&m.IdProducto, &m.NombreProducto, &m.Cantidad, &m.Ingresos, &m.Costo); err != nil {
+			return nil, err
+		}
+		m.Margen = m.Ingresos - m.Costo
+		margenes = append(margenes, m)
+	}
+	return margenes, rows.Err()
+}
+
+// ObtenerMargenPorSemana retorna ingresos, costo y margen bruto agrupados por
+// semana escolar (fecha de su primer día)
+func (r *Repositorio) ObtenerMargenPorSemana(desde, hasta time.Time) ([]models.MargenPeriodo, error) {
+	// 'weekday N' avanza al último día del ciclo de 7 días; -6 vuelve al primero
+	ultimoDia := (utils.Semana().DiaInicio + 6) % 7
+	rows, err := r.db.Query(`
+		SELECT date(fecha_consumo, 'weekday `+strconv.Itoa(int(ultimoDia))+`', '-6 days') AS inicio,
+		       SUM(total_linea),
+		       SUM(cantidad * costo_unitario)
+		FROM consumos
+		WHERE fecha_consumo BETWEEN ? AND ?
+		GROUP BY inicio
+		ORDER BY inicio
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var periodos []models.MargenPeriodo
+	for rows.Next() {
+		var m models.MargenPeriodo
+		var inicio string
+		if err := rows.Scan(&inicio, &m.Ingresos, &m.Costo); err != nil {
+			return nil, err
+		}
+		m.Inicio, _ = utils.ParsearFecha(inicio)
+		m.Margen = m.Ingresos - m.Costo
+		periodos = append(periodos, m)
+	}
+	return periodos, rows.Err()
+}
+
+// ObtenerContadoPorProducto retorna ingresos, costo y margen de las ventas al
+// contado (sin las anuladas) por producto en un rango
+func (r *Repositorio) ObtenerContadoPorProducto(desde, hasta time.Time) ([]models.MargenProducto, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id_producto, p.nombre,
+		       SUM(i.cantidad),
+		       SUM(i.total_linea),
+		       SUM(i.cantidad * i.costo_unitario)
+		FROM ventas_contado v
+		JOIN venta_contado_items i ON i.id_venta = v.id_venta
+		JOIN productos p ON i.id_producto = p.id_producto
+		WHERE v.anulada = 0 AND v.fecha BETWEEN ? AND ?
+		GROUP BY p.id_producto
+		ORDER BY SUM(i.total_linea) DESC
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var margenes []models.MargenProducto
+	for rows.Next() {
+		var m models.MargenProducto
+		if err := rows.Scan(&m.IdProducto, &m.NombreProducto, &m.Cantidad, &m.Ingresos, &m.Costo); err != nil {
+			return nil, err
+		}
+		m.Margen = m.Ingresos - m.Costo
+		margenes = append(margenes, m)
+	}
+	return margenes, rows.Err()
+}
+
+// ObtenerContadoPorSemana retorna el total de ventas al contado (sin las
+// anuladas) por semana escolar, indexado por la fecha de su primer día
+func (r *Repositorio) ObtenerContadoPorSemana(desde, hasta time.Time) (map[string]float64, error) {
+	ultimoDia := (utils.Semana().DiaInicio + 6) % 7
+	rows, err := r.db.Query(`
+		SELECT date(fecha, 'weekday `+strconv.Itoa(int(ultimoDia))+`', '-6 days') AS inicio,
+		       SUM(total)
+		FROM ventas_contado
+		WHERE anulada = 0 AND fecha BETWEEN ? AND ?
+		GROUP BY inicio
+	`, desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totales := make(map[string]float64)
+	for rows.Next() {
+		var inicio string
+		var total float64
+		if err := rows.Scan(&inicio, &total); err != nil {
+			return nil, err
+		}
+		totales[inicio] = total
+	}
+	return totales, rows.Err()
+}
@@ -14,3 +14,8 @@ type Repositorio struct {
 func NuevoRepositorio() *Repositorio {
 	return &Repositorio{db: config.DB()}
 }
+
+// NuevoRepositorioColegio crea una instancia del repositorio sobre la base de un colegio.
+func NuevoRepositorioColegio(nombre string) *Repositorio {
+	return &Repositorio{db: config.DBColegio(nombre)}
+}
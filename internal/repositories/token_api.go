@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"database/sql"
+	"kiosco/internal/models"
+)
+
+const selectTokensAPI = `
+	SELECT t.id_token, t.nombre, t.prefijo, t.alcance, COALESCE(u.usuario, ''),
+	       t.creado_en, t.ultimo_uso, t.expira_en, t.revocado_en
+	FROM tokens_api t
+	LEFT JOIN usuarios u ON t.id_usuario = u.id_usuario`
+
+func escanearTokenAPI(row interface{ Scan(...any) error }) (models.TokenAPI, error) {
+	var t models.TokenAPI
+	var ultimoUso, expira, revocado sql.NullTime
+	if err := row.Scan(&t.IdToken, &t.Nombre, &t.Prefijo, &t.Alcance, &t.NombreUsuario,
+		&t.CreadoEn, &ultimoUso, &expira, &revocado); err != nil {
+		return t, err
+	}
+	if ultimoUso.Valid {
+		t.UltimoUso = &ultimoUso.Time
+	}
+	if expira.Valid {
+		t.ExpiraEn = &expira.Time
+	}
+	if revocado.Valid {
+		t.RevocadoEn = &revocado.Time
+	}
+	return t, nil
+}
+
+// ObtenerTokensAPI retorna todos los tokens de servicio, los vigentes primero
+func (r *Repositorio) ObtenerTokensAPI() ([]models.TokenAPI, error) {
+	rows, err := r.db.Query(selectTokensAPI + `
+		ORDER BY t.revocado_en IS NOT NULL, t.creado_en DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.TokenAPI
+	for rows.Next() {
+		t, err := escanearTokenAPI(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// ObtenerTokenAPIPorHash busca un token de servicio por el hash de su valor
+func (r *Repositorio) ObtenerTokenAPIPorHash(hash string) (models.TokenAPI, error) {
+	return escanearTokenAPI(r.db.QueryRow(selectTokensAPI+`
+		WHERE t.hash_token = ?
+	`, hash))
+}
+
+// InsertarTokenAPI guarda un token de servicio nuevo (solo su hash)
+func (r *Repositorio) InsertarTokenAPI(token models.TokenAPI, hash string, idUsuario int) error {
+	var expira interface{}
+	if token.ExpiraEn != nil {
+		expira = token.ExpiraEn.Format("2006-01-02")
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO tokens_api (nombre, prefijo, hash_token, alcance, id_usuario, expira_en)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, token.Nombre, token.Prefijo, hash, token.Alcance, nuloSiCero(idUsuario), expira)
+	return err
+}
+
+// MarcarUsoTokenAPI registra el último uso de un token. Para no escribir en cada
+// request, solo actualiza si el uso anterior fue hace más de un minuto.
+func (r *Repositorio) MarcarUsoTokenAPI(idToken int) error {
+	_, err := r.db.Exec(`
+		UPDATE tokens_api SET ultimo_uso = CURRENT_TIMESTAMP
+		WHERE id_token = ? AND (ultimo_uso IS NULL OR ultimo_uso < datetime('now', '-1 minute'))
+	`, idToken)
+	return err
+}
+
+// RevocarTokenAPI marca un token como revocado; deja de aceptarse de inmediato
+func (r *Repositorio) RevocarTokenAPI(idToken int) error {
+	_, err := r.db.Exec(`
+		UPDATE tokens_api SET revocado_en = CURRENT_TIMESTAMP
+		WHERE id_token = ? AND revocado_en IS NULL
+	`, idToken)
+	return err
+}
@@ -1,6 +1,9 @@
 package repositories
 
-import "kiosco/internal/models"
+import (
+	"database/sql"
+	"kiosco/internal/models"
+)
 
 // ObtenerUsuarioPorNombre busca un usuario por su nombre de usuario
 func (r *Repositorio) ObtenerUsuarioPorNombre(usuario string) (models.Usuario, error) {
@@ -11,3 +14,13 @@ func (r *Repositorio) ObtenerUsuarioPorNombre(usuario string) (models.Usuario, e
 	`, usuario).Scan(&u.IdUsuario, &u.Usuario, &u.Contrasenha, &u.PuedeEditar)
 	return u, err
 }
+
+// EsSuperadmin indica si el usuario puede ver el resumen de todos los colegios
+func (r *Repositorio) EsSuperadmin(idUsuario int) (bool, error) {
+	var es bool
+	err := r.db.QueryRow(`SELECT es_superadmin FROM usuarios WHERE id_usuario = ?`, idUsuario).Scan(&es)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return es, err
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// RegistrarVentaContado inserta una venta al contado con sus ítems y descuenta
+// el stock en una transacción. El costo de cada ítem es el vigente, como en
+// los consumos, para el margen bruto. La venta queda en la caja abierta de
+// quien cobró, si tiene una.
+func (r *Repositorio) RegistrarVentaContado(venta models.VentaContado) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO ventas_contado (fecha, total, recibido, id_usuario, id_sesion_caja)
+		VALUES (?1, ?2, ?3, ?4, `+sesionCajaAbiertaSQL("?4")+`)
+	`, venta.Fecha.Format("2006-01-02"), venta.Total, venta.Recibido, nuloSiCero(venta.IdUsuario))
+	if err != nil {
+		return 0, err
+	}
+	idVenta, _ := result.LastInsertId()
+
+	for _, item := range venta.Items {
+		if _, err := tx.Exec(`
+			INSERT INTO venta_contado_items (id_venta, id_producto, cantidad, precio_unitario, costo_unitario)
+			VALUES (?, ?, ?, ?, `+costoProductoSQL+`)
+		`, idVenta, item.IdProducto, item.Cantidad, item.PrecioUnitario, item.IdProducto, item.IdProducto); err != nil {
+			return 0, fmt.Errorf("producto %d: %v", item.IdProducto, err)
+		}
+		if err := descontarStockVentaTx(tx, item.IdProducto, item.Cantidad, venta.Fecha); err != nil {
+			return 0, err
+		}
+	}
+
+	return int(idVenta), tx.Commit()
+}
+
+// AnularVentaContado marca una venta de la fecha indicada como anulada y
+// devuelve su stock. Retorna false si la venta no existe, ya estaba anulada, es
+// de otro día o su caja ya se cerró.
+func (r *Repositorio) AnularVentaContado(idVenta int, fecha time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE ventas_contado SET anulada = 1
+		WHERE id_venta = ? AND anulada = 0 AND fecha = ?
+		  AND (id_sesion_caja IS NULL OR id_sesion_caja IN (SELECT id_sesion FROM sesiones_caja WHERE cerrada_en IS NULL))
+		RETURNING fecha
+	`, idVenta, fecha.Format("2006-01-02")).Scan(&fecha)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := tx.Query(`SELECT id_producto, cantidad FROM venta_contado_items WHERE id_venta = ?`, idVenta)
+	if err != nil {
+		return false, err
+	}
+	var items []models.ItemVentaContado
+	for rows.Next() {
+		var item models.ItemVentaContado
+		if err := rows.Scan(&item.IdProducto, &item.Cantidad); err != nil {
+			rows.Close()
+			return false, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, item := range items {
+		if err := descontarStockVentaTx(tx, item.IdProducto, -item.Cantidad, fecha); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// ObtenerVentasContado retorna las ventas al contado de un día con sus ítems,
+// la más reciente primero
+func (r *Repositorio) ObtenerVentasContado(fecha time.Time) ([]models.VentaContado, error) {
+	return r.consultarVentasContado(`v.fecha = ?`, fecha.Format("2006-01-02"))
+}
+
+// ObtenerVentaContado retorna una venta al contado con sus ítems o sql.ErrNoRows
+func (r *Repositorio) ObtenerVentaContado(idVenta int) (models.VentaContado, error) {
+	ventas, err := r.consultarVentasContado(`v.id_venta = ?`, idVenta)
+	if err != nil {
+		return models.VentaContado{}, err
+	}
+	if len(ventas) == 0 {
+		return models.VentaContado{}, sql.ErrNoRows
+	}
+	return ventas[0], nil
+}
+
+// ObtenerVentasSesionCaja retorna las ventas al contado cobradas en una sesión
+// de caja, la más reciente primero
+func (r *Repositorio) ObtenerVentasSesionCaja(idSesion int) ([]models.VentaContado, error) {
+	return r.consultarVentasContado(`v.id_sesion_caja = ?`, idSesion)
+}
+
+func (r *Repositorio) consultarVentasContado(filtro string, args ...any) ([]models.VentaContado, error) {
+	rows, err := r.db.Query(`
+		SELECT v.id_venta, v.fecha, v.total, v.recibido, COALESCE(v.id_usuario, 0), v.anulada, v.creado_en,
+		       COALESCE(v.id_sesion_caja, 0), s.cerrada_en IS NOT NULL,
+		       i.id_producto, p.nombre, i.cantidad, i.precio_unitario, i.total_linea
+		FROM ventas_contado v
+		JOIN venta_contado_items i ON i.id_venta = v.id_venta
+		JOIN productos p ON i.id_producto = p.id_producto
+		LEFT JOIN sesiones_caja s ON v.id_sesion_caja = s.id_sesion
+		WHERE `+filtro+`
+		ORDER BY v.id_venta DESC, i.id_item
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Agrupación en memoria: slice para preservar el orden
+	var ventas []models.VentaContado
+	for rows.Next() {
+		var v models.VentaContado
+		var item models.ItemVentaContado
+		if err := rows.Scan(&v.IdVenta, &v.Fecha, &v.Total, &v.Recibido, &v.IdUsuario, &v.Anulada, &v.CreadoEn,
+			&v.IdSesionCaja, &v.CajaCerrada, &item.IdProducto, &item.NombreProducto, &item.Cantidad, &item.PrecioUnitario, &item.TotalLinea); err != nil {
+			return nil, err
+		}
+		if n := len(ventas); n == 0 || ventas[n-1].IdVenta != v.IdVenta {
+			ventas = append(ventas, v)
+		}
+		ultima := &ventas[len(ventas)-1]
+		ultima.Items = append(ultima.Items, item)
+	}
+	return ventas, rows.Err()
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"strings"
+)
+
+func escanearWebhooks(rows *sql.Rows) ([]models.Webhook, error) {
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var w models.Webhook
+		var eventos string
+		if err := rows.Scan(&w.IdWebhook, &w.URL, &w.Secreto, &eventos, &w.EstaActivo, &w.CreadoEn); err != nil {
+			return nil, err
+		}
+		w.Eventos = strings.Split(eventos, ",")
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// ObtenerWebhooks retorna todos los webhooks configurados
+func (r *Repositorio) ObtenerWebhooks() ([]models.Webhook, error) {
+	rows, err := r.db.Query(`
+		SELECT id_webhook, url, secreto, eventos, esta_activo, creado_en
+		FROM webhooks ORDER BY esta_activo DESC, id_webhook
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return escanearWebhooks(rows)
+}
+
+// ObtenerWebhooksEvento retorna los webhooks activos suscritos a un evento
+func (r *Repositorio) ObtenerWebhooksEvento(evento string) ([]models.Webhook, error) {
+	rows, err := r.db.Query(`
+		SELECT id_webhook, url, secreto, eventos, esta_activo, creado_en
+		FROM webhooks
+		WHERE esta_activo = 1 AND (',' || eventos || ',') LIKE '%,' || ? || ',%'
+	`, evento)
+	if err != nil {
+		return nil, err
+	}
+	return escanearWebhooks(rows)
+}
+
+// InsertarWebhook registra un destino de webhooks
+func (r *Repositorio) InsertarWebhook(w models.Webhook) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webhooks (url, secreto, eventos) VALUES (?, ?, ?)
+	`, w.URL, w.Secreto, w.EventosTexto())
+	return err
+}
+
+// CambiarEstadoWebhook activa o pausa un webhook; pausado no recibe eventos nuevos
+func (r *Repositorio) CambiarEstadoWebhook(idWebhook int, activo bool) error {
+	_, err := r.db.Exec(`UPDATE webhooks SET esta_activo = ? WHERE id_webhook = ?`, activo, idWebhook)
+	return err
+}
+
+// EliminarWebhook borra un webhook junto con su registro de entregas
+func (r *Repositorio) EliminarWebhook(idWebhook int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM webhook_entregas WHERE id_webhook = ?`, idWebhook); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM webhooks WHERE id_webhook = ?`, idWebhook); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EncolarEntregaWebhook agrega un evento a la bandeja de salida de un webhook
+func (r *Repositorio) EncolarEntregaWebhook(idWebhook int, idEvento, evento, payload string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webhook_entregas (id_webhook, id_evento, evento, payload)
+		VALUES (?, ?, ?, ?)
+	`, idWebhook, idEvento, evento, payload)
+	return err
+}
+
+const selectEntregasWebhook = `
+	SELECT e.id_entrega, e.id_webhook, w.url, w.secreto, e.id_evento, e.evento, e.payload,
+	       e.estado, e.intentos, e.proximo_intento, COALESCE(e.ultimo_status, 0),
+	       COALESCE(e.ultimo_error, ''), e.creado_en, e.entregado_en
+	FROM webhook_entregas e
+	JOIN webhooks w ON e.id_webhook = w.id_webhook`
+
+func escanearEntregasWebhook(rows *sql.Rows) ([]models.EntregaWebhook, error) {
+	defer rows.Close()
+
+	var entregas []models.EntregaWebhook
+	for rows.Next() {
+		var e models.EntregaWebhook
+		var entregado sql.NullTime
+		if err := rows.Scan(&e.IdEntrega, &e.IdWebhook, &e.URL, &e.Secreto, &e.IdEvento, &e.Evento,
+			&e.Payload, &e.Estado, &e.Intentos, &e.ProximoIntento, &e.UltimoStatus,
+			&e.UltimoError, &e.CreadoEn, &entregado); err != nil {
+			return nil, err
+		}
+		if entregado.Valid {
+			e.EntregadoEn = &entregado.Time
+		}
+		entregas = append(entregas, e)
+	}
+	return entregas, rows.Err()
+}
+
+// ObtenerEntregasPendientes retorna las entregas cuyo próximo intento ya venció.
+// Las de webhooks pausados quedan en cola hasta que se reactiven.
+func (r *Repositorio) ObtenerEntregasPendientes(limite int) ([]models.EntregaWebhook, error) {
+	rows, err := r.db.Query(selectEntregasWebhook+`
+		WHERE e.estado = 'pendiente' AND e.proximo_intento <= CURRENT_TIMESTAMP
+		  AND w.esta_activo = 1
+		ORDER BY e.proximo_intento, e.id_entrega
+		LIMIT ?
+	`, limite)
+	if err != nil {
+		return nil, err
+	}
+	return escanearEntregasWebhook(rows)
+}
+
+// ObtenerEntregasWebhook retorna las últimas entregas para el registro, opcionalmente por estado
+func (r *Repositorio) ObtenerEntregasWebhook(estado string, limite int) ([]models.EntregaWebhook, error) {
+	rows, err := r.db.Query(selectEntregasWebhook+`
+		WHERE ? = '' OR e.estado = ?
+		ORDER BY e.id_entrega DESC
+		LIMIT ?
+	`, estado, estado, limite)
+	if err != nil {
+		return nil, err
+	}
+	return escanearEntregasWebhook(rows)
+}
+
+// MarcarEntregaExitosa registra que el destino aceptó el evento
+func (r *Repositorio) MarcarEntregaExitosa(idEntrega, status int) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_entregas
+		SET estado = 'entregado', intentos = intentos + 1, ultimo_status = ?,
+		    ultimo_error = NULL, entregado_en = CURRENT_TIMESTAMP
+		WHERE id_entrega = ?
+	`, status, idEntrega)
+	return err
+}
+
+// MarcarEntregaFallida registra un intento fallido. Si esperaSegundos es 0 la
+// entrega queda como fallida; si no, se reprograma para dentro de esa espera.
+func (r *Repositorio) MarcarEntregaFallida(idEntrega, status int, mensaje string, esperaSegundos int) error {
+	estado := models.EntregaPendiente
+	if esperaSegundos == 0 {
+		estado = models.EntregaFallida
+	}
+	_, err := r.db.Exec(`
+		UPDATE webhook_entregas
+		SET estado = ?, intentos = intentos + 1, ultimo_status = ?, ultimo_error = ?,
+		    proximo_intento = datetime('now', ?)
+		WHERE id_entrega = ?
+	`, estado, nuloSiCero(status), mensaje, fmt.Sprintf("+%d seconds", esperaSegundos), idEntrega)
+	return err
+}
+
+// ReintentarEntregaWebhook vuelve a poner en cola una entrega fallida
+func (r *Repositorio) ReintentarEntregaWebhook(idEntrega int) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_entregas
+		SET estado = 'pendiente', intentos = 0, proximo_intento = CURRENT_TIMESTAMP
+		WHERE id_entrega = ? AND estado = 'fallido'
+	`, idEntrega)
+	return err
+}
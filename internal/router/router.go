@@ -4,11 +4,47 @@ import (
 	"kiosco/internal/config"
 	"kiosco/internal/controllers"
 	"kiosco/internal/middleware"
+	"net"
 	"net/http"
+	"strings"
 )
 
 // ConfigurarRutas registra todas las rutas y archivos estáticos, devuelve el mux.
 func ConfigurarRutas(controlador *controllers.Controlador) http.Handler {
+	return limitarConcurrencia(rutas(controlador))
+}
+
+// ConfigurarColegios arma el handler de un binario con varios colegios: cada
+// host entra a las rutas de su colegio, con su propia base, y un host que no
+// está en COLEGIOS recibe 404. El límite de concurrencia es uno para todos.
+func ConfigurarColegios(porHost map[string]*controllers.Controlador) http.Handler {
+	porControlador := make(map[*controllers.Controlador]http.Handler)
+	rutasPorHost := make(map[string]http.Handler, len(porHost))
+	for host, controlador := range porHost {
+		h, ok := porControlador[controlador]
+		if !ok {
+			h = middleware.ConColegio(controlador.Colegio(), rutas(controlador))
+			porControlador[controlador] = h
+		}
+		rutasPorHost[host] = h
+	}
+
+	return limitarConcurrencia(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		h, ok := rutasPorHost[host]
+		if !ok {
+			http.Error(w, "Colegio no encontrado", http.StatusNotFound)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+}
+
+// rutas registra las rutas y archivos estáticos de un colegio.
+func rutas(controlador *controllers.Controlador) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Archivos estáticos embebidos (públicos, sin auth)
@@ -18,6 +54,13 @@ func ConfigurarRutas(controlador *controllers.Controlador) http.Handler {
 	mux.HandleFunc("GET /login", middleware.ProtegerLogin(controlador.MostrarLogin))
 	mux.HandleFunc("POST /login", middleware.ProtegerLogin(controlador.ProcesarLogin))
 	mux.HandleFunc("GET /logout", controlador.Logout)
+	mux.HandleFunc("GET /correos/baja", middleware.ProtegerPublico(controlador.BajaCorreos)) // Enlace de baja de los correos y mensajes a apoderados
+	mux.HandleFunc("POST /correos/baja", middleware.ProtegerPublico(controlador.BajaCorreos))
+
+	// Portal de apoderados — solo lectura, con su propia cookie (no da acceso a las rutas del personal)
+	mux.HandleFunc("GET /portal/acceso", middleware.ProtegerPublico(controlador.PortalAcceso))
+	mux.HandleFunc("GET /portal/salir", middleware.ProtegerPublico(controlador.PortalSalir))
+	mux.HandleFunc("GET /portal", middleware.ProtegerPortal(controlador.Portal))
 
 	// Atajos para proteger HandlerFunc
 	proteger := middleware.Proteger             // Solo requiere autenticación
@@ -38,6 +81,43 @@ func ConfigurarRutas(controlador *controllers.Controlador) http.Handler {
 	mux.HandleFunc("POST /setup/estudiante", protegerEdicion(controlador.AgregarEstudiante))
 	mux.HandleFunc("POST /setup/estudiante/actualizar", protegerEdicion(controlador.ActualizarEstudiante))
 	mux.HandleFunc("POST /setup/estudiante/toggle", protegerEdicion(controlador.ToggleEstudiante))
+	mux.HandleFunc("GET /setup/carnes", protegerEdicion(controlador.Carnes))
+
+	// Calendario escolar — requiere edición
+	mux.HandleFunc("GET /setup/calendario", protegerEdicion(controlador.Calendario))
+	mux.HandleFunc("POST /setup/calendario/semana", protegerEdicion(controlador.GuardarDiasLaborables))
+	mux.HandleFunc("POST /setup/calendario/dia", protegerEdicion(controlador.CrearDiaNoLaborable))
+	mux.HandleFunc("POST /setup/calendario/dia/eliminar", protegerEdicion(controlador.EliminarDiaNoLaborable))
+
+	// Tokens de API para cuentas de servicio — requieren edición
+	mux.HandleFunc("GET /setup/tokens", protegerEdicion(controlador.TokensAPI))
+	mux.HandleFunc("POST /setup/tokens", protegerEdicion(controlador.CrearTokenAPI))
+	mux.HandleFunc("POST /setup/tokens/revocar", protegerEdicion(controlador.RevocarTokenAPI))
+
+	// Webhooks salientes y registro de entregas — requieren edición
+	mux.HandleFunc("GET /setup/webhooks", protegerEdicion(controlador.Webhooks))
+	mux.HandleFunc("POST /setup/webhooks", protegerEdicion(controlador.CrearWebhook))
+	mux.HandleFunc("POST /setup/webhooks/accion", protegerEdicion(controlador.AccionWebhook))
+	mux.HandleFunc("POST /setup/webhooks/reintentar", protegerEdicion(controlador.ReintentarEntregaWebhook))
+
+	// Correos a apoderados: estados de cuenta, recordatorios y registro de envíos — requieren edición
+	mux.HandleFunc("GET /setup/correos", protegerEdicion(controlador.Correos))
+	mux.HandleFunc("POST /setup/correos/apoderado", protegerEdicion(controlador.AgregarApoderado))
+	mux.HandleFunc("POST /setup/correos/apoderado/accion", protegerEdicion(controlador.AccionApoderado))
+	mux.HandleFunc("POST /setup/correos/portal", protegerEdicion(controlador.AccesoPortalApoderado))
+	mux.HandleFunc("POST /setup/correos/ajustes", protegerEdicion(controlador.GuardarAjustesCorreos))
+	mux.HandleFunc("POST /setup/correos/enviar", protegerEdicion(controlador.EnviarCorreos))
+	mux.HandleFunc("POST /setup/correos/reintentar", protegerEdicion(controlador.ReintentarCorreo))
+	mux.HandleFunc("GET /setup/mensajes", protegerEdicion(controlador.Mensajes))
+	mux.HandleFunc("POST /setup/mensajes/ajustes", protegerEdicion(controlador.GuardarAjustesMensajes))
+	mux.HandleFunc("POST /setup/mensajes/enviar", protegerEdicion(controlador.EnviarMensajes))
+	mux.HandleFunc("POST /setup/mensajes/reintentar", protegerEdicion(controlador.ReintentarMensaje))
+
+	// Conciliación de extractos de banco y billeteras con pagos — requiere edición
+	mux.HandleFunc("GET /setup/conciliacion", protegerEdicion(controlador.Conciliacion))
+	mux.HandleFunc("POST /setup/conciliacion/importar", protegerEdicion(controlador.ImportarExtracto))
+	mux.HandleFunc("POST /setup/conciliacion/movimiento", protegerEdicion(controlador.AccionMovimiento))
+	mux.HandleFunc("POST /setup/conciliacion/confirmar-sugeridos", protegerEdicion(controlador.ConfirmarSugeridos))
 
 	// Gestión de productos — solo lectura para usuarios sin edición
 	// GET es accesible a todos, POST requiere edición
@@ -45,15 +125,114 @@ func ConfigurarRutas(controlador *controllers.Controlador) http.Handler {
 	mux.HandleFunc("POST /setup/producto", protegerEdicion(controlador.AgregarProducto))
 	mux.HandleFunc("POST /setup/producto/actualizar", protegerEdicion(controlador.ActualizarProducto))
 	mux.HandleFunc("POST /setup/producto/toggle", protegerEdicion(controlador.ToggleProducto))
+	mux.HandleFunc("POST /setup/categoria", protegerEdicion(controlador.AgregarCategoria))
+	mux.HandleFunc("GET /setup/precios", proteger(controlador.HistorialPrecios))
+	mux.HandleFunc("POST /setup/precios", protegerEdicion(controlador.ProgramarPrecio))
+	mux.HandleFunc("POST /setup/precios/cancelar", protegerEdicion(controlador.CancelarPrecio))
+
+	// Menú del día — GET accesible a todos, POST requiere edición
+	mux.HandleFunc("GET /menu", proteger(controlador.MenuDia))
+	mux.HandleFunc("POST /menu", protegerEdicion(controlador.GuardarMenuDia))
+
+	// Inventario — GET accesible a todos, POST requiere edición
+	mux.HandleFunc("GET /inventario", proteger(controlador.Inventario))
+	mux.HandleFunc("POST /inventario/movimiento", protegerEdicion(controlador.RegistrarMovimientoStock))
+	mux.HandleFunc("POST /inventario/producto", protegerEdicion(controlador.ConfigurarStock))
+
+	// Compras a proveedores y reportes financieros — requieren edición
+	mux.HandleFunc("GET /compras", protegerEdicion(controlador.Compras))
+	mux.HandleFunc("POST /compras", protegerEdicion(controlador.RegistrarCompra))
+	mux.HandleFunc("POST /compras/proveedor", protegerEdicion(controlador.AgregarProveedor))
+	mux.HandleFunc("GET /reportes/margen", protegerEdicion(controlador.ReporteMargen))
+	mux.HandleFunc("GET /reportes/descuentos", protegerEdicion(controlador.ReporteDescuentos))
+
+	// Becas y descuentos — requieren edición
+	mux.HandleFunc("GET /setup/descuentos", protegerEdicion(controlador.Descuentos))
+	mux.HandleFunc("POST /setup/descuentos/regla", protegerEdicion(controlador.CrearReglaDescuento))
+	mux.HandleFunc("POST /setup/descuentos/regla/estado", protegerEdicion(controlador.CambiarEstadoReglaDescuento))
+	mux.HandleFunc("POST /setup/descuentos/grupo", protegerEdicion(controlador.CrearGrupoDescuento))
+	mux.HandleFunc("POST /setup/descuentos/grupo/estudiante", protegerEdicion(controlador.ModificarGrupoDescuento))
+
+	// Combos y planes de alimentación — requieren edición
+	mux.HandleFunc("GET /setup/combos", protegerEdicion(controlador.Combos))
+	mux.HandleFunc("POST /setup/combos/componente", protegerEdicion(controlador.GuardarComponenteCombo))
+	mux.HandleFunc("GET /planes", protegerEdicion(controlador.Planes))
+	mux.HandleFunc("POST /planes", protegerEdicion(controlador.CrearPlan))
+	mux.HandleFunc("POST /planes/inscribir", protegerEdicion(controlador.InscribirEnPlan))
+	mux.HandleFunc("POST /planes/finalizar", protegerEdicion(controlador.FinalizarSuscripcion))
 
 	// Registro de consumos por sector — accesible a todos
 	mux.HandleFunc("GET /registro", proteger(controlador.RegistroConsumos))
 	mux.HandleFunc("GET /registro/menor", proteger(controlador.RegistroSector))
 	mux.HandleFunc("GET /registro/mayor", proteger(controlador.RegistroSector))
+	mux.HandleFunc("GET /registro/personal", proteger(controlador.RegistroSector))
+	mux.HandleFunc("GET /registro/buscar", proteger(controlador.BuscarEstudiantesRegistro))
+
+	// Venta al contado (efectivo, sin cuenta de estudiante) — cobrar es accesible a todos
+	mux.HandleFunc("GET /venta-contado", proteger(controlador.VentaContado))
+	mux.HandleFunc("POST /venta-contado", proteger(controlador.CobrarVentaContado))
+	mux.HandleFunc("POST /venta-contado/anular", protegerEdicion(controlador.AnularVentaContado))
+
+	// Caja por cajero (fondo, arqueo y diferencia) — la revisión de todas las sesiones requiere edición
+	mux.HandleFunc("GET /caja", proteger(controlador.Caja))
+	mux.HandleFunc("POST /caja/abrir", proteger(controlador.AbrirCaja))
+	mux.HandleFunc("POST /caja/cerrar", proteger(controlador.CerrarCaja))
+	mux.HandleFunc("GET /caja/sesion", proteger(controlador.ReporteCaja))
+	mux.HandleFunc("GET /caja/sesiones", protegerEdicion(controlador.SesionesCaja))
+
+	// Ventas de todos los colegios del binario — solo usuarios con es_superadmin = 1
+	mux.HandleFunc("GET /superadmin", protegerEdicion(controlador.Superadmin))
+
+	// Cuentas mensuales de docentes, personal y otros clientes — requieren edición
+	mux.HandleFunc("GET /personal", protegerEdicion(controlador.Personal))
+	mux.HandleFunc("GET /personal/estado", protegerEdicion(controlador.EstadoCuentaPersonal))
 
 	// Resumen de consumos por sector — accesible a todos
 	mux.HandleFunc("GET /resumen/menor", proteger(controlador.ResumenSector))
 	mux.HandleFunc("GET /resumen/mayor", proteger(controlador.ResumenSector))
+	mux.HandleFunc("GET /resumen/personal", proteger(controlador.ResumenSector))
+
+	// Cambios en vivo de consumos y pagos (Server-Sent Events) — accesible a todos
+	mux.HandleFunc("GET /eventos", proteger(controlador.EventosEnVivo))
+
+	// API JSON v1 — token Bearer propio, sin cookie ni CSRF
+	middleware.RegistrarVerificadorTokens(controlador.Colegio(), controlador.VerificarTokenServicio)
+	apiLectura := middleware.ProtegerAPI         // Requiere token válido
+	apiEdicion := middleware.ProtegerAPIEdicion  // Requiere token con puede_editar = 1
+
+	mux.HandleFunc("GET /api/v1/openapi.json", controlador.APIOpenAPI)
+	mux.HandleFunc("POST /api/v1/auth/token", middleware.ProtegerTokenAPI(controlador.APIEmitirToken))
+	mux.HandleFunc("GET /api/v1/estudiantes", apiLectura(controlador.APIListarEstudiantes))
+	mux.HandleFunc("POST /api/v1/estudiantes", apiEdicion(controlador.APICrearEstudiante))
+	mux.HandleFunc("GET /api/v1/estudiantes/{id}", apiLectura(controlador.APIObtenerEstudiante))
+	mux.HandleFunc("GET /api/v1/productos", apiLectura(controlador.APIListarProductos))
+	mux.HandleFunc("GET /api/v1/productos/{id}", apiLectura(controlador.APIObtenerProducto))
+	mux.HandleFunc("GET /api/v1/consumos", apiEdicion(controlador.APIListarConsumos))
+	mux.HandleFunc("POST /api/v1/consumos", apiLectura(controlador.APIRegistrarConsumo))
+	mux.HandleFunc("GET /api/v1/pagos", apiEdicion(controlador.APIListarPagos))
+	mux.HandleFunc("POST /api/v1/pagos", apiEdicion(controlador.APIRegistrarPago))
+	mux.HandleFunc("GET /api/v1/pagos/{id}", apiEdicion(controlador.APIObtenerPago))
+	mux.HandleFunc("DELETE /api/v1/pagos/{id}", apiEdicion(controlador.APIEliminarPago))
+	mux.HandleFunc("GET /api/v1/saldos", apiEdicion(controlador.APIListarSaldos))
+	mux.HandleFunc("GET /api/v1/reportes/margen", apiEdicion(controlador.APIReporteMargen))
+	mux.HandleFunc("GET /api/v1/reportes/descuentos", apiEdicion(controlador.APIReporteDescuentos))
+	for _, metodo := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		mux.HandleFunc(metodo+" /api/", controlador.APINoEncontrado)
+	}
+
+	return mux
+}
 
-	return middleware.LimitarConcurrencia(middleware.LimiteConcurrenciaDefault)(mux)
+// limitarConcurrencia aplica el límite de conexiones concurrentes. Las conexiones
+// de eventos quedan abiertas mientras la página esté abierta: no ocupan cupo
+// (envivo tiene su propio límite)
+func limitarConcurrencia(h http.Handler) http.Handler {
+	limitado := middleware.LimitarConcurrencia(middleware.LimiteConcurrenciaDefault)(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/eventos" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		limitado.ServeHTTP(w, r)
+	})
 }
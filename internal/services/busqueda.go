@@ -0,0 +1,155 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// limiteBusqueda es cuántos estudiantes devuelve como máximo la búsqueda del registro
+const limiteBusqueda = 50
+
+var patronCodigoCarne = regexp.MustCompile(`^[A-Za-z0-9-]{3,20}$`)
+
+// CodigoEstudiante es el código que lleva el carné del estudiante: el que se le
+// asignó en configuración o, si no tiene, su código de pago
+func CodigoEstudiante(e models.Estudiante) string {
+	if e.Codigo != "" {
+		return e.Codigo
+	}
+	return CodigoPago(e.IdEstudiante)
+}
+
+// SectorDeEstudiante retorna el sector del registro al que pertenece una cuenta:
+// por grado para los estudiantes, "personal" para los demás
+func SectorDeEstudiante(e models.Estudiante) string {
+	if !e.EsEstudiante() {
+		return "personal"
+	}
+	if e.IdGrado <= 4 {
+		return "menor"
+	}
+	return "mayor"
+}
+
+// SectorValido indica si sector es uno de los del registro
+func SectorValido(sector string) bool {
+	return sector == "menor" || sector == "mayor" || sector == "personal"
+}
+
+// ValidarCodigoEstudiante revisa el código de carné que se quiere asignar a un
+// estudiante ("" lo quita). Retorna el código normalizado.
+func (s *Servicio) ValidarCodigoEstudiante(idEstudiante int, codigo string) (string, error) {
+	codigo = strings.ToUpper(strings.TrimSpace(codigo))
+	if codigo == "" {
+		return "", nil
+	}
+	if !patronCodigoCarne.MatchString(codigo) {
+		return "", fmt.Errorf("el código debe tener de 3 a 20 letras, números o guiones")
+	}
+	// Los códigos de pago quedan reservados para su estudiante: el lector no
+	// podría distinguirlos
+	if id := buscarCodigoPago(codigo); id != 0 && id != idEstudiante {
+		return "", fmt.Errorf("el código %s es el código de pago de otro estudiante", codigo)
+	}
+	otro, err := s.Repo.ObtenerEstudiantePorCodigo(codigo)
+	if err == nil && otro.IdEstudiante != idEstudiante {
+		return "", fmt.Errorf("el código %s ya es de %s %s", codigo, otro.Nombres, otro.Apellidos)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("error al verificar código: %v", err)
+	}
+	return codigo, nil
+}
+
+// ValidarTipoCliente revisa el tipo de una cuenta ("" = estudiante) y su grado:
+// los estudiantes necesitan un grado válido y los demás no llevan. Retorna el
+// tipo y el grado a guardar.
+func ValidarTipoCliente(tipo string, idGrado int) (string, int, error) {
+	if tipo == "" {
+		tipo = models.TipoEstudiante
+	}
+	if !models.TipoClienteValido(tipo) {
+		return "", 0, fmt.Errorf("tipo de cliente inválido")
+	}
+	if tipo != models.TipoEstudiante {
+		return tipo, 0, nil
+	}
+	if utils.NombreGrado(idGrado) == "" {
+		return "", 0, fmt.Errorf("grado inválido")
+	}
+	return tipo, idGrado, nil
+}
+
+// IdentificarEstudiante busca al estudiante activo cuyo carné tiene exactamente
+// ese código (el asignado o su código de pago), como lo envía un lector de
+// códigos. Retorna sql.ErrNoRows si ninguno coincide.
+func (s *Servicio) IdentificarEstudiante(texto string) (models.Estudiante, error) {
+	texto = strings.TrimSpace(texto)
+	if texto == "" || len(strings.Fields(texto)) > 1 {
+		return models.Estudiante{}, sql.ErrNoRows
+	}
+
+	e, err := s.Repo.ObtenerEstudiantePorCodigo(texto)
+	if err == sql.ErrNoRows {
+		id := buscarCodigoPago(texto)
+		if id == 0 {
+			return models.Estudiante{}, sql.ErrNoRows
+		}
+		e, err = s.Repo.ObtenerEstudiantePorId(id)
+	}
+	if err != nil {
+		return models.Estudiante{}, err
+	}
+	if !e.EstaActivo {
+		return models.Estudiante{}, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+// BuscarEstudiantesRegistro retorna los estudiantes activos del sector que
+// coinciden con lo escrito (apellidos, nombres o código, sin importar tildes ni
+// mayúsculas). Sin texto retorna todo el sector.
+func (s *Servicio) BuscarEstudiantesRegistro(texto, sector string) ([]models.Estudiante, error) {
+	texto = strings.TrimSpace(texto)
+	if texto == "" {
+		return s.Repo.ObtenerEstudiantesActivosPorSector(sector)
+	}
+
+	// Un código completo lleva directo a su estudiante
+	if e, err := s.IdentificarEstudiante(texto); err == nil {
+		if SectorDeEstudiante(e) == sector {
+			return []models.Estudiante{e}, nil
+		}
+	} else if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error al buscar código: %v", err)
+	}
+
+	consulta := consultaFTS(texto)
+	if consulta == "" {
+		return nil, nil
+	}
+	estudiantes, err := s.Repo.BuscarEstudiantes(consulta, sector, limiteBusqueda)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar estudiantes: %v", err)
+	}
+	return estudiantes, nil
+}
+
+// consultaFTS convierte lo escrito en una consulta FTS5 segura: cada palabra
+// (solo letras y números) pasa a ser un prefijo entre comillas, y todas deben
+// coincidir. "gar jo" encuentra a "García, José".
+func consultaFTS(texto string) string {
+	palabras := strings.FieldsFunc(texto, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terminos := make([]string, 0, len(palabras))
+	for _, p := range palabras {
+		terminos = append(terminos, `"`+p+`"*`)
+	}
+	return strings.Join(terminos, " ")
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxNotasCaja limita las observaciones del cierre de caja
+const maxNotasCaja = 500
+
+// parsearMontoCaja interpreta un monto en efectivo escrito por el cajero ("12,50" o "12.50")
+func parsearMontoCaja(valor, campo string) (float64, error) {
+	valor = strings.ReplaceAll(strings.TrimSpace(valor), ",", ".")
+	if valor == "" {
+		return 0, fmt.Errorf("ingrese el %s", campo)
+	}
+	monto, err := strconv.ParseFloat(valor, 64)
+	if err != nil || math.IsNaN(monto) || math.IsInf(monto, 0) || monto < 0 {
+		return 0, fmt.Errorf("el %s no es válido", campo)
+	}
+	return redondearCentavos(monto), nil
+}
+
+// AbrirCaja abre la caja del usuario con el fondo inicial en efectivo. Los
+// errores retornados son para mostrar al cajero.
+func (s *Servicio) AbrirCaja(idUsuario int, montoInicial string) error {
+	monto, err := parsearMontoCaja(montoInicial, "fondo inicial")
+	if err != nil {
+		return err
+	}
+	if _, err := s.Repo.ObtenerSesionCajaAbierta(idUsuario); err == nil {
+		return fmt.Errorf("ya tiene una caja abierta")
+	} else if err != sql.ErrNoRows {
+		return fmt.Errorf("error al verificar caja abierta: %v", err)
+	}
+	if _, err := s.Repo.AbrirSesionCaja(idUsuario, monto); err != nil {
+		return fmt.Errorf("error al abrir caja: %v", err)
+	}
+	return nil
+}
+
+// CerrarCaja cierra la caja abierta del usuario con el efectivo contado y sus
+// observaciones. Retorna el ID de la sesión cerrada para su reporte.
+func (s *Servicio) CerrarCaja(idUsuario int, montoContado, notas string) (int, error) {
+	contado, err := parsearMontoCaja(montoContado, "efectivo contado")
+	if err != nil {
+		return 0, err
+	}
+	notas = strings.TrimSpace(notas)
+	if len([]rune(notas)) > maxNotasCaja {
+		return 0, fmt.Errorf("las observaciones no pueden pasar de %d caracteres", maxNotasCaja)
+	}
+
+	sesion, err := s.Repo.ObtenerSesionCajaAbierta(idUsuario)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no tiene una caja abierta")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener caja abierta: %v", err)
+	}
+	ok, err := s.Repo.CerrarSesionCaja(sesion.IdSesion, idUsuario, contado, notas)
+	if err != nil {
+		return 0, fmt.Errorf("error al cerrar caja: %v", err)
+	}
+	if !ok {
+		return 0, fmt.Errorf("la caja ya estaba cerrada")
+	}
+	return sesion.IdSesion, nil
+}
+
+// ObtenerDatosCaja arma la pantalla de caja del usuario: su caja abierta con
+// los cobros en efectivo del turno, o el formulario para abrir una
+func (s *Servicio) ObtenerDatosCaja(idUsuario int, puedeRevisar bool) (models.DatosCaja, error) {
+	datos := models.DatosCaja{PuedeRevisar: puedeRevisar}
+
+	sesion, err := s.Repo.ObtenerSesionCajaAbierta(idUsuario)
+	if err == sql.ErrNoRows {
+		if ultima, err := s.Repo.ObtenerUltimaSesionCajaCerrada(idUsuario); err == nil {
+			datos.UltimaCerrada = &ultima
+		} else if err != sql.ErrNoRows {
+			return datos, fmt.Errorf("error al obtener última caja: %v", err)
+		}
+		return datos, nil
+	}
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener caja abierta: %v", err)
+	}
+	return s.completarDatosCaja(datos, sesion)
+}
+
+// ObtenerReporteCaja arma el reporte de cierre de una sesión. Un cajero solo
+// ve las suyas; un supervisor, todas. Retorna sql.ErrNoRows si no puede verla.
+func (s *Servicio) ObtenerReporteCaja(idSesion, idUsuario int, puedeRevisar bool) (models.DatosCaja, error) {
+	datos := models.DatosCaja{PuedeRevisar: puedeRevisar}
+
+	sesion, err := s.Repo.ObtenerSesionCaja(idSesion)
+	if err != nil {
+		return datos, err
+	}
+	if sesion.IdUsuario != idUsuario && !puedeRevisar {
+		return datos, sql.ErrNoRows
+	}
+	return s.completarDatosCaja(datos, sesion)
+}
+
+func (s *Servicio) completarDatosCaja(datos models.DatosCaja, sesion models.SesionCaja) (models.DatosCaja, error) {
+	datos.Sesion = &sesion
+
+	pagos, err := s.Repo.ObtenerPagosSesionCaja(sesion.IdSesion)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener pagos de la caja: %v", err)
+	}
+	datos.Pagos = pagos
+
+	ventas, err := s.Repo.ObtenerVentasSesionCaja(sesion.IdSesion)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener ventas de la caja: %v", err)
+	}
+	datos.Ventas = ventas
+	return datos, nil
+}
+
+// ObtenerSesionesCaja lista las sesiones de caja abiertas entre dos fechas del colegio
+func (s *Servicio) ObtenerSesionesCaja(desde, hasta time.Time) (models.DatosSesionesCaja, error) {
+	datos := models.DatosSesionesCaja{Desde: desde, Hasta: hasta}
+
+	// Las fechas son días del colegio: se pasan a instantes en su zona horaria
+	zona := utils.Semana().Zona
+	inicio := time.Date(desde.Year(), desde.Month(), desde.Day(), 0, 0, 0, 0, zona)
+	fin := time.Date(hasta.Year(), hasta.Month(), hasta.Day(), 0, 0, 0, 0, zona).AddDate(0, 0, 1)
+
+	sesiones, err := s.Repo.ObtenerSesionesCaja(inicio, fin)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener sesiones de caja: %v", err)
+	}
+	datos.Sesiones = sesiones
+	for _, sesion := range sesiones {
+		if !sesion.Abierta() {
+			datos.TotalDiferencia += sesion.Diferencia()
+		}
+	}
+	return datos, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"time"
+)
+
+// ObtenerCalendario carga los días de atención y los cierres del rango indicado
+func (s *Servicio) ObtenerCalendario(desde, hasta time.Time) (*models.Calendario, error) {
+	laborables, err := s.Repo.ObtenerDiasLaborables()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener días laborables: %v", err)
+	}
+
+	cierres, err := s.Repo.ObtenerDiasNoLaborables(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener días no laborables: %v", err)
+	}
+
+	return &models.Calendario{DiasLaborables: laborables, Cierres: cierres}, nil
+}
+
+// ObtenerCalendarioSemana carga el calendario de la semana que contiene la fecha
+func (s *Servicio) ObtenerCalendarioSemana(fecha time.Time) (*models.Calendario, error) {
+	inicio, fin := utils.CalcularSemanaDesdeFecha(fecha)
+	return s.ObtenerCalendario(inicio, fin)
+}
+
+// ValidarDiaLaborable retorna un error si el kiosco no atiende en la fecha
+func (s *Servicio) ValidarDiaLaborable(fecha time.Time) error {
+	calendario, err := s.ObtenerCalendario(fecha, fecha)
+	if err != nil {
+		return err
+	}
+	if motivo := calendario.MotivoCierre(fecha); motivo != "" {
+		return fmt.Errorf("no se registran consumos el %s: %s", utils.FormatearFechaLarga(fecha), motivo)
+	}
+	return nil
+}
+
+// CrearDiaNoLaborable valida y guarda un feriado o rango de cierre
+func (s *Servicio) CrearDiaNoLaborable(dia models.DiaNoLaborable) error {
+	if dia.Descripcion == "" {
+		return fmt.Errorf("la descripción es obligatoria")
+	}
+	if dia.FechaHasta.Before(dia.FechaDesde) {
+		return fmt.Errorf("la fecha de término es anterior a la de inicio")
+	}
+	switch dia.Tipo {
+	case models.CierreFeriado, models.CierreVacaciones, models.CierreOtro:
+	default:
+		return fmt.Errorf("tipo de cierre inválido: %s", dia.Tipo)
+	}
+	if err := s.Repo.InsertarDiaNoLaborable(dia); err != nil {
+		return fmt.Errorf("error al guardar día no laborable: %v", err)
+	}
+	return nil
+}
+
+// GuardarDiasLaborables actualiza los días de atención dentro de la semana escolar
+func (s *Servicio) GuardarDiasLaborables(dias []time.Weekday) error {
+	if len(dias) == 0 {
+		return fmt.Errorf("debe haber al menos un día de atención")
+	}
+	enSemana := make(map[time.Weekday]bool)
+	for _, d := range utils.DiasSemanaConfigurada() {
+		enSemana[d] = true
+	}
+	for _, d := range dias {
+		if !enSemana[d] {
+			return fmt.Errorf("día fuera de la semana escolar: %s", utils.NombreDia(d))
+		}
+	}
+	if err := s.Repo.GuardarDiasLaborables(dias); err != nil {
+		return fmt.Errorf("error al guardar días laborables: %v", err)
+	}
+	return nil
+}
+
+// ObtenerDatosCalendario prepara los cierres de un año para la página de calendario
+func (s *Servicio) ObtenerDatosCalendario(anio int) (*models.DatosCalendario, error) {
+	desde := time.Date(anio, time.January, 1, 0, 0, 0, 0, time.UTC)
+	hasta := time.Date(anio, time.December, 31, 0, 0, 0, 0, time.UTC)
+	calendario, err := s.ObtenerCalendario(desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DatosCalendario{
+		Anio:           anio,
+		DiasLaborables: calendario.DiasLaborables,
+		Cierres:        calendario.Cierres,
+	}, nil
+}
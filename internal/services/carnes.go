@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/qr"
+	"kiosco/internal/utils"
+)
+
+// CarnesPorHoja es cuántos carnés (tamaño tarjeta, 85,6 × 54 mm) entran en una hoja A4
+const CarnesPorHoja = 10
+
+// ObtenerDatosCarnes arma los carnés de los estudiantes activos del grado (0 =
+// todos), repartidos en hojas A4, con el QR de su código de carné
+func (s *Servicio) ObtenerDatosCarnes(idGrado int) (*models.DatosCarnes, error) {
+	estudiantes, err := s.Repo.ObtenerTodosEstudiantes()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+
+	datos := &models.DatosCarnes{
+		Grados:            utils.ObtenerGradosEstaticos(),
+		GradoSeleccionado: idGrado,
+	}
+	var hoja []models.Carne
+	for _, e := range estudiantes {
+		if !e.EstaActivo || (idGrado > 0 && e.IdGrado != idGrado) {
+			continue
+		}
+		codigo := CodigoEstudiante(e)
+		simbolo, err := qr.Codificar(codigo)
+		if err != nil {
+			return nil, fmt.Errorf("error al generar QR de %s: %v", codigo, err)
+		}
+		hoja = append(hoja, models.Carne{Estudiante: e, Codigo: codigo, QR: simbolo.SVG()})
+		if len(hoja) == CarnesPorHoja {
+			datos.Hojas = append(datos.Hojas, hoja)
+			hoja = nil
+		}
+		datos.Total++
+	}
+	if len(hoja) > 0 {
+		datos.Hojas = append(datos.Hojas, hoja)
+	}
+	return datos, nil
+}
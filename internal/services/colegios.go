@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"log"
+	"time"
+)
+
+// ObtenerVentasColegio resume las ventas, el margen, lo cobrado y el saldo por
+// cobrar del colegio de este servicio entre desde y hasta
+func (s *Servicio) ObtenerVentasColegio(desde, hasta time.Time) (models.VentasColegio, error) {
+	var v models.VentasColegio
+
+	margen, err := s.ObtenerReporteMargen(desde, hasta)
+	if err != nil {
+		return v, err
+	}
+	v.Consumos = margen.TotalIngresos + margen.TotalPlanes
+	v.Contado = margen.TotalContado
+	v.Margen = margen.TotalMargen + margen.MargenContado
+
+	pagos, err := s.Repo.ObtenerPagosRango(desde, hasta)
+	if err != nil {
+		return v, fmt.Errorf("error al obtener pagos: %v", err)
+	}
+	for _, p := range pagos {
+		v.Cobrado += p.Monto
+	}
+
+	cuentas, err := s.Repo.ObtenerEstudiantesActivos()
+	if err != nil {
+		return v, fmt.Errorf("error al obtener cuentas: %v", err)
+	}
+	v.Cuentas = len(cuentas)
+
+	// Solo las deudas: un saldo a favor no descuenta lo que deben los demás
+	saldos, err := s.Repo.ObtenerDeudasAnterioresBatch(0, hasta.AddDate(0, 0, 1))
+	if err != nil {
+		return v, fmt.Errorf("error al obtener saldos: %v", err)
+	}
+	for _, saldo := range saldos {
+		if saldo > 0 {
+			v.SaldoPorCobrar += saldo
+		}
+	}
+	return v, nil
+}
+
+// ServicioColegio es el servicio de uno de los colegios del binario
+type ServicioColegio struct {
+	Nombre   string
+	Servicio *Servicio
+}
+
+// ObtenerDatosSuperadmin suma las ventas de todos los colegios. Un colegio cuya
+// base falla queda con su error en la lista y no detiene a los demás.
+func ObtenerDatosSuperadmin(colegios []ServicioColegio, desde, hasta time.Time) models.DatosSuperadmin {
+	datos := models.DatosSuperadmin{Desde: desde, Hasta: hasta}
+	datos.Total.Colegio = "Total"
+	for _, c := range colegios {
+		v, err := c.Servicio.ObtenerVentasColegio(desde, hasta)
+		if err != nil {
+			log.Printf("Error al obtener ventas del colegio %q: %v", c.Nombre, err)
+			v = models.VentasColegio{Error: "No se pudo leer su base de datos"}
+		}
+		v.Colegio = c.Nombre
+		datos.Colegios = append(datos.Colegios, v)
+
+		datos.Total.Cuentas += v.Cuentas
+		datos.Total.Consumos += v.Consumos
+		datos.Total.Contado += v.Contado
+		datos.Total.Margen += v.Margen
+		datos.Total.Cobrado += v.Cobrado
+		datos.Total.SaldoPorCobrar += v.SaldoPorCobrar
+	}
+	return datos
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+)
+
+// ObtenerDatosCombos prepara los combos con sus componentes
+func (s *Servicio) ObtenerDatosCombos() (*models.DatosCombos, error) {
+	productos, err := s.Repo.ObtenerProductosActivos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	componentes, err := s.Repo.ObtenerComponentesCombos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener componentes: %v", err)
+	}
+
+	var combos []models.Combo
+	for _, p := range productos {
+		if p.EsCombo {
+			combos = append(combos, models.Combo{Producto: p, Componentes: componentes[p.IdProducto]})
+		}
+	}
+
+	return &models.DatosCombos{Combos: combos, Productos: productos}, nil
+}
+
+// AgregarComponenteCombo valida y agrega un componente a un combo.
+// No se permiten combos anidados: un combo no puede ser componente ni tener
+// como componente a otro combo.
+func (s *Servicio) AgregarComponenteCombo(idCombo, idComponente, cantidad int) error {
+	if idCombo == idComponente {
+		return fmt.Errorf("un producto no puede ser componente de sí mismo")
+	}
+	if cantidad <= 0 {
+		return fmt.Errorf("la cantidad debe ser mayor a cero")
+	}
+
+	componente, err := s.Repo.ObtenerProductoPorId(idComponente)
+	if err != nil {
+		return fmt.Errorf("componente no encontrado: %v", err)
+	}
+	if componente.EsCombo {
+		return fmt.Errorf("%s es un combo y no puede ser componente", componente.Nombre)
+	}
+
+	esComponente, err := s.Repo.EsComponente(idCombo)
+	if err != nil {
+		return fmt.Errorf("error al verificar combo: %v", err)
+	}
+	if esComponente {
+		return fmt.Errorf("el producto ya es componente de otro combo")
+	}
+
+	if err := s.Repo.GuardarComponenteCombo(idCombo, idComponente, cantidad); err != nil {
+		return fmt.Errorf("error al guardar componente: %v", err)
+	}
+	return nil
+}
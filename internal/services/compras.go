@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"sort"
+	"time"
+)
+
+// RegistrarCompraDesdeFormulario valida y registra una compra a proveedor.
+// Las líneas con cantidad cero se ignoran; debe quedar al menos una.
+func (s *Servicio) RegistrarCompraDesdeFormulario(idProveedor int, fecha time.Time, nota string, items []models.ItemCompra) error {
+	if idProveedor <= 0 {
+		return fmt.Errorf("debe seleccionar un proveedor")
+	}
+
+	validos := make([]models.ItemCompra, 0, len(items))
+	for _, item := range items {
+		if item.Cantidad == 0 {
+			continue
+		}
+		if item.Cantidad < 0 || item.CostoUnitario < 0 {
+			return fmt.Errorf("cantidad y costo no pueden ser negativos")
+		}
+		validos = append(validos, item)
+	}
+	if len(validos) == 0 {
+		return fmt.Errorf("la compra no tiene ítems")
+	}
+
+	_, err := s.Repo.RegistrarCompra(models.Compra{
+		IdProveedor: idProveedor,
+		FechaCompra: fecha,
+		Nota:        nota,
+		Items:       validos,
+	})
+	return err
+}
+
+// ObtenerDatosCompras prepara los datos para la página de compras
+func (s *Servicio) ObtenerDatosCompras() (*models.DatosCompras, error) {
+	proveedores, err := s.Repo.ObtenerProveedoresActivos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener proveedores: %v", err)
+	}
+
+	productos, err := s.Repo.ObtenerProductosActivos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	compras, err := s.Repo.ObtenerComprasRecientes(30)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener compras: %v", err)
+	}
+
+	return &models.DatosCompras{
+		Proveedores: proveedores,
+		Productos:   productos,
+		Compras:     compras,
+	}, nil
+}
+
+// ObtenerReporteMargen calcula el margen bruto por producto y por semana en un rango
+func (s *Servicio) ObtenerReporteMargen(desde, hasta time.Time) (*models.DatosReporteMargen, error) {
+	porProducto, err := s.Repo.ObtenerMargenPorProducto(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener margen por producto: %v", err)
+	}
+
+	porPeriodo, err := s.Repo.ObtenerMargenPorSemana(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener margen por semana: %v", err)
+	}
+
+	totalCompras, err := s.Repo.ObtenerTotalCompras(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener total de compras: %v", err)
+	}
+
+	contadoPorSemana, err := s.Repo.ObtenerContadoPorSemana(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener ventas al contado por semana: %v", err)
+	}
+	for i := range porPeriodo {
+		inicio := utils.FormatearFechaCompleta(porPeriodo[i].Inicio)
+		porPeriodo[i].Contado = contadoPorSemana[inicio]
+		delete(contadoPorSemana, inicio)
+	}
+	// Semanas con ventas al contado pero sin consumos a cuenta
+	for inicio, total := range contadoPorSemana {
+		fecha, _ := utils.ParsearFecha(inicio)
+		porPeriodo = append(porPeriodo, models.MargenPeriodo{Inicio: fecha, Contado: total})
+	}
+	sort.Slice(porPeriodo, func(i, j int) bool { return porPeriodo[i].Inicio.Before(porPeriodo[j].Inicio) })
+
+	// Días de atención de cada semana para el promedio diario
+	calendario, err := s.ObtenerCalendario(desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	for i := range porPeriodo {
+		for _, dia := range calendario.DiasAtencion(utils.DiasDeSemana(porPeriodo[i].Inicio)) {
+			if calendario.EsLaborable(dia) {
+				porPeriodo[i].DiasAtencion++
+			}
+		}
+	}
+
+	totalPlanes, err := s.Repo.ObtenerTotalCargosPlan(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cargos de planes: %v", err)
+	}
+
+	unidades, err := s.Repo.ObtenerUnidadesPorProducto(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener unidades por producto: %v", err)
+	}
+
+	contadoPorProducto, err := s.Repo.ObtenerContadoPorProducto(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener ventas al contado por producto: %v", err)
+	}
+
+	datos := &models.DatosReporteMargen{
+		Desde:              desde,
+		Hasta:              hasta,
+		PorProducto:        porProducto,
+		PorPeriodo:         porPeriodo,
+		TotalCompras:       totalCompras,
+		TotalPlanes:        totalPlanes,
+		Unidades:           unidades,
+		ContadoPorProducto: contadoPorProducto,
+	}
+	for _, m := range porProducto {
+		datos.TotalIngresos += m.Ingresos
+		datos.TotalCosto += m.Costo
+	}
+	datos.TotalMargen = datos.TotalIngresos - datos.TotalCosto
+	for _, m := range contadoPorProducto {
+		datos.TotalContado += m.Ingresos
+		datos.CostoContado += m.Costo
+	}
+	datos.MargenContado = datos.TotalContado - datos.CostoContado
+
+	return datos, nil
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	movimientosResueltos = 30 // Abonos confirmados o descartados que se muestran
+	extractosRecientes   = 10
+)
+
+// OrigenesExtracto son las fuentes de extractos que se pueden importar
+var OrigenesExtracto = []string{"banco", "yape", "plin", "otro"}
+
+// CodigoPago es la referencia que el apoderado escribe en la transferencia o en
+// el mensaje del Yape para que el abono se asigne solo: "K" + id del estudiante
+// con cuatro cifras + dígito verificador (K00017 para el estudiante 1).
+func CodigoPago(idEstudiante int) string {
+	cifras := fmt.Sprintf("%04d", idEstudiante)
+	return "K" + cifras + strconv.Itoa(digitoVerificador(cifras))
+}
+
+// digitoVerificador pondera las cifras 3,1,3,1... desde la derecha (como EAN) para
+// que un dígito mal escrito o dos cifras invertidas no apunten a otro estudiante
+func digitoVerificador(cifras string) int {
+	suma := 0
+	for i := len(cifras) - 1; i >= 0; i-- {
+		d := int(cifras[i] - '0')
+		if (len(cifras)-1-i)%2 == 0 {
+			d *= 3
+		}
+		suma += d
+	}
+	return (10 - suma%10) % 10
+}
+
+var patronCodigoPago = regexp.MustCompile(`(?i)\bK[\s-]?(\d{5,7})\b`)
+
+// buscarCodigoPago retorna el estudiante del primer código de pago válido del texto (0 si no hay)
+func buscarCodigoPago(texto string) int {
+	for _, m := range patronCodigoPago.FindAllStringSubmatch(texto, -1) {
+		cifras, verificador := m[1][:len(m[1])-1], int(m[1][len(m[1])-1]-'0')
+		if digitoVerificador(cifras) != verificador {
+			continue
+		}
+		if id, err := strconv.Atoi(cifras); err == nil && id > 0 {
+			return id
+		}
+	}
+	return 0
+}
+
+// Nombres de columna aceptados en los extractos (sin tildes, en minúsculas)
+var (
+	columnasFecha       = []string{"fecha", "fecha de operacion", "fecha operacion", "fecha valor", "fecha y hora"}
+	columnasMonto       = []string{"abono", "abonos", "monto", "importe", "ingreso", "ingresos", "monto (s/)", "importe (s/)"}
+	columnasDescripcion = []string{"descripcion", "concepto", "glosa", "mensaje", "detalle", "referencia", "descripcion operacion"}
+	columnasOrdenante   = []string{"ordenante", "origen", "nombre", "de", "remitente", "nombre origen"}
+)
+
+func normalizarColumna(nombre string) string {
+	nombre = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(nombre, "\ufeff")))
+	return strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n").Replace(nombre)
+}
+
+func indiceColumna(cabecera []string, nombres []string) int {
+	for _, nombre := range nombres {
+		for i, c := range cabecera {
+			if normalizarColumna(c) == nombre {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parsearMonto interpreta importes como "S/ 1,234.50", "1.234,50", "12,5" o "15".
+// Con un solo tipo de separador, uno seguido de 1 o 2 cifras es el decimal y
+// grupos de 3 cifras son miles; cualquier otra forma (ej. "12,5678") se rechaza
+// en vez de adivinar, porque el monto decide el estudiante sugerido y el pago.
+func parsearMonto(valor string) (float64, error) {
+	v := strings.NewReplacer("S/.", "", "S/", "", "PEN", "", " ", "", "\u00a0", "").Replace(strings.TrimSpace(valor))
+	coma, punto := strings.LastIndex(v, ","), strings.LastIndex(v, ".")
+	switch {
+	case coma >= 0 && punto >= 0:
+		if coma > punto {
+			v = strings.ReplaceAll(v, ".", "")
+			v = strings.Replace(v, ",", ".", 1)
+		} else {
+			v = strings.ReplaceAll(v, ",", "")
+		}
+	case coma >= 0:
+		normalizado, err := separadorUnico(v, ",")
+		if err != nil {
+			return 0, fmt.Errorf("monto ambiguo: %q", valor)
+		}
+		v = normalizado
+	case punto >= 0:
+		normalizado, err := separadorUnico(v, ".")
+		if err != nil {
+			return 0, fmt.Errorf("monto ambiguo: %q", valor)
+		}
+		v = normalizado
+	}
+	monto, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("monto inválido: %q", valor)
+	}
+	return monto, nil
+}
+
+// separadorUnico resuelve un monto con un solo tipo de separador: si aparece
+// una vez seguido de 1 o 2 cifras es el decimal; si todos los grupos que
+// separa tienen 3 cifras (y el primero de 1 a 3) son miles.
+func separadorUnico(v, separador string) (string, error) {
+	partes := strings.Split(v, separador)
+	if len(partes) == 2 && len(partes[1]) >= 1 && len(partes[1]) <= 2 {
+		return partes[0] + "." + partes[1], nil
+	}
+	if len(partes[0]) < 1 || len(strings.TrimPrefix(partes[0], "-")) > 3 {
+		return "", fmt.Errorf("separador inválido")
+	}
+	for _, grupo := range partes[1:] {
+		if len(grupo) != 3 {
+			return "", fmt.Errorf("separador inválido")
+		}
+	}
+	return strings.Join(partes, ""), nil
+}
+
+var formatosFechaExtracto = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "02/01/06", "2/1/06"}
+
+// parsearFechaExtracto interpreta la fecha de un abono; la hora, si viene, se ignora
+func parsearFechaExtracto(valor string) (time.Time, error) {
+	v := strings.TrimSpace(valor)
+	if i := strings.IndexAny(v, " T"); i > 0 {
+		v = v[:i]
+	}
+	for _, formato := range formatosFechaExtracto {
+		if f, err := time.Parse(formato, v); err == nil {
+			return f, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("fecha inválida: %q", valor)
+}
+
+// leerExtracto extrae los abonos de un CSV de banco o billetera. Detecta el
+// separador (coma o punto y coma) y salta las líneas previas a la cabecera. Los
+// cargos y montos en cero se ignoran. La huella de cada abono incluye cuántas
+// veces se repite en el archivo, para no confundir dos pagos iguales del mismo día.
+func leerExtracto(contenido []byte) ([]models.MovimientoExtracto, error) {
+	contenido = bytes.TrimPrefix(contenido, []byte("\ufeff"))
+	muestra := contenido[:min(len(contenido), 2048)] // La primera línea puede ser un título sin separadores
+	lector := csv.NewReader(bytes.NewReader(contenido))
+	if bytes.Count(muestra, []byte(";")) > bytes.Count(muestra, []byte(",")) {
+		lector.Comma = ';'
+	}
+	lector.FieldsPerRecord = -1
+	lector.LazyQuotes = true
+
+	fecha, monto, descripcion, ordenante := -1, -1, -1, -1
+	repeticiones := make(map[string]int)
+	var movimientos []models.MovimientoExtracto
+	for fila := 1; ; fila++ {
+		registro, err := lector.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("línea %d: %v", fila, err)
+		}
+
+		if fecha < 0 || monto < 0 {
+			fecha, monto = indiceColumna(registro, columnasFecha), indiceColumna(registro, columnasMonto)
+			descripcion, ordenante = indiceColumna(registro, columnasDescripcion), indiceColumna(registro, columnasOrdenante)
+			continue
+		}
+		if len(registro) <= fecha || len(registro) <= monto || strings.TrimSpace(registro[monto]) == "" {
+			continue
+		}
+
+		m := models.MovimientoExtracto{}
+		if m.Fecha, err = parsearFechaExtracto(registro[fecha]); err != nil {
+			return nil, fmt.Errorf("línea %d: %v", fila, err)
+		}
+		if m.Monto, err = parsearMonto(registro[monto]); err != nil {
+			return nil, fmt.Errorf("línea %d: %v", fila, err)
+		}
+		m.Monto = math.Round(m.Monto*100) / 100
+		if m.Monto <= 0 {
+			continue
+		}
+		if descripcion >= 0 && descripcion < len(registro) {
+			m.Descripcion = strings.TrimSpace(registro[descripcion])
+		}
+		if ordenante >= 0 && ordenante < len(registro) {
+			m.Ordenante = strings.TrimSpace(registro[ordenante])
+		}
+
+		clave := fmt.Sprintf("%s|%.2f|%s|%s", m.Fecha.Format("2006-01-02"), m.Monto, m.Descripcion, m.Ordenante)
+		repeticiones[clave]++
+		suma := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", clave, repeticiones[clave])))
+		m.Huella = hex.EncodeToString(suma[:16])
+		movimientos = append(movimientos, m)
+	}
+	if fecha < 0 || monto < 0 {
+		return nil, fmt.Errorf("no se encontró la cabecera: se necesitan las columnas fecha y monto (o abono/importe)")
+	}
+	return movimientos, nil
+}
+
+// sugerirEstudiantes asigna a cada abono el estudiante de su código de pago o, si
+// no trae código, el único estudiante activo cuyo saldo al inicio de la semana o
+// al día del abono es igual al monto. Si hay varios candidatos queda sin sugerencia.
+func (s *Servicio) sugerirEstudiantes(movimientos []models.MovimientoExtracto) error {
+	estudiantes, err := s.Repo.ObtenerEstudiantesActivos()
+	if err != nil {
+		return fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+	activos := make(map[int]bool, len(estudiantes))
+	for _, e := range estudiantes {
+		activos[e.IdEstudiante] = true
+	}
+
+	saldos := make(map[string]map[int]float64) // Saldos por fecha de corte
+	saldosAl := func(fecha time.Time) (map[int]float64, error) {
+		clave := fecha.Format("2006-01-02")
+		if _, ok := saldos[clave]; !ok {
+			deudas, err := s.Repo.ObtenerDeudasAnterioresBatch(0, fecha)
+			if err != nil {
+				return nil, err
+			}
+			saldos[clave] = deudas
+		}
+		return saldos[clave], nil
+	}
+
+	for i := range movimientos {
+		m := &movimientos[i]
+		if id := buscarCodigoPago(m.Descripcion + " " + m.Ordenante); activos[id] {
+			m.IdEstudiante, m.Criterio = id, models.CriterioCodigo
+			continue
+		}
+
+		inicio, _ := utils.CalcularSemanaDesdeFecha(m.Fecha)
+		candidatos := make(map[int]bool)
+		for _, corte := range []time.Time{inicio, m.Fecha} {
+			deudas, err := saldosAl(corte)
+			if err != nil {
+				return fmt.Errorf("error al calcular saldos: %v", err)
+			}
+			for id, deuda := range deudas {
+				if math.Abs(deuda-m.Monto) < 0.005 {
+					candidatos[id] = true
+				}
+			}
+		}
+		if len(candidatos) == 1 {
+			for id := range candidatos {
+				m.IdEstudiante, m.Criterio = id, models.CriterioMonto
+			}
+		}
+	}
+	return nil
+}
+
+// ImportarExtracto lee un CSV de banco o billetera, sugiere el estudiante de cada
+// abono y lo deja pendiente de conciliar. Los abonos ya importados en otro
+// extracto se omiten.
+func (s *Servicio) ImportarExtracto(nombreArchivo, origen string, contenido []byte) (models.Extracto, error) {
+	valido := false
+	for _, o := range OrigenesExtracto {
+		valido = valido || o == origen
+	}
+	if !valido {
+		return models.Extracto{}, fmt.Errorf("origen inválido")
+	}
+
+	movimientos, err := leerExtracto(contenido)
+	if err != nil {
+		return models.Extracto{}, err
+	}
+	if len(movimientos) == 0 {
+		return models.Extracto{}, fmt.Errorf("el archivo no tiene abonos")
+	}
+	if err := s.sugerirEstudiantes(movimientos); err != nil {
+		return models.Extracto{}, err
+	}
+
+	extracto, err := s.Repo.InsertarExtracto(models.Extracto{NombreArchivo: nombreArchivo, Origen: origen}, movimientos)
+	if err != nil {
+		return models.Extracto{}, fmt.Errorf("error al guardar extracto: %v", err)
+	}
+	return extracto, nil
+}
+
+// ObtenerDatosConciliacion prepara la página de conciliación
+func (s *Servicio) ObtenerDatosConciliacion() (*models.DatosConciliacion, error) {
+	datos := &models.DatosConciliacion{Origenes: OrigenesExtracto}
+	var err error
+	if datos.Pendientes, err = s.Repo.ObtenerMovimientosPendientes(); err != nil {
+		return nil, fmt.Errorf("error al obtener abonos pendientes: %v", err)
+	}
+	if datos.Resueltos, err = s.Repo.ObtenerMovimientosResueltos(movimientosResueltos); err != nil {
+		return nil, fmt.Errorf("error al obtener abonos conciliados: %v", err)
+	}
+	if datos.Extractos, err = s.Repo.ObtenerExtractos(extractosRecientes); err != nil {
+		return nil, fmt.Errorf("error al obtener extractos: %v", err)
+	}
+	if datos.Estudiantes, err = s.Repo.ObtenerEstudiantesActivos(); err != nil {
+		return nil, fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+	return datos, nil
+}
+
+// ConfirmarMovimiento registra el abono como pago del estudiante, con la fecha
+// del extracto. Si idEstudiante difiere de la sugerencia, queda como elección
+// manual. Retorna sql.ErrNoRows si el abono ya no estaba pendiente.
+func (s *Servicio) ConfirmarMovimiento(idMovimiento, idEstudiante int) (models.Pago, error) {
+	if idEstudiante <= 0 {
+		return models.Pago{}, fmt.Errorf("seleccione un estudiante")
+	}
+	m, err := s.Repo.ObtenerMovimientoExtracto(idMovimiento)
+	if err != nil {
+		return models.Pago{}, err
+	}
+	criterio := m.Criterio
+	if idEstudiante != m.IdEstudiante {
+		criterio = ""
+	}
+
+	ok, err := s.Repo.ReservarMovimiento(idMovimiento, idEstudiante, criterio)
+	if err != nil {
+		return models.Pago{}, fmt.Errorf("error al confirmar abono: %v", err)
+	}
+	if !ok {
+		return models.Pago{}, sql.ErrNoRows
+	}
+
+	pago, err := s.RegistrarPagoDesdeFormulario(idEstudiante, m.Monto, m.Fecha, metodoDeOrigen(m.Origen), 0)
+	if err != nil {
+		// Sin pago el abono vuelve a quedar pendiente
+		if _, errRev := s.Repo.CambiarEstadoMovimiento(idMovimiento, models.MovimientoConfirmado, models.MovimientoPendiente); errRev != nil {
+			return models.Pago{}, fmt.Errorf("error al registrar pago: %v (y al revertir el abono: %v)", err, errRev)
+		}
+		return models.Pago{}, fmt.Errorf("error al registrar pago: %v", err)
+	}
+	if err := s.Repo.GuardarPagoMovimiento(idMovimiento, pago.IdPago); err != nil {
+		return pago, fmt.Errorf("error al guardar pago del abono: %v", err)
+	}
+	return pago, nil
+}
+
+// metodoDeOrigen es el método de pago de un abono según el origen de su extracto
+func metodoDeOrigen(origen string) string {
+	switch origen {
+	case "banco":
+		return models.MetodoTransferencia
+	case "yape", "plin":
+		return models.MetodoBilletera
+	}
+	return models.MetodoOtro
+}
+
+// ConfirmarSugeridos confirma todos los abonos pendientes que tienen estudiante
+// sugerido. Retorna cuántos se confirmaron.
+func (s *Servicio) ConfirmarSugeridos() (int, error) {
+	pendientes, err := s.Repo.ObtenerMovimientosPendientes()
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener abonos pendientes: %v", err)
+	}
+	confirmados := 0
+	for _, m := range pendientes {
+		if m.IdEstudiante == 0 {
+			continue
+		}
+		_, err := s.ConfirmarMovimiento(m.IdMovimiento, m.IdEstudiante)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return confirmados, err
+		}
+		confirmados++
+	}
+	return confirmados, nil
+}
+
+// DescartarMovimiento marca un abono pendiente como ajeno al kiosco
+func (s *Servicio) DescartarMovimiento(idMovimiento int) (bool, error) {
+	return s.Repo.CambiarEstadoMovimiento(idMovimiento, models.MovimientoPendiente, models.MovimientoDescartado)
+}
+
+// ReabrirMovimiento devuelve a pendiente un abono descartado
+func (s *Servicio) ReabrirMovimiento(idMovimiento int) (bool, error) {
+	return s.Repo.CambiarEstadoMovimiento(idMovimiento, models.MovimientoDescartado, models.MovimientoPendiente)
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"kiosco/internal/correo"
+	"kiosco/internal/models"
+	"kiosco/internal/notificador"
+	"kiosco/internal/utils"
+	"kiosco/templates/correos"
+	"log"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	intervaloCorreos  = 30 * time.Second
+	loteCorreos       = 20 // Correos por ciclo; el servidor SMTP suele limitar la tasa
+	maxIntentosCorreo = 8  // Con la espera de los webhooks: ~1 hora antes de marcar fallido
+
+	claveUmbralRecordatorio = "correos_umbral_recordatorio"
+	claveEstadoAutomatico   = "correos_estado_automatico" // "1" = enviar al cerrar la semana
+	claveSemanaEnviada      = "correos_semana_enviada"    // Inicio de la última semana enviada automáticamente
+	umbralPorDefecto        = 20.0
+)
+
+// ObtenerUmbralRecordatorio retorna el saldo a partir del cual se envían recordatorios
+func (s *Servicio) ObtenerUmbralRecordatorio() float64 {
+	valor, err := s.Repo.ObtenerConfiguracion(claveUmbralRecordatorio)
+	if err != nil || valor == "" {
+		return umbralPorDefecto
+	}
+	umbral, err := strconv.ParseFloat(valor, 64)
+	if err != nil {
+		return umbralPorDefecto
+	}
+	return umbral
+}
+
+// GuardarAjustesCorreos guarda el umbral de recordatorios y el envío automático
+func (s *Servicio) GuardarAjustesCorreos(umbral float64, automatico bool) error {
+	if umbral < 0 {
+		return fmt.Errorf("el umbral no puede ser negativo")
+	}
+	if err := s.Repo.GuardarConfiguracion(claveUmbralRecordatorio, strconv.FormatFloat(umbral, 'f', 2, 64)); err != nil {
+		return fmt.Errorf("error al guardar umbral: %v", err)
+	}
+	valor := "0"
+	if automatico {
+		valor = "1"
+		// Solo semanas que cierren desde ahora: no se envía en bloque lo ya cerrado
+		inicio, _ := ultimaSemanaCerrada()
+		if err := s.Repo.GuardarConfiguracion(claveSemanaEnviada, utils.FormatearFechaCompleta(inicio)); err != nil {
+			return fmt.Errorf("error al guardar ajustes: %v", err)
+		}
+	}
+	if err := s.Repo.GuardarConfiguracion(claveEstadoAutomatico, valor); err != nil {
+		return fmt.Errorf("error al guardar ajustes: %v", err)
+	}
+	return nil
+}
+
+// ObtenerDatosCorreos prepara apoderados, ajustes y el registro de envíos
+func (s *Servicio) ObtenerDatosCorreos(estado string) (*models.DatosCorreos, error) {
+	apoderados, err := s.Repo.ObtenerApoderados()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener apoderados: %v", err)
+	}
+	estudiantes, err := s.Repo.ObtenerEstudiantesPorGrado(0)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+	registro, err := s.Repo.ObtenerCorreos(estado, 100)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener correos: %v", err)
+	}
+	automatico, err := s.Repo.ObtenerConfiguracion(claveEstadoAutomatico)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener ajustes: %v", err)
+	}
+
+	config := correo.Vigente()
+	inicio, _ := ultimaSemanaCerrada()
+	return &models.DatosCorreos{
+		SMTPConfigurado:  config.Habilitado(),
+		Remitente:        config.Remitente,
+		Umbral:           s.ObtenerUmbralRecordatorio(),
+		EstadoAutomatico: automatico == "1",
+		Apoderados:       apoderados,
+		Estudiantes:      estudiantes,
+		Correos:          registro,
+		Estado:           estado,
+		FechaSemana:      utils.FormatearFechaCompleta(inicio),
+	}, nil
+}
+
+// AgregarApoderado registra un contacto de un estudiante. Basta con un correo o
+// un teléfono; el teléfono se guarda en formato internacional.
+func (s *Servicio) AgregarApoderado(idEstudiante int, nombre, email, telefono string) error {
+	nombre = strings.TrimSpace(nombre)
+	if nombre == "" {
+		return fmt.Errorf("el nombre es obligatorio")
+	}
+	email = strings.TrimSpace(email)
+	telefono = strings.TrimSpace(telefono)
+	if email == "" && telefono == "" {
+		return fmt.Errorf("indique un correo o un teléfono")
+	}
+	if email != "" {
+		direccion, err := mail.ParseAddress(email)
+		if err != nil {
+			return fmt.Errorf("correo inválido")
+		}
+		email = strings.ToLower(direccion.Address)
+	}
+	if telefono != "" {
+		numero, err := notificador.NormalizarTelefono(telefono)
+		if err != nil {
+			return err
+		}
+		telefono = numero
+	}
+	if _, err := s.Repo.ObtenerEstudiantePorId(idEstudiante); err != nil {
+		return fmt.Errorf("estudiante no encontrado")
+	}
+
+	apoderado := models.Apoderado{
+		IdEstudiante: idEstudiante,
+		Nombre:       nombre,
+		Email:        email,
+		Telefono:     telefono,
+		TokenBaja:    generarIdAleatorio(16),
+	}
+	if err := s.Repo.InsertarApoderado(apoderado); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return fmt.Errorf("ese correo o teléfono ya está registrado para el estudiante")
+		}
+		return fmt.Errorf("error al guardar apoderado: %v", err)
+	}
+	return nil
+}
+
+// DarDeBaja procesa el enlace de baja de un correo o de un mensaje
+// (canal "mensajes"); cada canal se da de baja por separado.
+func (s *Servicio) DarDeBaja(token, canal string) (models.Apoderado, error) {
+	apoderado, err := s.Repo.ObtenerApoderadoPorTokenBaja(token)
+	if err != nil {
+		return apoderado, err
+	}
+	if canal == "mensajes" {
+		err = s.Repo.CambiarRecepcionMensajes(apoderado.IdApoderado, false)
+		apoderado.RecibeMensajes = false
+	} else {
+		err = s.Repo.CambiarRecepcionApoderado(apoderado.IdApoderado, false)
+		apoderado.RecibeCorreos = false
+	}
+	if err != nil {
+		return apoderado, fmt.Errorf("error al dar de baja: %v", err)
+	}
+	return apoderado, nil
+}
+
+// urlPublica es la base de los enlaces en correos y mensajes: la del colegio o,
+// si no tiene, la URL_PUBLICA general
+func (s *Servicio) urlPublica() string {
+	if s.URLPublica != "" {
+		return s.URLPublica
+	}
+	return correo.Vigente().URLPublica
+}
+
+// urlBaja arma el enlace de baja de un apoderado ("" sin URL pública)
+func (s *Servicio) urlBaja(token string) string {
+	base := s.urlPublica()
+	if base == "" {
+		return ""
+	}
+	return base + "/correos/baja?t=" + url.QueryEscape(token)
+}
+
+// apoderadosPorEstudiante agrupa los apoderados que aceptan correos
+func (s *Servicio) apoderadosPorEstudiante() (map[int][]models.Apoderado, error) {
+	apoderados, err := s.Repo.ObtenerApoderados()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener apoderados: %v", err)
+	}
+	porEstudiante := make(map[int][]models.Apoderado)
+	for _, a := range apoderados {
+		if a.AceptaCorreos() {
+			porEstudiante[a.IdEstudiante] = append(porEstudiante[a.IdEstudiante], a)
+		}
+	}
+	return porEstudiante, nil
+}
+
+// EncolarEstadosCuenta deja en cola el estado de cuenta de la semana que
+// contiene la fecha para los apoderados de los estudiantes activos (idGrado 0 =
+// todos). Omite estudiantes sin movimientos ni saldo, y no repite correos ya
+// encolados para esa semana. Retorna cuántos correos se encolaron.
+func (s *Servicio) EncolarEstadosCuenta(fecha time.Time, idGrado int) (int, error) {
+	inicio, fin := utils.CalcularSemanaDesdeFecha(fecha)
+
+	porEstudiante, err := s.apoderadosPorEstudiante()
+	if err != nil {
+		return 0, err
+	}
+	estudiantes, err := s.Repo.ObtenerEstudiantesPorGrado(idGrado)
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+
+	encolados := 0
+	for _, e := range estudiantes {
+		apoderados := porEstudiante[e.IdEstudiante]
+		if len(apoderados) == 0 {
+			continue
+		}
+		comprobante, err := s.ObtenerDatosConsumoSemanal(e.IdEstudiante, inicio, fin)
+		if err != nil {
+			return encolados, err
+		}
+		if len(comprobante.ConsumosPorDia) == 0 && comprobante.Pagos == 0 && comprobante.Total == 0 {
+			continue
+		}
+
+		for _, a := range apoderados {
+			datos := correos.EstadoCuenta{Apoderado: a.Nombre, Comprobante: *comprobante, URLBaja: s.urlBaja(a.TokenBaja)}
+			texto, err := correos.TextoEstadoCuenta(datos)
+			if err != nil {
+				return encolados, fmt.Errorf("error al armar estado de cuenta: %v", err)
+			}
+			var html bytes.Buffer
+			if err := correos.EstadoCuentaHTML(datos).Render(context.Background(), &html); err != nil {
+				return encolados, fmt.Errorf("error al armar estado de cuenta: %v", err)
+			}
+			nuevo, err := s.Repo.EncolarCorreo(models.Correo{
+				Tipo:         models.CorreoEstadoCuenta,
+				IdApoderado:  a.IdApoderado,
+				IdEstudiante: e.IdEstudiante,
+				Semana:       &inicio,
+				Destinatario: a.Email,
+				Asunto:       correos.AsuntoEstadoCuenta(datos),
+				CuerpoTexto:  texto,
+				CuerpoHTML:   html.String(),
+				URLBaja:      datos.URLBaja,
+			})
+			if err != nil {
+				return encolados, fmt.Errorf("error al encolar correo: %v", err)
+			}
+			if nuevo {
+				encolados++
+			}
+		}
+	}
+	return encolados, nil
+}
+
+// EncolarRecordatorios deja en cola un recordatorio para los apoderados de los
+// estudiantes cuyo saldo actual supera el umbral. Como máximo uno por semana.
+func (s *Servicio) EncolarRecordatorios() (int, error) {
+	umbral := s.ObtenerUmbralRecordatorio()
+	hoy := utils.Hoy()
+	inicio, fin := utils.CalcularSemanaDesdeFecha(hoy)
+
+	porEstudiante, err := s.apoderadosPorEstudiante()
+	if err != nil {
+		return 0, err
+	}
+	datos, err := s.ObtenerDatosVistaPrincipal(inicio, fin, 0, "")
+	if err != nil {
+		return 0, err
+	}
+
+	encolados := 0
+	for _, e := range datos.EstudiantesConData {
+		if e.Total <= umbral {
+			continue
+		}
+		for _, a := range porEstudiante[e.IdEstudiante] {
+			recordatorio := correos.RecordatorioDeuda{
+				Apoderado:  a.Nombre,
+				Estudiante: e.Nombres + " " + e.Apellidos,
+				Saldo:      e.Total,
+				FechaCorte: hoy,
+				URLBaja:    s.urlBaja(a.TokenBaja),
+			}
+			texto, err := correos.TextoRecordatorio(recordatorio)
+			if err != nil {
+				return encolados, fmt.Errorf("error al armar recordatorio: %v", err)
+			}
+			var html bytes.Buffer
+			if err := correos.RecordatorioHTML(recordatorio).Render(context.Background(), &html); err != nil {
+				return encolados, fmt.Errorf("error al armar recordatorio: %v", err)
+			}
+			nuevo, err := s.Repo.EncolarCorreo(models.Correo{
+				Tipo:         models.CorreoRecordatorio,
+				IdApoderado:  a.IdApoderado,
+				IdEstudiante: e.IdEstudiante,
+				Semana:       &inicio,
+				Destinatario: a.Email,
+				Asunto:       correos.AsuntoRecordatorio(recordatorio),
+				CuerpoTexto:  texto,
+				CuerpoHTML:   html.String(),
+				URLBaja:      recordatorio.URLBaja,
+			})
+			if err != nil {
+				return encolados, fmt.Errorf("error al encolar correo: %v", err)
+			}
+			if nuevo {
+				encolados++
+			}
+		}
+	}
+	return encolados, nil
+}
+
+// EncolarCorreoPrueba deja en cola un correo de prueba para verificar el SMTP
+func (s *Servicio) EncolarCorreoPrueba(para string) error {
+	direccion, err := mail.ParseAddress(strings.TrimSpace(para))
+	if err != nil {
+		return fmt.Errorf("correo inválido")
+	}
+	texto := "Este es un correo de prueba del kiosco escolar. Si lo recibe, el envío por SMTP funciona."
+	_, err = s.Repo.EncolarCorreo(models.Correo{
+		Tipo:         models.CorreoPrueba,
+		Destinatario: direccion.Address,
+		Asunto:       "Correo de prueba del kiosco",
+		CuerpoTexto:  texto,
+		CuerpoHTML:   "<p>" + texto + "</p>",
+	})
+	if err != nil {
+		return fmt.Errorf("error al encolar correo: %v", err)
+	}
+	return nil
+}
+
+// IniciarEnvioCorreos arranca la goroutine que envía la bandeja de salida de
+// correos y los estados de cuenta automáticos. Se detiene al cancelar ctx.
+func (s *Servicio) IniciarEnvioCorreos(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(intervaloCorreos)
+		defer ticker.Stop()
+		for {
+			s.verificarEstadosAutomaticos()
+			s.despacharCorreos(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// verificarEstadosAutomaticos encola los estados de cuenta de la última semana
+// cerrada si el envío automático está activo y aún no se hizo.
+func (s *Servicio) verificarEstadosAutomaticos() {
+	automatico, err := s.Repo.ObtenerConfiguracion(claveEstadoAutomatico)
+	if err != nil || automatico != "1" {
+		return
+	}
+
+	inicio, _ := ultimaSemanaCerrada()
+	inicioStr := utils.FormatearFechaCompleta(inicio)
+	ultima, err := s.Repo.ObtenerConfiguracion(claveSemanaEnviada)
+	if err != nil {
+		log.Printf("Error al leer última semana enviada: %v", err)
+		return
+	}
+	if ultima >= inicioStr {
+		return
+	}
+
+	// Encolar es idempotente por apoderado y semana: si falla a medias se repite
+	n, err := s.EncolarEstadosCuenta(inicio, 0)
+	if err != nil {
+		log.Printf("Error al encolar estados de cuenta de %s: %v", inicioStr, err)
+		return
+	}
+	if err := s.Repo.GuardarConfiguracion(claveSemanaEnviada, inicioStr); err != nil {
+		log.Printf("Error al guardar última semana enviada: %v", err)
+		return
+	}
+	log.Printf("📧 %d estados de cuenta encolados (semana %s)", n, inicioStr)
+}
+
+// despacharCorreos envía los correos vencidos y reprograma los que fallan.
+// Sin SMTP configurado no hace nada: los correos esperan en la cola.
+func (s *Servicio) despacharCorreos(ctx context.Context) {
+	if !correo.Vigente().Habilitado() {
+		return
+	}
+	pendientes, err := s.Repo.ObtenerCorreosPendientes(loteCorreos)
+	if err != nil {
+		log.Printf("Error al obtener correos pendientes: %v", err)
+		return
+	}
+
+	for _, c := range pendientes {
+		if ctx.Err() != nil {
+			return
+		}
+		err := correo.Enviar(correo.Mensaje{
+			Para:    c.Destinatario,
+			Asunto:  c.Asunto,
+			Texto:   c.CuerpoTexto,
+			HTML:    c.CuerpoHTML,
+			URLBaja: c.URLBaja,
+		})
+		if err == nil {
+			if err := s.Repo.MarcarCorreoEnviado(c.IdCorreo); err != nil {
+				log.Printf("Error al marcar correo %d: %v", c.IdCorreo, err)
+			}
+			continue
+		}
+
+		espera := 0
+		if c.Intentos+1 < maxIntentosCorreo {
+			espera = int(esperaReintento(c.Intentos + 1).Seconds())
+		} else {
+			log.Printf("⚠️ Correo %d a %s fallido tras %d intentos: %v", c.IdCorreo, c.Destinatario, c.Intentos+1, err)
+		}
+		if err := s.Repo.MarcarCorreoFallido(c.IdCorreo, err.Error(), espera); err != nil {
+			log.Printf("Error al marcar correo %d: %v", c.IdCorreo, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// aplicarDescuento elige, entre las reglas que cubren el producto, la que da
+// el mayor descuento sobre el precio de lista
+func aplicarDescuento(producto models.Producto, precioLista float64, reglas []models.ReglaDescuento) models.PrecioAplicado {
+	precio := models.PrecioAplicado{PrecioLista: precioLista}
+	for _, regla := range reglas {
+		if !regla.AplicaA(producto) {
+			continue
+		}
+		if descuento := regla.DescuentoSobre(precioLista); descuento > precio.Descuento {
+			precio.Descuento = descuento
+			precio.IdRegla = regla.IdRegla
+		}
+	}
+	return precio
+}
+
+// CrearReglaDescuento valida y guarda una regla de descuento
+func (s *Servicio) CrearReglaDescuento(regla models.ReglaDescuento) error {
+	if regla.Nombre == "" {
+		return fmt.Errorf("el nombre es obligatorio")
+	}
+	if (regla.IdEstudiante == 0) == (regla.IdGrupo == 0) {
+		return fmt.Errorf("la regla debe aplicar a un estudiante o a un grupo")
+	}
+	switch regla.Tipo {
+	case models.DescuentoPorcentaje:
+		if regla.Valor <= 0 || regla.Valor > 100 {
+			return fmt.Errorf("el porcentaje debe estar entre 0 y 100")
+		}
+	case models.DescuentoMonto:
+		if regla.Valor <= 0 {
+			return fmt.Errorf("el monto debe ser mayor a cero")
+		}
+	default:
+		return fmt.Errorf("tipo de descuento inválido: %s", regla.Tipo)
+	}
+	if regla.VigenteHasta != nil && regla.VigenteHasta.Before(regla.VigenteDesde) {
+		return fmt.Errorf("la fecha de término es anterior a la de inicio")
+	}
+
+	if err := s.Repo.InsertarReglaDescuento(regla); err != nil {
+		return fmt.Errorf("error al guardar regla: %v", err)
+	}
+	return nil
+}
+
+// ObtenerDatosDescuentos prepara los datos de la página de configuración de descuentos
+func (s *Servicio) ObtenerDatosDescuentos() (*models.DatosDescuentos, error) {
+	reglas, err := s.Repo.ObtenerReglasDescuento()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener reglas: %v", err)
+	}
+
+	grupos, err := s.Repo.ObtenerGruposDescuento()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener grupos: %v", err)
+	}
+
+	estudiantes, err := s.Repo.ObtenerEstudiantesPorGrado(0)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+
+	productos, err := s.Repo.ObtenerProductosActivos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	categorias, err := s.Repo.ObtenerCategorias()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener categorías: %v", err)
+	}
+
+	return &models.DatosDescuentos{
+		Reglas:      reglas,
+		Grupos:      grupos,
+		Estudiantes: estudiantes,
+		Productos:   productos,
+		Categorias:  categorias,
+	}, nil
+}
+
+// ObtenerReporteDescuentos resume los descuentos otorgados en un rango de fechas
+func (s *Servicio) ObtenerReporteDescuentos(desde, hasta time.Time) (*models.DatosReporteDescuentos, error) {
+	porEstudiante, err := s.Repo.ObtenerDescuentosPorEstudiante(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener descuentos por estudiante: %v", err)
+	}
+
+	porRegla, err := s.Repo.ObtenerDescuentosPorRegla(desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener descuentos por regla: %v", err)
+	}
+
+	datos := &models.DatosReporteDescuentos{
+		Desde:         desde,
+		Hasta:         hasta,
+		PorEstudiante: porEstudiante,
+		PorRegla:      porRegla,
+	}
+	for _, d := range porEstudiante {
+		datos.TotalDescuento += d.Descuento
+	}
+	return datos, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+)
+
+const (
+	diasClavesIdempotencia = 30 // Un envío en cola sin conexión no debería esperar más
+	intervaloPurgaClaves   = 6 * time.Hour
+)
+
+var patronClaveIdempotencia = regexp.MustCompile(`^[A-Za-z0-9-]{8,64}$`)
+
+// ReclamarSolicitud registra la clave de idempotencia de un formulario. Retorna
+// false si la misma solicitud ya se procesó (un reenvío del service worker o un
+// doble clic), en cuyo caso no debe volver a aplicarse. Sin clave siempre procesa.
+func (s *Servicio) ReclamarSolicitud(clave, ruta string) (bool, error) {
+	if clave == "" {
+		return true, nil
+	}
+	if err := ValidarClaveIdempotencia(clave); err != nil {
+		return false, err
+	}
+	nueva, err := s.Repo.ReclamarClaveIdempotencia(clave, ruta)
+	if err != nil {
+		return false, fmt.Errorf("error al registrar clave de idempotencia: %v", err)
+	}
+	return nueva, nil
+}
+
+// ValidarClaveIdempotencia verifica el formato de una clave de idempotencia ("" es válida: sin control)
+func ValidarClaveIdempotencia(clave string) error {
+	if clave != "" && !patronClaveIdempotencia.MatchString(clave) {
+		return fmt.Errorf("clave de idempotencia inválida")
+	}
+	return nil
+}
+
+// LiberarSolicitud permite reintentar con la misma clave una solicitud que falló
+func (s *Servicio) LiberarSolicitud(clave string) {
+	if clave == "" {
+		return
+	}
+	if err := s.Repo.LiberarClaveIdempotencia(clave); err != nil {
+		log.Printf("Error al liberar clave de idempotencia: %v", err)
+	}
+}
+
+// IniciarPurgaIdempotencia borra periódicamente las claves antiguas hasta que se cancele ctx
+func (s *Servicio) IniciarPurgaIdempotencia(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(intervaloPurgaClaves)
+		defer ticker.Stop()
+		for {
+			if err := s.Repo.PurgarClavesIdempotencia(diasClavesIdempotencia); err != nil {
+				log.Printf("Error al purgar claves de idempotencia: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
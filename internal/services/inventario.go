@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// RegistrarMovimientoInventario valida y registra un movimiento manual de stock.
+// Para entradas y mermas cantidad es el número de unidades; para ajustes es el conteo físico.
+func (s *Servicio) RegistrarMovimientoInventario(idProducto int, tipo string, cantidad int, fecha time.Time, nota string) error {
+	if cantidad < 0 {
+		return fmt.Errorf("la cantidad no puede ser negativa")
+	}
+
+	switch tipo {
+	case models.MovimientoEntrada:
+		if cantidad == 0 {
+			return fmt.Errorf("la cantidad debe ser mayor a cero")
+		}
+		return s.Repo.RegistrarMovimientoStock(idProducto, tipo, cantidad, fecha, nota)
+	case models.MovimientoMerma:
+		if cantidad == 0 {
+			return fmt.Errorf("la cantidad debe ser mayor a cero")
+		}
+		return s.Repo.RegistrarMovimientoStock(idProducto, tipo, -cantidad, fecha, nota)
+	case models.MovimientoAjuste:
+		return s.Repo.AjustarStock(idProducto, cantidad, fecha, nota)
+	default:
+		return fmt.Errorf("tipo de movimiento inválido: %s", tipo)
+	}
+}
+
+// ObtenerDatosInventario prepara los datos para la página de inventario
+func (s *Servicio) ObtenerDatosInventario(puedeEditar bool) (*models.DatosInventario, error) {
+	productos, err := s.Repo.ObtenerTodosProductos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	movimientos, err := s.Repo.ObtenerMovimientosRecientes(50)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener movimientos: %v", err)
+	}
+
+	return &models.DatosInventario{
+		Productos:   productos,
+		Movimientos: movimientos,
+		PuedeEditar: puedeEditar,
+	}, nil
+}
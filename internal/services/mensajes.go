@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/notificador"
+	"kiosco/internal/utils"
+	"kiosco/templates/mensajes"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	intervaloMensajes  = 10 * time.Second
+	loteMensajes       = 10 // El limitador del notificador espacia los envíos dentro del lote
+	maxIntentosMensaje = 6  // Con la espera de los webhooks: ~15 minutos antes de marcar fallido
+
+	claveConfirmarPagos = "mensajes_confirmar_pagos" // "1" = avisar cada pago registrado
+)
+
+// ObtenerDatosMensajes prepara los ajustes y el registro de mensajes
+func (s *Servicio) ObtenerDatosMensajes(estado string) (*models.DatosMensajes, error) {
+	registro, err := s.Repo.ObtenerMensajes(estado, 100)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mensajes: %v", err)
+	}
+	confirmar, err := s.Repo.ObtenerConfiguracion(claveConfirmarPagos)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener ajustes: %v", err)
+	}
+
+	datos := &models.DatosMensajes{
+		Habilitado:     notificador.Habilitado(),
+		Umbral:         s.ObtenerUmbralRecordatorio(),
+		ConfirmarPagos: confirmar == "1",
+		Mensajes:       registro,
+		Estado:         estado,
+	}
+	if datos.Habilitado {
+		datos.Proveedor = notificador.Vigente().Nombre()
+	}
+	return datos, nil
+}
+
+// GuardarAjustesMensajes activa o desactiva la confirmación automática de pagos
+func (s *Servicio) GuardarAjustesMensajes(confirmarPagos bool) error {
+	valor := "0"
+	if confirmarPagos {
+		valor = "1"
+	}
+	if err := s.Repo.GuardarConfiguracion(claveConfirmarPagos, valor); err != nil {
+		return fmt.Errorf("error al guardar ajustes: %v", err)
+	}
+	return nil
+}
+
+// urlBajaMensajes arma el enlace de baja de WhatsApp/SMS de un apoderado
+func (s *Servicio) urlBajaMensajes(token string) string {
+	if base := s.urlBaja(token); base != "" {
+		return base + "&canal=mensajes"
+	}
+	return ""
+}
+
+// EncolarRecordatoriosMensaje deja en cola un WhatsApp/SMS para los apoderados
+// de los estudiantes cuyo saldo actual supera el umbral. Como máximo uno por semana.
+func (s *Servicio) EncolarRecordatoriosMensaje() (int, error) {
+	umbral := s.ObtenerUmbralRecordatorio()
+	hoy := utils.Hoy()
+	inicio, fin := utils.CalcularSemanaDesdeFecha(hoy)
+
+	apoderados, err := s.Repo.ObtenerApoderados()
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener apoderados: %v", err)
+	}
+	porEstudiante := make(map[int][]models.Apoderado)
+	for _, a := range apoderados {
+		if a.AceptaMensajes() {
+			porEstudiante[a.IdEstudiante] = append(porEstudiante[a.IdEstudiante], a)
+		}
+	}
+
+	datos, err := s.ObtenerDatosVistaPrincipal(inicio, fin, 0, "")
+	if err != nil {
+		return 0, err
+	}
+
+	encolados := 0
+	for _, e := range datos.EstudiantesConData {
+		if e.Total <= umbral {
+			continue
+		}
+		for _, a := range porEstudiante[e.IdEstudiante] {
+			texto, err := mensajes.TextoRecordatorio(mensajes.RecordatorioDeuda{
+				Estudiante: e.Nombres + " " + e.Apellidos,
+				Saldo:      e.Total,
+				FechaCorte: hoy,
+				URLBaja:    s.urlBajaMensajes(a.TokenBaja),
+			})
+			if err != nil {
+				return encolados, fmt.Errorf("error al armar recordatorio: %v", err)
+			}
+			nuevo, err := s.Repo.EncolarMensaje(models.Mensaje{
+				Tipo:         models.MensajeRecordatorio,
+				IdApoderado:  a.IdApoderado,
+				IdEstudiante: e.IdEstudiante,
+				Referencia:   utils.FormatearFechaCompleta(inicio),
+				Telefono:     a.Telefono,
+				Texto:        texto,
+			})
+			if err != nil {
+				return encolados, fmt.Errorf("error al encolar mensaje: %v", err)
+			}
+			if nuevo {
+				encolados++
+			}
+		}
+	}
+	return encolados, nil
+}
+
+// notificarPago encola la confirmación de un pago si está activada. Igual que
+// los webhooks, un error aquí no deshace el pago: solo se registra.
+func (s *Servicio) notificarPago(pago models.Pago) {
+	confirmar, err := s.Repo.ObtenerConfiguracion(claveConfirmarPagos)
+	if err != nil || confirmar != "1" {
+		return
+	}
+
+	apoderados, err := s.Repo.ObtenerApoderados()
+	if err != nil {
+		log.Printf("Error al obtener apoderados para pago %d: %v", pago.IdPago, err)
+		return
+	}
+	var destinatarios []models.Apoderado
+	for _, a := range apoderados {
+		if a.IdEstudiante == pago.IdEstudiante && a.AceptaMensajes() {
+			destinatarios = append(destinatarios, a)
+		}
+	}
+	if len(destinatarios) == 0 {
+		return
+	}
+
+	est, err := s.Repo.ObtenerEstudiantePorId(pago.IdEstudiante)
+	if err != nil {
+		log.Printf("Error al obtener estudiante para pago %d: %v", pago.IdPago, err)
+		return
+	}
+	// Saldo con todo lo registrado hasta hoy (o hasta la fecha del pago si es posterior)
+	corte := utils.Hoy()
+	if pago.FechaPago.After(corte) {
+		corte = pago.FechaPago
+	}
+	saldo, err := s.Repo.ObtenerDeudaAnterior(pago.IdEstudiante, corte.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("Error al calcular saldo para pago %d: %v", pago.IdPago, err)
+		return
+	}
+
+	for _, a := range destinatarios {
+		texto, err := mensajes.TextoConfirmacionPago(mensajes.ConfirmacionPago{
+			Estudiante: est.Nombres + " " + est.Apellidos,
+			Monto:      pago.Monto,
+			Fecha:      pago.FechaPago,
+			Saldo:      saldo,
+			URLBaja:    s.urlBajaMensajes(a.TokenBaja),
+		})
+		if err != nil {
+			log.Printf("Error al armar confirmación de pago %d: %v", pago.IdPago, err)
+			return
+		}
+		if _, err := s.Repo.EncolarMensaje(models.Mensaje{
+			Tipo:         models.MensajePago,
+			IdApoderado:  a.IdApoderado,
+			IdEstudiante: pago.IdEstudiante,
+			Referencia:   strconv.Itoa(pago.IdPago),
+			Telefono:     a.Telefono,
+			Texto:        texto,
+		}); err != nil {
+			log.Printf("Error al encolar confirmación de pago %d: %v", pago.IdPago, err)
+		}
+	}
+}
+
+// EncolarMensajePrueba deja en cola un mensaje de prueba para verificar la pasarela
+func (s *Servicio) EncolarMensajePrueba(telefono string) error {
+	numero, err := notificador.NormalizarTelefono(telefono)
+	if err != nil {
+		return err
+	}
+	_, err = s.Repo.EncolarMensaje(models.Mensaje{
+		Tipo:     models.MensajePrueba,
+		Telefono: numero,
+		Texto:    "Kiosco escolar: mensaje de prueba. Si lo recibe, la pasarela funciona.",
+	})
+	if err != nil {
+		return fmt.Errorf("error al encolar mensaje: %v", err)
+	}
+	return nil
+}
+
+// IniciarEnvioMensajes arranca la goroutine que envía la bandeja de salida de
+// WhatsApp/SMS. Se detiene al cancelar ctx.
+func (s *Servicio) IniciarEnvioMensajes(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(intervaloMensajes)
+		defer ticker.Stop()
+		for {
+			s.despacharMensajes(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// despacharMensajes envía los mensajes vencidos y reprograma los que fallan.
+// Sin notificador configurado no hace nada: los mensajes esperan en la cola.
+func (s *Servicio) despacharMensajes(ctx context.Context) {
+	n := notificador.Vigente()
+	if n == nil {
+		return
+	}
+	pendientes, err := s.Repo.ObtenerMensajesPendientes(loteMensajes)
+	if err != nil {
+		log.Printf("Error al obtener mensajes pendientes: %v", err)
+		return
+	}
+
+	for _, m := range pendientes {
+		if ctx.Err() != nil {
+			return
+		}
+		resultado, err := n.Enviar(ctx, notificador.Mensaje{
+			Telefono:   m.Telefono,
+			Texto:      m.Texto,
+			Referencia: "kiosco-" + strconv.Itoa(m.IdMensaje),
+		})
+		if err == nil {
+			log.Printf("📱 Mensaje %d (%s) enviado a %s vía %s", m.IdMensaje, m.Tipo, m.Telefono, n.Nombre())
+			if err := s.Repo.MarcarMensajeEnviado(m.IdMensaje, n.Nombre(), resultado.Status, resultado.IdExterno); err != nil {
+				log.Printf("Error al marcar mensaje %d: %v", m.IdMensaje, err)
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return // Apagado: el mensaje sigue pendiente sin gastar un intento
+		}
+
+		espera := 0
+		if m.Intentos+1 < maxIntentosMensaje {
+			espera = int(esperaReintento(m.Intentos + 1).Seconds())
+		} else {
+			log.Printf("⚠️ Mensaje %d a %s fallido tras %d intentos: %v", m.IdMensaje, m.Telefono, m.Intentos+1, err)
+		}
+		if err := s.Repo.MarcarMensajeFallido(m.IdMensaje, n.Nombre(), resultado.Status, err.Error(), espera); err != nil {
+			log.Printf("Error al marcar mensaje %d: %v", m.IdMensaje, err)
+		}
+	}
+}
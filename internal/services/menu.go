@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// ObtenerProductosEdicion retorna los productos a mostrar al editar los consumos
+// de un estudiante: los del menú de la fecha más los que ya consumió ese día,
+// para que un producto fuera del menú no desaparezca del formulario.
+// Los precios son los vigentes en la fecha, con los descuentos y planes del estudiante aplicados.
+func (s *Servicio) ObtenerProductosEdicion(idEstudiante int, fecha time.Time, consumidos map[int]int) ([]models.Producto, error) {
+	productos, err := s.productosEdicion(fecha, consumidos)
+	if err != nil {
+		return nil, err
+	}
+
+	precios, err := s.Repo.ObtenerPreciosVigentes(fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener precios: %v", err)
+	}
+
+	reglas, err := s.Repo.ObtenerReglasVigentes(idEstudiante, fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener descuentos: %v", err)
+	}
+
+	suscripciones, err := s.Repo.ObtenerSuscripcionesEstudiante(idEstudiante, fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener planes: %v", err)
+	}
+
+	for i := range productos {
+		precio := aplicarDescuento(productos[i], precios[productos[i].IdProducto], reglas)
+		precio = aplicarPlan(precio, productos[i], suscripciones)
+		productos[i].PrecioUnitario = precio.PrecioVenta()
+	}
+	return productos, nil
+}
+
+func (s *Servicio) productosEdicion(fecha time.Time, consumidos map[int]int) ([]models.Producto, error) {
+	productos, err := s.Repo.ObtenerProductosParaFecha(fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	incluidos := make(map[int]bool, len(productos))
+	for _, p := range productos {
+		incluidos[p.IdProducto] = true
+	}
+
+	faltantes := false
+	for id, cantidad := range consumidos {
+		if cantidad > 0 && !incluidos[id] {
+			faltantes = true
+			break
+		}
+	}
+	if !faltantes {
+		return productos, nil
+	}
+
+	// Reconstruir en el orden del catálogo para no romper la agrupación por categoría
+	todos, err := s.Repo.ObtenerTodosProductos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+	resultado := make([]models.Producto, 0, len(productos)+1)
+	for _, p := range todos {
+		if incluidos[p.IdProducto] || consumidos[p.IdProducto] > 0 {
+			resultado = append(resultado, p)
+		}
+	}
+	return resultado, nil
+}
+
+// ObtenerDatosMenuDia prepara los datos de la página de menú del día
+func (s *Servicio) ObtenerDatosMenuDia(fecha time.Time, puedeEditar bool) (*models.DatosMenuDia, error) {
+	categorias, err := s.Repo.ObtenerCategorias()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener categorías: %v", err)
+	}
+
+	productos, err := s.Repo.ObtenerProductosActivos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	enMenu, err := s.Repo.ObtenerMenuDia(fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener menú: %v", err)
+	}
+
+	return &models.DatosMenuDia{
+		Fecha:       fecha,
+		Categorias:  categorias,
+		Productos:   productos,
+		EnMenu:      enMenu,
+		PuedeEditar: puedeEditar,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// rangoMes retorna el primer y el último día del mes de una fecha
+func rangoMes(mes time.Time) (time.Time, time.Time) {
+	inicio := time.Date(mes.Year(), mes.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return inicio, inicio.AddDate(0, 1, -1)
+}
+
+// ObtenerDatosPersonal arma la vista mensual de docentes, personal y otros
+// clientes: saldo al inicio del mes, consumos y pagos del mes, y saldo
+func (s *Servicio) ObtenerDatosPersonal(mes time.Time) (models.DatosPersonal, error) {
+	inicio, fin := rangoMes(mes)
+	datos := models.DatosPersonal{Mes: inicio}
+
+	cuentas, err := s.Repo.ObtenerEstudiantesActivosPorSector("personal")
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener personal: %v", err)
+	}
+	if len(cuentas) == 0 {
+		return datos, nil
+	}
+
+	// Mismos cálculos que la vista semanal, con el mes como periodo
+	anteriores, err := s.Repo.ObtenerDeudasAnterioresBatch(0, inicio)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener deudas anteriores: %v", err)
+	}
+	pagos, err := s.Repo.ObtenerPagosSemanaBatch(0, inicio, fin)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener pagos: %v", err)
+	}
+	consumos, err := s.Repo.ObtenerConsumosSemana(inicio, fin)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener consumos: %v", err)
+	}
+	cargos, err := s.Repo.ObtenerCargosPlanSemana(inicio, fin)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener cargos de planes: %v", err)
+	}
+	consumosMes := make(map[int]float64)
+	for _, c := range consumos {
+		consumosMes[c.IdEstudiante] += c.TotalLinea
+	}
+	for _, c := range cargos {
+		consumosMes[c.IdEstudiante] += c.Monto
+	}
+
+	datos.Cuentas = make([]models.CuentaPersonal, 0, len(cuentas))
+	for _, e := range cuentas {
+		cuenta := models.CuentaPersonal{
+			Estudiante:    e,
+			SaldoAnterior: anteriores[e.IdEstudiante],
+			Consumos:      consumosMes[e.IdEstudiante],
+			Pagos:         pagos[e.IdEstudiante],
+		}
+		datos.Cuentas = append(datos.Cuentas, cuenta)
+		datos.TotalConsumos += cuenta.Consumos
+		datos.TotalPagos += cuenta.Pagos
+		datos.TotalSaldo += cuenta.Saldo()
+	}
+	return datos, nil
+}
+
+// ObtenerEstadoCuentaMes arma la nota de venta del mes de una cuenta. Retorna
+// sql.ErrNoRows si la cuenta no existe.
+func (s *Servicio) ObtenerEstadoCuentaMes(idEstudiante int, mes time.Time) (*models.DatosConsumoSemanal, error) {
+	if _, err := s.Repo.ObtenerEstudiantePorId(idEstudiante); err == sql.ErrNoRows {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("error al obtener cuenta: %v", err)
+	}
+
+	inicio, fin := rangoMes(mes)
+	datos, err := s.ObtenerDatosConsumoSemanal(idEstudiante, inicio, fin)
+	if err != nil {
+		return nil, err
+	}
+	datos.Mensual = true
+	return datos, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"log"
+	"time"
+)
+
+// Los períodos empiezan a medianoche: cada hora basta para que el cargo de la
+// semana o el mes nuevo aparezca temprano sin generarlo en cada vista
+const intervaloCargosPlanes = time.Hour
+
+// GenerarCargosPlanes crea los cargos de todos los períodos de suscripción que
+// comenzaron hasta la fecha indicada y aún no fueron cobrados. Los períodos sin
+// ningún día de atención (vacaciones) no se cobran. Es idempotente.
+func (s *Servicio) GenerarCargosPlanes(hasta time.Time) error {
+	// Comparar por día calendario (las fechas de la base se leen a medianoche UTC)
+	hasta = time.Date(hasta.Year(), hasta.Month(), hasta.Day(), 0, 0, 0, 0, time.UTC)
+
+	suscripciones, err := s.Repo.ObtenerSuscripciones()
+	if err != nil {
+		return fmt.Errorf("error al obtener suscripciones: %v", err)
+	}
+
+	ultimos, err := s.Repo.ObtenerUltimosPeriodosCargados()
+	if err != nil {
+		return fmt.Errorf("error al obtener cargos de planes: %v", err)
+	}
+
+	for _, sus := range suscripciones {
+		if !sus.Plan.EstaActivo {
+			continue
+		}
+
+		inicioSemana := utils.Semana().DiaInicio
+		periodo := sus.Plan.InicioPeriodo(sus.FechaInicio, inicioSemana)
+		if ultimo, ok := ultimos[sus.IdSuscripcion]; ok {
+			// Los cargos guardados antes de cambiar SEMANA_INICIO (o antes de que
+			// existiera) empiezan en lunes: el siguiente período empieza el primer
+			// día de la semana configurada tras el ya cobrado, sin volver a cobrar
+			// días de ese período
+			periodo = sus.Plan.PrimerPeriodoDesde(sus.Plan.SiguientePeriodo(ultimo), inicioSemana)
+		}
+
+		for ; !periodo.After(hasta); periodo = sus.Plan.SiguientePeriodo(periodo) {
+			if sus.FechaFin != nil && periodo.After(*sus.FechaFin) {
+				break
+			}
+			atiende, err := s.hayAtencionEntre(periodo, sus.Plan.SiguientePeriodo(periodo).AddDate(0, 0, -1))
+			if err != nil {
+				return err
+			}
+			if !atiende {
+				continue
+			}
+			// El primer período se cobra en la fecha de inscripción
+			fechaCargo := periodo
+			if fechaCargo.Before(sus.FechaInicio) {
+				fechaCargo = sus.FechaInicio
+			}
+			if err := s.Repo.InsertarCargoPlan(models.CargoPlan{
+				IdSuscripcion: sus.IdSuscripcion,
+				IdEstudiante:  sus.IdEstudiante,
+				PeriodoInicio: periodo,
+				FechaCargo:    fechaCargo,
+				Monto:         sus.Plan.Monto,
+			}); err != nil {
+				return fmt.Errorf("error al registrar cargo de plan: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// IniciarGeneracionCargos genera al arrancar y luego periódicamente los cargos
+// de planes vencidos hasta hoy, hasta que se cancele ctx
+func (s *Servicio) IniciarGeneracionCargos(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(intervaloCargosPlanes)
+		defer ticker.Stop()
+		for {
+			if err := s.GenerarCargosPlanes(utils.Hoy()); err != nil {
+				log.Printf("Error al generar cargos de planes: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// hayAtencionEntre indica si el calendario tiene al menos un día de atención en el rango
+func (s *Servicio) hayAtencionEntre(desde, hasta time.Time) (bool, error) {
+	calendario, err := s.ObtenerCalendario(desde, hasta)
+	if err != nil {
+		return false, err
+	}
+	for dia := desde; !dia.After(hasta); dia = dia.AddDate(0, 0, 1) {
+		if calendario.EsLaborable(dia) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// aplicarPlan marca el precio como cubierto si alguna suscripción vigente
+// del estudiante incluye el producto
+func aplicarPlan(precio models.PrecioAplicado, producto models.Producto, suscripciones []models.Suscripcion) models.PrecioAplicado {
+	for _, sus := range suscripciones {
+		if sus.Plan.EstaActivo && sus.Plan.Cubre(producto) {
+			precio.IdSuscripcion = sus.IdSuscripcion
+			precio.Descuento = 0
+			precio.IdRegla = 0
+			break
+		}
+	}
+	return precio
+}
+
+// CrearPlan valida y guarda un plan de alimentación
+func (s *Servicio) CrearPlan(plan models.Plan) error {
+	if plan.Nombre == "" {
+		return fmt.Errorf("el nombre es obligatorio")
+	}
+	if plan.Monto <= 0 {
+		return fmt.Errorf("el monto debe ser mayor a cero")
+	}
+	if plan.Periodo != models.PeriodoSemanal && plan.Periodo != models.PeriodoMensual {
+		return fmt.Errorf("período inválido: %s", plan.Periodo)
+	}
+	if err := s.Repo.InsertarPlan(plan); err != nil {
+		return fmt.Errorf("error al guardar plan: %v", err)
+	}
+	return nil
+}
+
+// InscribirEnPlan suscribe a un estudiante y genera los cargos ya vencidos
+func (s *Servicio) InscribirEnPlan(idPlan, idEstudiante int, fechaInicio time.Time) error {
+	if err := s.Repo.InsertarSuscripcion(idPlan, idEstudiante, fechaInicio); err != nil {
+		return fmt.Errorf("error al inscribir estudiante: %v", err)
+	}
+	return s.GenerarCargosPlanes(utils.Hoy())
+}
+
+// ObtenerDatosPlanes prepara los datos de la página de planes
+func (s *Servicio) ObtenerDatosPlanes() (*models.DatosPlanes, error) {
+	planes, err := s.Repo.ObtenerPlanes()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener planes: %v", err)
+	}
+
+	suscripciones, err := s.Repo.ObtenerSuscripciones()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener suscripciones: %v", err)
+	}
+
+	estudiantes, err := s.Repo.ObtenerEstudiantesPorGrado(0)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+
+	categorias, err := s.Repo.ObtenerCategorias()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener categorías: %v", err)
+	}
+
+	return &models.DatosPlanes{
+		Planes:        planes,
+		Suscripciones: suscripciones,
+		Estudiantes:   estudiantes,
+		Categorias:    categorias,
+	}, nil
+}
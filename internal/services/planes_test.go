@@ -0,0 +1,71 @@
+package services
+
+import (
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"testing"
+	"time"
+)
+
+// Al cambiar SEMANA_INICIO entre dos generaciones, el primer período con el
+// nuevo inicio no puede empezar dentro de la semana ya cobrada
+func TestGenerarCargosPlanesCambioInicioSemana(t *testing.T) {
+	t.Chdir(t.TempDir()) // La base se crea en database/ del directorio actual
+	anterior := utils.Semana()
+	t.Cleanup(func() { utils.ConfigurarSemana(anterior) })
+
+	configurarInicio := func(dia time.Weekday) {
+		t.Helper()
+		if err := utils.ConfigurarSemana(utils.ConfiguracionSemana{Zona: time.UTC, DiaInicio: dia, Dias: 5}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fecha := func(valor string) time.Time {
+		t.Helper()
+		f, err := utils.ParsearFecha(valor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	s := NuevoServicioColegio("planes", "")
+	if err := s.CrearPlan(models.Plan{Nombre: "Almuerzo", Monto: 50, Periodo: models.PeriodoSemanal}); err != nil {
+		t.Fatal(err)
+	}
+	est, err := s.Repo.InsertarEstudiante("Ana", "Prueba", 0, "estudiante")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Repo.InsertarSuscripcion(1, est.IdEstudiante, fecha("2026-10-05")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lunes 5 y lunes 12 de octubre; luego la semana pasa a empezar el miércoles
+	configurarInicio(time.Monday)
+	if err := s.GenerarCargosPlanes(fecha("2026-10-18")); err != nil {
+		t.Fatal(err)
+	}
+	configurarInicio(time.Wednesday)
+	if err := s.GenerarCargosPlanes(fecha("2026-11-01")); err != nil {
+		t.Fatal(err)
+	}
+
+	cargos, err := s.Repo.ObtenerCargosPlanSemana(fecha("2026-10-01"), fecha("2026-11-30"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperados := []string{"2026-10-05", "2026-10-12", "2026-10-21", "2026-10-28"}
+	if len(cargos) != len(esperados) {
+		t.Fatalf("se generaron %d cargos, se esperaban %d: %v", len(cargos), len(esperados), cargos)
+	}
+	for i, c := range cargos {
+		if got := c.PeriodoInicio.Format("2006-01-02"); got != esperados[i] {
+			t.Errorf("cargo %d empieza el %s, se esperaba el %s", i, got, esperados[i])
+		}
+		if i > 0 && c.PeriodoInicio.Before(cargos[i-1].PeriodoInicio.AddDate(0, 0, 7)) {
+			t.Errorf("el período del %s se superpone con el del %s",
+				c.PeriodoInicio.Format("2006-01-02"), cargos[i-1].PeriodoInicio.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/auth"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"net/url"
+	"time"
+)
+
+const pagosPortal = 20 // Pagos que se muestran en el historial del portal
+
+// GenerarAccesoPortal crea un enlace nuevo al portal para un apoderado y anula el
+// anterior. Retorna el token en claro: solo se muestra una vez.
+func (s *Servicio) GenerarAccesoPortal(idApoderado int) (string, error) {
+	token, hash, err := auth.GenerarTokenPortal()
+	if err != nil {
+		return "", fmt.Errorf("error al generar enlace: %v", err)
+	}
+	ok, err := s.Repo.GuardarAccesoPortal(idApoderado, hash)
+	if err != nil {
+		return "", fmt.Errorf("error al guardar enlace: %v", err)
+	}
+	if !ok {
+		return "", fmt.Errorf("apoderado no encontrado")
+	}
+	return token, nil
+}
+
+// RevocarAccesoPortal anula el enlace del apoderado y cierra sus sesiones abiertas
+func (s *Servicio) RevocarAccesoPortal(idApoderado int) error {
+	if _, err := s.Repo.GuardarAccesoPortal(idApoderado, ""); err != nil {
+		return fmt.Errorf("error al revocar enlace: %v", err)
+	}
+	return nil
+}
+
+// EnlacePortal arma la URL de acceso. Sin URL_PUBLICA usa el host de la solicitud.
+func (s *Servicio) EnlacePortal(token, host string) string {
+	base := s.urlPublica()
+	if base == "" {
+		base = "http://" + host
+	}
+	return base + "/portal/acceso?t=" + url.QueryEscape(token)
+}
+
+// IniciarSesionPortal canjea el token de un enlace por el id del apoderado
+// (sql.ErrNoRows si el enlace no existe o fue reemplazado)
+func (s *Servicio) IniciarSesionPortal(token string) (int, error) {
+	return s.Repo.ObtenerIdApoderadoPorHashPortal(auth.HashTokenServicio(token))
+}
+
+// ObtenerDatosPortal prepara lo que ve el apoderado: sus estudiantes con el saldo
+// al día y, del seleccionado, la nota de venta de la semana de fecha y los últimos
+// pagos. Un id que no es de su familia (o 0) muestra el primero de sus estudiantes.
+// Retorna sql.ErrNoRows si el acceso fue revocado o si la sesión se abrió con un
+// enlace anterior al vigente.
+func (s *Servicio) ObtenerDatosPortal(idApoderado int, sesionEmitida time.Time, idEstudiante int, fecha time.Time) (*models.DatosPortal, error) {
+	apoderado, err := s.Repo.ObtenerApoderadoPortal(idApoderado)
+	if err != nil {
+		return nil, err
+	}
+	if apoderado.PortalGeneradoEn == nil || sesionEmitida.Before(*apoderado.PortalGeneradoEn) {
+		return nil, sql.ErrNoRows
+	}
+	familia, err := s.Repo.ObtenerEstudiantesFamilia(idApoderado)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener estudiantes: %v", err)
+	}
+
+	hoy := utils.Hoy()
+	datos := &models.DatosPortal{NombreApoderado: apoderado.Nombre}
+	for _, e := range familia {
+		// Saldo con todo lo registrado hasta hoy, igual que los recordatorios
+		saldo, err := s.Repo.ObtenerDeudaAnterior(e.IdEstudiante, hoy.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, fmt.Errorf("error al calcular saldo: %v", err)
+		}
+		datos.Estudiantes = append(datos.Estudiantes, models.EstudiantePortal{Estudiante: e, Saldo: saldo, CodigoPago: CodigoPago(e.IdEstudiante)})
+		if e.IdEstudiante == idEstudiante {
+			datos.Seleccionado = idEstudiante
+		}
+	}
+	if len(familia) == 0 {
+		return datos, nil
+	}
+	if datos.Seleccionado == 0 {
+		datos.Seleccionado = familia[0].IdEstudiante
+	}
+
+	if fecha.After(hoy) {
+		fecha = hoy
+	}
+	inicio, fin := utils.CalcularSemanaDesdeFecha(fecha)
+	semana, err := s.ObtenerDatosConsumoSemanal(datos.Seleccionado, inicio, fin)
+	if err != nil {
+		return nil, err
+	}
+	datos.Semana = *semana
+	datos.SemanaAnterior = utils.FormatearFechaCompleta(inicio.AddDate(0, 0, -7))
+	if siguiente := inicio.AddDate(0, 0, 7); !siguiente.After(hoy) {
+		datos.SemanaSiguiente = utils.FormatearFechaCompleta(siguiente)
+	}
+
+	datos.Pagos, err = s.Repo.ObtenerPagosRecientes(datos.Seleccionado, pagosPortal)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pagos: %v", err)
+	}
+	return datos, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/models"
+	"time"
+)
+
+// ActualizarProducto guarda los datos de catálogo de un producto y, si el precio
+// cambió respecto al vigente en vigenteDesde, registra el cambio desde esa fecha
+func (s *Servicio) ActualizarProducto(producto models.Producto, vigenteDesde time.Time) error {
+	if err := s.Repo.ActualizarProducto(producto); err != nil {
+		return fmt.Errorf("error al actualizar producto: %v", err)
+	}
+
+	precioActual, err := s.Repo.ObtenerPrecioVigente(producto.IdProducto, vigenteDesde)
+	if err != nil {
+		return fmt.Errorf("error al obtener precio vigente: %v", err)
+	}
+	if precioActual == producto.PrecioUnitario {
+		return nil
+	}
+	return s.ProgramarCambioPrecio(producto.IdProducto, producto.PrecioUnitario, vigenteDesde)
+}
+
+// ProgramarCambioPrecio valida y registra un precio vigente desde una fecha
+func (s *Servicio) ProgramarCambioPrecio(idProducto int, precio float64, vigenteDesde time.Time) error {
+	if precio <= 0 {
+		return fmt.Errorf("el precio debe ser mayor a cero")
+	}
+	if err := s.Repo.ProgramarPrecio(idProducto, precio, vigenteDesde); err != nil {
+		return fmt.Errorf("error al registrar precio: %v", err)
+	}
+	return nil
+}
+
+// ObtenerDatosHistorialPrecios prepara los datos de la página de historial de precios
+func (s *Servicio) ObtenerDatosHistorialPrecios(puedeEditar bool) (*models.DatosHistorialPrecios, error) {
+	productos, err := s.Repo.ObtenerProductosActivos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	cambios, err := s.Repo.ObtenerHistorialPrecios()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener historial de precios: %v", err)
+	}
+
+	return &models.DatosHistorialPrecios{
+		Productos:   productos,
+		Cambios:     cambios,
+		PuedeEditar: puedeEditar,
+	}, nil
+}
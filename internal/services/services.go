@@ -1,22 +1,41 @@
 package services
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"kiosco/internal/envivo"
 	"kiosco/internal/models"
 	"kiosco/internal/repositories"
 	"kiosco/internal/utils"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // Servicio contiene la lógica de negocio
 type Servicio struct {
-	Repo *repositories.Repositorio
+	Repo   *repositories.Repositorio
+	EnVivo *envivo.Bus // Cambios de consumos y pagos para las pantallas abiertas
+
+	// URL pública propia del colegio; "" usa la URL_PUBLICA general
+	URLPublica string
 }
 
 // NuevoServicio crea una instancia del servicio con su repositorio.
 func NuevoServicio() *Servicio {
-	return &Servicio{Repo: repositories.NuevoRepositorio()}
+	return &Servicio{Repo: repositories.NuevoRepositorio(), EnVivo: envivo.NuevoBus()}
+}
+
+// NuevoServicioColegio crea el servicio de un colegio, con su propia base y su
+// propio bus de cambios en vivo.
+func NuevoServicioColegio(nombre, urlPublica string) *Servicio {
+	return &Servicio{
+		Repo:       repositories.NuevoRepositorioColegio(nombre),
+		EnVivo:     envivo.NuevoBus(),
+		URLPublica: urlPublica,
+	}
 }
 
 // ObtenerDatosVistaPrincipal prepara todos los datos para la vista principal
@@ -42,6 +61,7 @@ func (s *Servicio) ObtenerDatosVistaPrincipal(fechaInicio, fechaFin time.Time, i
 	// Crear mapas optimizados con capacidad pre-asignada
 	numEstudiantes := len(estudiantes)
 	consumosPorDia := make(map[int]map[string]map[int]int, numEstudiantes)
+	totalesPorDia := make(map[int]map[string]float64, numEstudiantes)
 	subTotalesPorEstudiante := make(map[int]float64, numEstudiantes)
 
 	for _, c := range consumos {
@@ -55,6 +75,12 @@ func (s *Servicio) ObtenerDatosVistaPrincipal(fechaInicio, fechaFin time.Time, i
 		}
 		consumosPorDia[c.IdEstudiante][fechaKey][c.IdProducto] = c.Cantidad
 
+		// Totales con el precio cobrado, que puede diferir del precio actual
+		if totalesPorDia[c.IdEstudiante] == nil {
+			totalesPorDia[c.IdEstudiante] = make(map[string]float64)
+		}
+		totalesPorDia[c.IdEstudiante][fechaKey] += c.TotalLinea
+
 		// Subtotales
 		subTotalesPorEstudiante[c.IdEstudiante] += c.TotalLinea
 	}
@@ -70,10 +96,21 @@ func (s *Servicio) ObtenerDatosVistaPrincipal(fechaInicio, fechaFin time.Time, i
 		return nil, fmt.Errorf("error al obtener pagos: %v", err)
 	}
 
+	// Cargos fijos de planes con fecha en la semana
+	cargos, err := s.Repo.ObtenerCargosPlanSemana(fechaInicio, fechaFin)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cargos de planes: %v", err)
+	}
+	cargosSemana := make(map[int]float64)
+	for _, c := range cargos {
+		cargosSemana[c.IdEstudiante] += c.Monto
+	}
+
 	// Calcular datos de cada estudiante (sin queries adicionales)
 	estudiantesConData := make([]models.EstudianteConDeuda, 0, len(estudiantes))
 	for _, est := range estudiantes {
-		subTotal := subTotalesPorEstudiante[est.IdEstudiante]
+		cargosPlan := cargosSemana[est.IdEstudiante]
+		subTotal := subTotalesPorEstudiante[est.IdEstudiante] + cargosPlan
 		deudaAnterior := deudasAnteriores[est.IdEstudiante]
 		descuento := pagosSemana[est.IdEstudiante]
 		total := subTotal + deudaAnterior - descuento
@@ -81,6 +118,7 @@ func (s *Servicio) ObtenerDatosVistaPrincipal(fechaInicio, fechaFin time.Time, i
 		estudiantesConData = append(estudiantesConData, models.EstudianteConDeuda{
 			Estudiante:    est,
 			SubTotal:      subTotal,
+			CargosPlan:    cargosPlan,
 			DeudaAnterior: deudaAnterior,
 			Descuento:     descuento,
 			Total:         total,
@@ -100,18 +138,24 @@ func (s *Servicio) ObtenerDatosVistaPrincipal(fechaInicio, fechaFin time.Time, i
 		}
 	}
 
-	// Obtener todos los días y marcar su estado
-	todosDias := utils.ObtenerDiasHabiles(fechaInicio)
+	// Días de atención según el calendario escolar; feriados y vacaciones quedan deshabilitados
+	calendario, err := s.ObtenerCalendario(fechaInicio, fechaFin)
+	if err != nil {
+		return nil, err
+	}
+	todosDias := calendario.DiasAtencion(utils.DiasDeSemana(fechaInicio))
 	diasConEstado := make([]models.DiaConEstado, 0, len(todosDias))
 	diasHabilesFiltrados := make([]time.Time, 0, len(todosDias))
 
 	for _, dia := range todosDias {
 		fechaKey := dia.Format("2006-01-02")
-		esHabil := !mapaDiasDeshabilitados[fechaKey]
+		motivo := calendario.MotivoCierre(dia)
+		esHabil := motivo == "" && !mapaDiasDeshabilitados[fechaKey]
 
 		diasConEstado = append(diasConEstado, models.DiaConEstado{
 			Fecha:   dia,
 			EsHabil: esHabil,
+			Motivo:  motivo,
 		})
 
 		if esHabil {
@@ -119,6 +163,12 @@ func (s *Servicio) ObtenerDatosVistaPrincipal(fechaInicio, fechaFin time.Time, i
 		}
 	}
 
+	// Productos con inventario agotado o bajo el mínimo para el aviso de la vista
+	stockBajo, err := s.Repo.ObtenerProductosStockBajo()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener stock bajo: %v", err)
+	}
+
 	return &models.DatosVistaPrincipal{
 		Semana:             utils.FormatearSemana(fechaInicio, fechaFin),
 		FechaInicio:        fechaInicio,
@@ -128,35 +178,332 @@ func (s *Servicio) ObtenerDatosVistaPrincipal(fechaInicio, fechaFin time.Time, i
 		Productos:          productos,
 		EstudiantesConData: estudiantesConData,
 		ConsumosPorDia:     consumosPorDia,
+		TotalesPorDia:      totalesPorDia,
 		Grados:             utils.ObtenerGradosEstaticos(),
 		GradoSeleccionado:  idGrado,
 		DiasDeshabilitados: diasDeshabilitados,
+		ProductosStockBajo: stockBajo,
 	}, nil
 }
 
+// ValidarCantidadConsumo retorna un error si la cantidad de un producto en un
+// día es negativa o supera el máximo, igual que al guardar el día completo
+func (s *Servicio) ValidarCantidadConsumo(cantidad int) error {
+	if cantidad < 0 {
+		return fmt.Errorf("la cantidad no puede ser negativa")
+	}
+	if cantidad > models.MaxCantidadPorProducto {
+		return fmt.Errorf("máximo %d unidades de un producto por día", models.MaxCantidadPorProducto)
+	}
+	return nil
+}
+
 // RegistrarConsumoDesdeFormulario procesa el registro de un consumo desde el formulario
 func (s *Servicio) RegistrarConsumoDesdeFormulario(idEstudiante, idProducto, cantidad int, fecha time.Time) error {
-	// Obtener el precio actual del producto
-	producto, err := s.Repo.ObtenerProductoPorId(idProducto)
+	if err := s.ValidarCantidadConsumo(cantidad); err != nil {
+		return err
+	}
+
+	// En días sin atención solo se permite anular consumos ya registrados
+	if cantidad > 0 {
+		if err := s.ValidarDiaLaborable(fecha); err != nil {
+			return err
+		}
+	}
+
+	precios, err := s.preciosConsumo(idEstudiante, fecha, []int{idProducto})
 	if err != nil {
-		return fmt.Errorf("producto no encontrado: %v", err)
+		return err
+	}
+
+	// Cantidad previa, para notificar solo si el consumo cambia
+	cantidadAnterior, err := s.Repo.ObtenerConsumoExistente(idEstudiante, idProducto, fecha)
+	if err != nil {
+		return fmt.Errorf("error al obtener consumo: %v", err)
 	}
 
 	// Actualizar o insertar el consumo
-	return s.Repo.ActualizarConsumo(idEstudiante, idProducto, fecha, cantidad, producto.PrecioUnitario)
+	precio := precios[idProducto]
+	if err := s.Repo.ActualizarConsumo(idEstudiante, idProducto, fecha, cantidad, precio); err != nil {
+		return err
+	}
+
+	s.notificarConsumo(idEstudiante, idProducto, fecha, cantidad, cantidadAnterior, precio)
+	return nil
 }
 
-// RegistrarPagoDesdeFormulario procesa el registro de un pago
-func (s *Servicio) RegistrarPagoDesdeFormulario(idEstudiante int, monto float64, fecha time.Time) error {
+// ValidarCantidadesDia convierte y valida las cantidades del formulario de un día
+// (producto → texto del campo). Retorna las cantidades y, por producto, los errores:
+// producto inexistente, texto que no es un entero, negativo o sobre el máximo.
+// Un campo vacío cuenta como 0.
+func (s *Servicio) ValidarCantidadesDia(valores map[int]string) (map[int]int, map[int]string, error) {
+	productos, err := s.Repo.ObtenerTodosProductos()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+	existe := make(map[int]bool, len(productos))
+	for _, p := range productos {
+		existe[p.IdProducto] = true
+	}
+
+	cantidades := make(map[int]int, len(valores))
+	errores := make(map[int]string)
+	for idProducto, valor := range valores {
+		if !existe[idProducto] {
+			errores[idProducto] = fmt.Sprintf("El producto %d no existe", idProducto)
+			continue
+		}
+		valor = strings.TrimSpace(valor)
+		if valor == "" {
+			cantidades[idProducto] = 0
+			continue
+		}
+		cantidad, err := strconv.Atoi(valor)
+		switch {
+		case err != nil:
+			errores[idProducto] = "La cantidad debe ser un número entero"
+		case cantidad < 0:
+			errores[idProducto] = "La cantidad no puede ser negativa"
+		case cantidad > models.MaxCantidadPorProducto:
+			errores[idProducto] = fmt.Sprintf("Máximo %d por día", models.MaxCantidadPorProducto)
+		default:
+			cantidades[idProducto] = cantidad
+		}
+	}
+	return cantidades, errores, nil
+}
+
+// GuardarConsumosDia guarda de una vez las cantidades del formulario de un día
+// (producto → cantidad). version es la del día al abrir el formulario y clave la
+// de idempotencia; ver Repositorio.GuardarConsumosDia para conflictos y repeticiones.
+func (s *Servicio) GuardarConsumosDia(idEstudiante int, fecha time.Time, version, clave, ruta string, cantidades map[int]int) (models.ResultadoConsumosDia, error) {
+	ids := make([]int, 0, len(cantidades))
+	for idProducto := range cantidades {
+		ids = append(ids, idProducto)
+	}
+	sort.Ints(ids)
+	precios, err := s.preciosConsumo(idEstudiante, fecha, ids)
+	if err != nil {
+		return models.ResultadoConsumosDia{}, err
+	}
+
+	lineas := make([]models.LineaConsumoDia, 0, len(ids))
+	for _, idProducto := range ids {
+		lineas = append(lineas, models.LineaConsumoDia{IdProducto: idProducto, Cantidad: cantidades[idProducto], Precio: precios[idProducto]})
+	}
+	resultado, err := s.Repo.GuardarConsumosDia(idEstudiante, fecha, version, clave, ruta, lineas)
+	if err != nil {
+		return resultado, fmt.Errorf("error al guardar consumos: %v", err)
+	}
+	if resultado.Repetida || resultado.Conflicto {
+		return resultado, nil
+	}
+
+	for _, l := range lineas {
+		s.notificarConsumo(idEstudiante, l.IdProducto, fecha, l.Cantidad, resultado.Anteriores[l.IdProducto], l.Precio)
+	}
+	return resultado, nil
+}
+
+// preciosConsumo calcula el precio que se cobra al estudiante por cada producto en
+// la fecha: precio vigente ese día (no el de hoy), sus becas y descuentos, y lo
+// que cubren sus planes de alimentación (sin costo).
+func (s *Servicio) preciosConsumo(idEstudiante int, fecha time.Time, idsProductos []int) (map[int]models.PrecioAplicado, error) {
+	reglas, err := s.Repo.ObtenerReglasVigentes(idEstudiante, fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener descuentos: %v", err)
+	}
+	suscripciones, err := s.Repo.ObtenerSuscripcionesEstudiante(idEstudiante, fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener planes: %v", err)
+	}
+
+	precios := make(map[int]models.PrecioAplicado, len(idsProductos))
+	for _, idProducto := range idsProductos {
+		producto, err := s.Repo.ObtenerProductoPorId(idProducto)
+		if err != nil {
+			return nil, fmt.Errorf("producto no encontrado: %v", err)
+		}
+		precioLista, err := s.Repo.ObtenerPrecioVigente(idProducto, fecha)
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener precio: %v", err)
+		}
+		precios[idProducto] = aplicarPlan(aplicarDescuento(*producto, precioLista, reglas), *producto, suscripciones)
+	}
+	return precios, nil
+}
+
+// notificarConsumo emite el webhook de un consumo si su cantidad cambió
+func (s *Servicio) notificarConsumo(idEstudiante, idProducto int, fecha time.Time, cantidad, cantidadAnterior int, precio models.PrecioAplicado) {
+	if cantidad < 0 {
+		cantidad = 0
+	}
+	if cantidad == cantidadAnterior {
+		return
+	}
+	s.EnVivo.Publicar(envivo.Cambio{Tipo: envivo.CambioConsumo, IdEstudiante: idEstudiante, Fecha: utils.FormatearFechaCompleta(fecha)})
+	evento := models.EventoConsumoModificado
+	if cantidadAnterior == 0 {
+		evento = models.EventoConsumoCreado
+	}
+	s.emitirEvento(evento, datosConsumoWebhook{
+		IdEstudiante:     idEstudiante,
+		IdProducto:       idProducto,
+		Fecha:            utils.FormatearFechaCompleta(fecha),
+		Cantidad:         cantidad,
+		CantidadAnterior: cantidadAnterior,
+		PrecioUnitario:   precio.PrecioVenta(),
+		Total:            precio.PrecioVenta() * float64(cantidad),
+	})
+}
+
+// RegistrarPagoDesdeFormulario procesa el registro de un pago. idUsuario es
+// quien lo registra: un pago en efectivo entra en su caja abierta.
+func (s *Servicio) RegistrarPagoDesdeFormulario(idEstudiante int, monto float64, fecha time.Time, metodo string, idUsuario int) (models.Pago, error) {
 	if monto <= 0 {
-		return fmt.Errorf("el monto debe ser mayor a cero")
+		return models.Pago{}, fmt.Errorf("el monto debe ser mayor a cero")
+	}
+	if !models.MetodoPagoValido(metodo) {
+		return models.Pago{}, fmt.Errorf("método de pago inválido")
 	}
 
 	pago := models.Pago{
 		IdEstudiante: idEstudiante,
 		Monto:        monto,
 		FechaPago:    fecha,
+		Metodo:       metodo,
+		IdUsuario:    idUsuario,
 	}
 
-	return s.Repo.RegistrarPago(pago)
+	id, err := s.Repo.RegistrarPago(pago)
+	if err != nil {
+		return models.Pago{}, err
+	}
+	pago.IdPago = id
+	s.emitirEvento(models.EventoPagoRegistrado, nuevoDatosPagoWebhook(pago))
+	s.publicarCambioPago(pago)
+	s.notificarPago(pago)
+	return pago, nil
+}
+
+// AnularPago elimina un pago y lo notifica a los webhooks.
+// Si el pago ya no existe no hace nada (doble clic, otra pestaña).
+func (s *Servicio) AnularPago(idPago int) error {
+	pago, err := s.Repo.ObtenerPagoPorId(idPago)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.EliminarPago(idPago); err != nil {
+		return err
+	}
+	s.emitirEvento(models.EventoPagoAnulado, nuevoDatosPagoWebhook(pago))
+	s.publicarCambioPago(pago)
+	return nil
+}
+
+// publicarCambioPago avisa a las pantallas abiertas que cambió el saldo de un estudiante
+func (s *Servicio) publicarCambioPago(pago models.Pago) {
+	s.EnVivo.Publicar(envivo.Cambio{Tipo: envivo.CambioPago, IdEstudiante: pago.IdEstudiante, Fecha: utils.FormatearFechaCompleta(pago.FechaPago)})
+}
+
+// ObtenerDatosConsumoSemanal arma el comprobante semanal de un estudiante:
+// consumos y cargos de planes agrupados por día, deuda anterior y pagos.
+// Lo usan la vista del comprobante y el estado de cuenta por correo.
+func (s *Servicio) ObtenerDatosConsumoSemanal(idEstudiante int, fechaInicio, fechaFin time.Time) (*models.DatosConsumoSemanal, error) {
+	var nombreEstudiante string
+	est, err := s.Repo.ObtenerEstudiantePorId(idEstudiante)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error al obtener estudiante: %v", err)
+	}
+	if err == nil {
+		nombreEstudiante = est.Apellidos + ", " + est.Nombres
+	}
+
+	consumos, err := s.Repo.ObtenerConsumosSemana(fechaInicio, fechaFin)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener consumos: %v", err)
+	}
+
+	productos, err := s.Repo.ObtenerTodosProductos()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener productos: %v", err)
+	}
+
+	productosMap := make(map[int]models.Producto)
+	for _, p := range productos {
+		productosMap[p.IdProducto] = p
+	}
+
+	diasConsumo := make(map[string][]models.ConsumoProducto)
+	totalesPorDia := make(map[string]float64)
+
+	for _, c := range consumos {
+		if c.IdEstudiante != idEstudiante {
+			continue
+		}
+		fechaKey := c.FechaConsumo.Format("2006-01-02")
+		producto := productosMap[c.IdProducto]
+		diasConsumo[fechaKey] = append(diasConsumo[fechaKey], models.ConsumoProducto{
+			Nombre:   producto.Nombre,
+			Cantidad: c.Cantidad,
+			Precio:   c.PrecioUnitarioVenta,
+			Total:    c.TotalLinea,
+		})
+		totalesPorDia[fechaKey] += c.TotalLinea
+	}
+
+	// Los cargos fijos de planes aparecen como una línea más en el día del cargo
+	cargos, err := s.Repo.ObtenerCargosPlanSemana(fechaInicio, fechaFin)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cargos de planes: %v", err)
+	}
+	for _, c := range cargos {
+		if c.IdEstudiante != idEstudiante {
+			continue
+		}
+		fechaKey := c.FechaCargo.Format("2006-01-02")
+		diasConsumo[fechaKey] = append(diasConsumo[fechaKey], models.ConsumoProducto{
+			Nombre:   "Plan: " + c.NombrePlan,
+			Cantidad: 1,
+			Precio:   c.Monto,
+			Total:    c.Monto,
+		})
+		totalesPorDia[fechaKey] += c.Monto
+	}
+
+	var consumosPorDia []models.ConsumoDiario
+	currentDate := fechaInicio
+	subTotal := 0.0
+
+	for !currentDate.After(fechaFin) {
+		fechaKey := currentDate.Format("2006-01-02")
+		consumoDia := models.ConsumoDiario{
+			Fecha:     currentDate,
+			Productos: diasConsumo[fechaKey],
+			Total:     totalesPorDia[fechaKey],
+		}
+		if len(consumoDia.Productos) > 0 {
+			consumosPorDia = append(consumosPorDia, consumoDia)
+			subTotal += consumoDia.Total
+		}
+		currentDate = currentDate.AddDate(0, 0, 1)
+	}
+
+	deudaAnterior, _ := s.Repo.ObtenerDeudaAnterior(idEstudiante, fechaInicio)
+	pagos, _ := s.Repo.ObtenerPagosSemana(idEstudiante, fechaInicio, fechaFin)
+
+	return &models.DatosConsumoSemanal{
+		IdEstudiante:     idEstudiante,
+		NombreEstudiante: nombreEstudiante,
+		FechaInicio:      fechaInicio,
+		FechaFin:         fechaFin,
+		ConsumosPorDia:   consumosPorDia,
+		SubTotal:         subTotal,
+		DeudaAnterior:    deudaAnterior,
+		Pagos:            pagos,
+		Total:            subTotal + deudaAnterior - pagos,
+	}, nil
 }
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"kiosco/internal/auth"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+// largoPrefijoToken son los caracteres del token que se guardan para reconocerlo
+const largoPrefijoToken = 12
+
+// CrearTokenAPI genera un token de servicio y retorna su valor en claro.
+// Es la única vez que el valor está disponible: en la base queda solo el hash.
+func (s *Servicio) CrearTokenAPI(nombre, alcance string, expiraEn *time.Time, idUsuario int) (string, error) {
+	nombre = strings.TrimSpace(nombre)
+	if nombre == "" {
+		return "", fmt.Errorf("el nombre es obligatorio")
+	}
+	if alcance != models.AlcanceLectura && alcance != models.AlcanceEdicion {
+		return "", fmt.Errorf("alcance inválido: %s", alcance)
+	}
+	if expiraEn != nil && expiraEn.Before(utils.Hoy()) {
+		return "", fmt.Errorf("la fecha de expiración ya pasó")
+	}
+
+	valor, hash, err := auth.GenerarTokenServicio()
+	if err != nil {
+		return "", fmt.Errorf("error al generar token: %v", err)
+	}
+
+	token := models.TokenAPI{
+		Nombre:   nombre,
+		Prefijo:  valor[:largoPrefijoToken],
+		Alcance:  alcance,
+		ExpiraEn: expiraEn,
+	}
+	if err := s.Repo.InsertarTokenAPI(token, hash, idUsuario); err != nil {
+		return "", fmt.Errorf("error al guardar token: %v", err)
+	}
+	return valor, nil
+}
+
+// VerificarTokenServicio valida un token de servicio presentado como Bearer.
+// Retorna si su alcance permite editar y si es válido; registra el último uso.
+func (s *Servicio) VerificarTokenServicio(valor string) (bool, bool) {
+	if !strings.HasPrefix(valor, auth.PrefijoTokenServicio) {
+		return false, false
+	}
+
+	token, err := s.Repo.ObtenerTokenAPIPorHash(auth.HashTokenServicio(valor))
+	if err != nil || !token.Vigente(utils.Hoy()) {
+		return false, false
+	}
+
+	if err := s.Repo.MarcarUsoTokenAPI(token.IdToken); err != nil {
+		log.Printf("Error al registrar uso del token %d: %v", token.IdToken, err)
+	}
+	return token.PuedeEditar(), true
+}
+
+// ObtenerDatosTokensAPI prepara la lista de tokens para la administración
+func (s *Servicio) ObtenerDatosTokensAPI() (*models.DatosTokensAPI, error) {
+	tokens, err := s.Repo.ObtenerTokensAPI()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tokens: %v", err)
+	}
+	return &models.DatosTokensAPI{Tokens: tokens}, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ValidarVentaContado arma la venta al contado de hoy a partir del formulario
+// (producto → texto de la cantidad) y del efectivo recibido. Solo se venden
+// productos del menú del día, al precio vigente; un recibido vacío cuenta como
+// el monto exacto. Los errores retornados son para mostrar al cajero.
+func (s *Servicio) ValidarVentaContado(valores map[int]string, recibido string) (models.VentaContado, error) {
+	venta := models.VentaContado{Fecha: utils.Hoy()}
+
+	productos, err := s.Repo.ObtenerProductosParaFecha(venta.Fecha)
+	if err != nil {
+		return venta, fmt.Errorf("error al obtener productos: %v", err)
+	}
+	precios, err := s.Repo.ObtenerPreciosVigentes(venta.Fecha)
+	if err != nil {
+		return venta, fmt.Errorf("error al obtener precios: %v", err)
+	}
+	disponibles := make(map[int]bool, len(productos))
+	for _, p := range productos {
+		disponibles[p.IdProducto] = true
+	}
+	for idProducto := range valores {
+		if !disponibles[idProducto] {
+			return venta, fmt.Errorf("el producto %d no está a la venta hoy", idProducto)
+		}
+	}
+
+	// En el orden del menú, como se ve en la pantalla
+	for _, p := range productos {
+		valor := strings.TrimSpace(valores[p.IdProducto])
+		if valor == "" {
+			continue
+		}
+		cantidad, err := strconv.Atoi(valor)
+		switch {
+		case err != nil:
+			return venta, fmt.Errorf("la cantidad de %s debe ser un número entero", p.Nombre)
+		case cantidad < 0:
+			return venta, fmt.Errorf("la cantidad de %s no puede ser negativa", p.Nombre)
+		case cantidad > models.MaxCantidadPorProducto:
+			return venta, fmt.Errorf("máximo %d unidades de %s por venta", models.MaxCantidadPorProducto, p.Nombre)
+		case cantidad == 0:
+			continue
+		}
+		precio := precios[p.IdProducto]
+		venta.Items = append(venta.Items, models.ItemVentaContado{
+			IdProducto:     p.IdProducto,
+			NombreProducto: p.Nombre,
+			Cantidad:       cantidad,
+			PrecioUnitario: precio,
+			TotalLinea:     redondearCentavos(precio * float64(cantidad)),
+		})
+		venta.Total += precio * float64(cantidad)
+	}
+	if len(venta.Items) == 0 {
+		return venta, fmt.Errorf("agregue al menos un producto")
+	}
+	venta.Total = redondearCentavos(venta.Total)
+
+	recibido = strings.ReplaceAll(strings.TrimSpace(recibido), ",", ".")
+	if recibido == "" {
+		venta.Recibido = venta.Total
+		return venta, nil
+	}
+	monto, err := strconv.ParseFloat(recibido, 64)
+	if err != nil || math.IsNaN(monto) || math.IsInf(monto, 0) {
+		return venta, fmt.Errorf("el monto recibido no es válido")
+	}
+	venta.Recibido = redondearCentavos(monto)
+	if venta.Recibido < venta.Total {
+		return venta, fmt.Errorf("el monto recibido (%s) no alcanza para el total (%s)",
+			utils.FormatearMoneda(venta.Recibido), utils.FormatearMoneda(venta.Total))
+	}
+	return venta, nil
+}
+
+// RegistrarVentaContado guarda una venta ya validada y descuenta su stock
+func (s *Servicio) RegistrarVentaContado(venta *models.VentaContado) error {
+	id, err := s.Repo.RegistrarVentaContado(*venta)
+	if err != nil {
+		return fmt.Errorf("error al registrar venta al contado: %v", err)
+	}
+	venta.IdVenta = id
+	return nil
+}
+
+// ValidarAnulacionVentaContado verifica que la venta se pueda anular: solo las
+// de hoy cuya caja sigue abierta, porque una caja cerrada ya congeló su arqueo.
+// Una venta ya anulada pasa (doble clic, otra pestaña).
+func (s *Servicio) ValidarAnulacionVentaContado(idVenta int) error {
+	venta, err := s.Repo.ObtenerVentaContado(idVenta)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("la venta no existe")
+	}
+	if err != nil {
+		return fmt.Errorf("error al obtener venta al contado: %v", err)
+	}
+	if venta.Anulada {
+		return nil
+	}
+	if venta.Fecha.Format("2006-01-02") != utils.Hoy().Format("2006-01-02") {
+		return fmt.Errorf("solo se anulan ventas de hoy y esta es del %s", utils.FormatearFechaLarga(venta.Fecha))
+	}
+	if venta.CajaCerrada {
+		return fmt.Errorf("la caja en que se cobró ya se cerró")
+	}
+	return nil
+}
+
+// AnularVentaContado anula una venta de hoy y devuelve su stock. Si ya estaba
+// anulada, o dejó de ser anulable tras validarla, no hace nada.
+func (s *Servicio) AnularVentaContado(idVenta int) error {
+	if _, err := s.Repo.AnularVentaContado(idVenta, utils.Hoy()); err != nil {
+		return fmt.Errorf("error al anular venta al contado: %v", err)
+	}
+	return nil
+}
+
+// ObtenerDatosVentaContado arma la pantalla de venta al contado de hoy.
+// idVenta, si no es 0, es la venta recién cobrada cuyo vuelto se muestra.
+func (s *Servicio) ObtenerDatosVentaContado(idVenta int, puedeAnular bool) (models.DatosVentaContado, error) {
+	datos := models.DatosVentaContado{Fecha: utils.Hoy(), PuedeAnular: puedeAnular}
+
+	productos, err := s.Repo.ObtenerProductosParaFecha(datos.Fecha)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener productos: %v", err)
+	}
+	precios, err := s.Repo.ObtenerPreciosVigentes(datos.Fecha)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener precios: %v", err)
+	}
+	for i := range productos {
+		productos[i].PrecioUnitario = precios[productos[i].IdProducto]
+	}
+	datos.Productos = productos
+
+	ventas, err := s.Repo.ObtenerVentasContado(datos.Fecha)
+	if err != nil {
+		return datos, fmt.Errorf("error al obtener ventas al contado: %v", err)
+	}
+	datos.Ventas = ventas
+	for i := range ventas {
+		if ventas[i].IdVenta == idVenta {
+			datos.UltimaVenta = &ventas[i]
+		}
+		if !ventas[i].Anulada {
+			datos.TotalDia += ventas[i].Total
+			datos.VentasDia++
+		}
+	}
+	return datos, nil
+}
+
+// redondearCentavos evita arrastrar errores de punto flotante en los montos
+func redondearCentavos(monto float64) float64 {
+	return math.Round(monto*100) / 100
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kiosco/internal/auth"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	intervaloDespacho   = 15 * time.Second
+	loteDespacho        = 20               // Entregas por ciclo del despachador
+	maxIntentosWebhook  = 10               // ~8 horas de reintentos antes de marcar fallido
+	esperaBaseWebhook   = 30 * time.Second // Se duplica en cada intento
+	esperaMaximaWebhook = 2 * time.Hour
+
+	claveSemanaCerrada = "webhooks_semana_cerrada" // Inicio de la última semana notificada
+)
+
+// clienteWebhooks envía las entregas; el timeout evita que un destino lento frene la cola
+var clienteWebhooks = &http.Client{Timeout: 10 * time.Second}
+
+// EventoWebhook es el cuerpo JSON que recibe el destino. id se repite en los
+// reintentos para que el receptor pueda descartar duplicados.
+type EventoWebhook struct {
+	Id       string    `json:"id"`
+	Evento   string    `json:"evento"`
+	CreadoEn time.Time `json:"creado_en"`
+	Datos    any       `json:"datos"`
+}
+
+// datosConsumoWebhook acompaña a consumo.creado y consumo.modificado
+type datosConsumoWebhook struct {
+	IdEstudiante     int     `json:"id_estudiante"`
+	IdProducto       int     `json:"id_producto"`
+	Fecha            string  `json:"fecha"`
+	Cantidad         int     `json:"cantidad"`
+	CantidadAnterior int     `json:"cantidad_anterior"`
+	PrecioUnitario   float64 `json:"precio_unitario"`
+	Total            float64 `json:"total"`
+}
+
+// datosPagoWebhook acompaña a pago.registrado y pago.anulado
+type datosPagoWebhook struct {
+	IdPago       int     `json:"id_pago"`
+	IdEstudiante int     `json:"id_estudiante"`
+	Monto        float64 `json:"monto"`
+	Fecha        string  `json:"fecha"`
+}
+
+// datosSemanaWebhook acompaña a semana.cerrada
+type datosSemanaWebhook struct {
+	Desde               string  `json:"desde"`
+	Hasta               string  `json:"hasta"`
+	TotalConsumos       float64 `json:"total_consumos"` // Incluye cargos de planes
+	TotalCargosPlan     float64 `json:"total_cargos_plan"`
+	TotalPagos          float64 `json:"total_pagos"`
+	Estudiantes         int     `json:"estudiantes"`
+	EstudiantesConDeuda int     `json:"estudiantes_con_deuda"`
+	DeudaTotal          float64 `json:"deuda_total"`
+}
+
+func nuevoDatosPagoWebhook(p models.Pago) datosPagoWebhook {
+	return datosPagoWebhook{
+		IdPago:       p.IdPago,
+		IdEstudiante: p.IdEstudiante,
+		Monto:        p.Monto,
+		Fecha:        utils.FormatearFechaCompleta(p.FechaPago),
+	}
+}
+
+// generarIdAleatorio retorna n bytes aleatorios en hexadecimal
+func generarIdAleatorio(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("webhooks: no se pudo generar id aleatorio: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// CrearWebhook valida y registra un destino. Sin secreto se genera uno aleatorio.
+func (s *Servicio) CrearWebhook(direccion, secreto string, eventos []string) error {
+	u, err := url.Parse(strings.TrimSpace(direccion))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("la URL debe ser http:// o https://")
+	}
+	if len(eventos) == 0 {
+		return fmt.Errorf("seleccione al menos un evento")
+	}
+	for _, e := range eventos {
+		if !slices.Contains(models.EventosWebhook, e) {
+			return fmt.Errorf("evento desconocido: %s", e)
+		}
+	}
+	secreto = strings.TrimSpace(secreto)
+	if secreto == "" {
+		secreto = generarIdAleatorio(32)
+	}
+
+	webhook := models.Webhook{URL: u.String(), Secreto: secreto, Eventos: eventos}
+	if err := s.Repo.InsertarWebhook(webhook); err != nil {
+		return fmt.Errorf("error al guardar webhook: %v", err)
+	}
+	return nil
+}
+
+// ObtenerDatosWebhooks prepara los webhooks y las últimas entregas para la administración
+func (s *Servicio) ObtenerDatosWebhooks(estado string) (*models.DatosWebhooks, error) {
+	webhooks, err := s.Repo.ObtenerWebhooks()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener webhooks: %v", err)
+	}
+	entregas, err := s.Repo.ObtenerEntregasWebhook(estado, 100)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener entregas: %v", err)
+	}
+	return &models.DatosWebhooks{
+		Webhooks: webhooks,
+		Entregas: entregas,
+		Eventos:  models.EventosWebhook,
+		Estado:   estado,
+	}, nil
+}
+
+// ProbarWebhook encola un evento ping solo para el webhook indicado
+func (s *Servicio) ProbarWebhook(idWebhook int) error {
+	cuerpo, idEvento, err := serializarEvento(models.EventoPing, map[string]string{"mensaje": "Prueba desde el kiosco"})
+	if err != nil {
+		return err
+	}
+	return s.Repo.EncolarEntregaWebhook(idWebhook, idEvento, models.EventoPing, cuerpo)
+}
+
+func serializarEvento(evento string, datos any) (string, string, error) {
+	ev := EventoWebhook{
+		Id:       generarIdAleatorio(16),
+		Evento:   evento,
+		CreadoEn: time.Now().UTC().Truncate(time.Second),
+		Datos:    datos,
+	}
+	cuerpo, err := json.Marshal(ev)
+	if err != nil {
+		return "", "", fmt.Errorf("error al serializar evento %s: %v", evento, err)
+	}
+	return string(cuerpo), ev.Id, nil
+}
+
+// emitirEvento deja el evento en la bandeja de salida de cada webhook suscrito.
+// Un error aquí no debe deshacer la operación que lo originó: solo se registra.
+func (s *Servicio) emitirEvento(evento string, datos any) {
+	webhooks, err := s.Repo.ObtenerWebhooksEvento(evento)
+	if err != nil {
+		log.Printf("Error al obtener webhooks para %s: %v", evento, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	cuerpo, idEvento, err := serializarEvento(evento, datos)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	for _, w := range webhooks {
+		if err := s.Repo.EncolarEntregaWebhook(w.IdWebhook, idEvento, evento, cuerpo); err != nil {
+			log.Printf("Error al encolar %s para webhook %d: %v", evento, w.IdWebhook, err)
+		}
+	}
+}
+
+// IniciarDespachoWebhooks arranca la goroutine que envía la bandeja de salida
+// y detecta el cierre de semana. Se detiene al cancelar ctx.
+func (s *Servicio) IniciarDespachoWebhooks(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(intervaloDespacho)
+		defer ticker.Stop()
+		for {
+			s.verificarSemanaCerrada()
+			s.despacharWebhooks(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// despacharWebhooks envía las entregas vencidas y reprograma las que fallan
+func (s *Servicio) despacharWebhooks(ctx context.Context) {
+	entregas, err := s.Repo.ObtenerEntregasPendientes(loteDespacho)
+	if err != nil {
+		log.Printf("Error al obtener entregas pendientes: %v", err)
+		return
+	}
+
+	for _, e := range entregas {
+		if ctx.Err() != nil {
+			return
+		}
+		status, err := enviarEntrega(ctx, e)
+		if err == nil {
+			if err := s.Repo.MarcarEntregaExitosa(e.IdEntrega, status); err != nil {
+				log.Printf("Error al marcar entrega %d: %v", e.IdEntrega, err)
+			}
+			continue
+		}
+
+		espera := 0
+		if e.Intentos+1 < maxIntentosWebhook {
+			espera = int(esperaReintento(e.Intentos + 1).Seconds())
+		} else {
+			log.Printf("⚠️ Webhook %d: entrega %d (%s) fallida tras %d intentos: %v", e.IdWebhook, e.IdEntrega, e.Evento, e.Intentos+1, err)
+		}
+		if err := s.Repo.MarcarEntregaFallida(e.IdEntrega, status, err.Error(), espera); err != nil {
+			log.Printf("Error al marcar entrega %d: %v", e.IdEntrega, err)
+		}
+	}
+}
+
+// esperaReintento duplica la espera en cada intento: 30s, 1m, 2m, ... hasta 2h
+func esperaReintento(intento int) time.Duration {
+	espera := esperaBaseWebhook << (intento - 1)
+	if espera <= 0 || espera > esperaMaximaWebhook {
+		return esperaMaximaWebhook
+	}
+	return espera
+}
+
+// enviarEntrega hace el POST firmado. Cualquier respuesta 2xx cuenta como entregada.
+func enviarEntrega(ctx context.Context, e models.EntregaWebhook) (int, error) {
+	cuerpo := []byte(e.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(cuerpo))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kiosco-webhooks/1")
+	req.Header.Set("X-Kiosco-Evento", e.Evento)
+	req.Header.Set("X-Kiosco-Entrega", e.IdEvento)
+	req.Header.Set("X-Kiosco-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Kiosco-Firma", "sha256="+auth.FirmaWebhook(e.Secreto, timestamp, cuerpo))
+
+	resp, err := clienteWebhooks.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detalle, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(detalle)))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// ultimaSemanaCerrada retorna la semana escolar terminada más reciente
+func ultimaSemanaCerrada() (time.Time, time.Time) {
+	hoy := utils.Hoy()
+	inicio, fin := utils.CalcularSemanaDesdeFecha(hoy)
+	if !fin.Before(hoy) {
+		// La semana actual sigue abierta: la última cerrada es la anterior
+		inicio, fin = utils.CalcularSemanaDesdeFecha(inicio.AddDate(0, 0, -1))
+	}
+	return inicio, fin
+}
+
+// verificarSemanaCerrada emite semana.cerrada una vez por semana escolar terminada.
+// Solo notifica la última semana cerrada, sin recorrer semanas anteriores.
+func (s *Servicio) verificarSemanaCerrada() {
+	webhooks, err := s.Repo.ObtenerWebhooksEvento(models.EventoSemanaCerrada)
+	if err != nil || len(webhooks) == 0 {
+		return
+	}
+
+	inicio, fin := ultimaSemanaCerrada()
+
+	ultima, err := s.Repo.ObtenerConfiguracion(claveSemanaCerrada)
+	if err != nil {
+		log.Printf("Error al leer última semana cerrada: %v", err)
+		return
+	}
+	inicioStr := utils.FormatearFechaCompleta(inicio)
+	if ultima >= inicioStr {
+		return
+	}
+
+	datos, err := s.ObtenerDatosVistaPrincipal(inicio, fin, 0, "")
+	if err != nil {
+		log.Printf("Error al calcular cierre de semana %s: %v", inicioStr, err)
+		return
+	}
+	resumen := datosSemanaWebhook{
+		Desde:       inicioStr,
+		Hasta:       utils.FormatearFechaCompleta(fin),
+		Estudiantes: len(datos.EstudiantesConData),
+	}
+	for _, e := range datos.EstudiantesConData {
+		resumen.TotalConsumos += e.SubTotal
+		resumen.TotalCargosPlan += e.CargosPlan
+		resumen.TotalPagos += e.Descuento
+		if e.Total > 0 {
+			resumen.EstudiantesConDeuda++
+			resumen.DeudaTotal += e.Total
+		}
+	}
+
+	// Guardar primero: ante un error preferimos no repetir el evento
+	if err := s.Repo.GuardarConfiguracion(claveSemanaCerrada, inicioStr); err != nil {
+		log.Printf("Error al guardar última semana cerrada: %v", err)
+		return
+	}
+	s.emitirEvento(models.EventoSemanaCerrada, resumen)
+}
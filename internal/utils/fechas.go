@@ -29,37 +29,9 @@ func ObtenerGradosEstaticos() []models.InfoGrado {
 	}
 }
 
-// ObtenerSemanaActual retorna el lunes y viernes de la semana actual
+// ObtenerSemanaActual retorna el primer y último día de la semana escolar actual
 func ObtenerSemanaActual() (time.Time, time.Time) {
-	ahora := time.Now()
-
-	// Obtener el día de la semana (0 = Domingo, 1 = Lunes, ...)
-	diaSemana := int(ahora.Weekday())
-
-	// Ajustar para que Lunes sea 0
-	if diaSemana == 0 {
-		diaSemana = 7
-	}
-	diaSemana-- // Ahora Lunes = 0, Martes = 1, ..., Domingo = 6
-
-	// Calcular el lunes de esta semana
-	lunes := ahora.AddDate(0, 0, -diaSemana)
-	lunes = time.Date(lunes.Year(), lunes.Month(), lunes.Day(), 0, 0, 0, 0, lunes.Location())
-
-	// Calcular el sábado
-	sabado := lunes.AddDate(0, 0, 5)
-	sabado = time.Date(sabado.Year(), sabado.Month(), sabado.Day(), 23, 59, 59, 0, sabado.Location())
-
-	return lunes, sabado
-}
-
-// ObtenerDiasHabiles retorna los días de lunes a sábado
-func ObtenerDiasHabiles(inicio time.Time) []time.Time {
-	dias := make([]time.Time, 6)
-	for i := 0; i < 6; i++ {
-		dias[i] = inicio.AddDate(0, 0, i)
-	}
-	return dias
+	return CalcularSemanaDesdeFecha(Hoy())
 }
 
 // FormatearSemana retorna el texto de la semana (ej: "27 AL 31 DE OCTUBRE")
@@ -82,19 +54,16 @@ func FormatearSemana(inicio, fin time.Time) string {
 	return fmt.Sprintf("%d DE %s AL %d DE %s", inicio.Day(), mesInicio, fin.Day(), mesFin)
 }
 
-// CalcularSemanaDesdeFecha calcula el lunes y sábado de la semana que contiene una fecha
+// CalcularSemanaDesdeFecha calcula el primer y último día de la semana escolar
+// que contiene una fecha. El fin incluye todo el último día (23:59:59).
 func CalcularSemanaDesdeFecha(fecha time.Time) (time.Time, time.Time) {
-	diaSemana := int(fecha.Weekday())
-	if diaSemana == 0 {
-		diaSemana = 7
-	}
-	diaSemana--
+	diferencia := (int(fecha.Weekday()) - int(semana.DiaInicio) + 7) % 7
 
-	lunes := fecha.AddDate(0, 0, -diaSemana)
-	lunes = time.Date(lunes.Year(), lunes.Month(), lunes.Day(), 0, 0, 0, 0, lunes.Location())
+	inicio := fecha.AddDate(0, 0, -diferencia)
+	inicio = time.Date(inicio.Year(), inicio.Month(), inicio.Day(), 0, 0, 0, 0, inicio.Location())
 
-	sabado := lunes.AddDate(0, 0, 5)
-	sabado = time.Date(sabado.Year(), sabado.Month(), sabado.Day(), 23, 59, 59, 0, sabado.Location())
+	fin := inicio.AddDate(0, 0, semana.Dias-1)
+	fin = time.Date(fin.Year(), fin.Month(), fin.Day(), 23, 59, 59, 0, fin.Location())
 
-	return lunes, sabado
+	return inicio, fin
 }
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConfiguracionSemana define la zona horaria del colegio y la semana escolar
+// usadas en todo el sistema para calcular "hoy" y los rangos semanales.
+//
+// Las fechas sin hora (consumos, pagos, feriados) se representan a medianoche
+// UTC, igual que las que retorna ParsearFecha y las columnas DATE de la base;
+// la zona horaria solo se usa para decidir qué día es hoy.
+type ConfiguracionSemana struct {
+	Zona      *time.Location
+	DiaInicio time.Weekday
+	Dias      int // Cantidad de días de la semana escolar (1 a 7)
+}
+
+var semana = ConfiguracionSemana{Zona: time.Local, DiaInicio: time.Monday, Dias: 6}
+
+// ConfigurarSemana reemplaza la configuración por defecto (zona local, lunes a sábado).
+// Debe llamarse al iniciar, antes de atender solicitudes.
+func ConfigurarSemana(c ConfiguracionSemana) error {
+	if c.Zona == nil {
+		return fmt.Errorf("zona horaria no definida")
+	}
+	if c.DiaInicio < time.Sunday || c.DiaInicio > time.Saturday {
+		return fmt.Errorf("día de inicio inválido: %d", c.DiaInicio)
+	}
+	if c.Dias < 1 || c.Dias > 7 {
+		return fmt.Errorf("la semana debe tener entre 1 y 7 días: %d", c.Dias)
+	}
+	semana = c
+	return nil
+}
+
+// Semana retorna la configuración vigente
+func Semana() ConfiguracionSemana {
+	return semana
+}
+
+// Ahora retorna el instante actual en la zona horaria del colegio
+func Ahora() time.Time {
+	return time.Now().In(semana.Zona)
+}
+
+// Hoy retorna la fecha actual del colegio (a medianoche UTC)
+func Hoy() time.Time {
+	ahora := Ahora()
+	return time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParsearFecha interpreta una fecha "2006-01-02" de formularios y URLs
+func ParsearFecha(valor string) (time.Time, error) {
+	return time.Parse("2006-01-02", valor)
+}
+
+// DiasDeSemana retorna las fechas de la semana escolar que empieza en inicio
+func DiasDeSemana(inicio time.Time) []time.Time {
+	dias := make([]time.Time, semana.Dias)
+	for i := range dias {
+		dias[i] = inicio.AddDate(0, 0, i)
+	}
+	return dias
+}
+
+// DiasSemanaConfigurada retorna los días de la semana escolar en orden (ej. lunes a sábado)
+func DiasSemanaConfigurada() []time.Weekday {
+	dias := make([]time.Weekday, semana.Dias)
+	for i := range dias {
+		dias[i] = (semana.DiaInicio + time.Weekday(i)) % 7
+	}
+	return dias
+}
+
+// NombreDia retorna el nombre en español del día de la semana
+func NombreDia(dia time.Weekday) string {
+	nombres := []string{"Domingo", "Lunes", "Martes", "Miércoles", "Jueves", "Viernes", "Sábado"}
+	return nombres[dia]
+}
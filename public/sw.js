@@ -1,4 +1,4 @@
-const CACHE = 'kiosco-v1';
+const CACHE = 'kiosco-v2';
 
 const ASSETS_ESTATICOS = [
   '/dist/styles.css',
@@ -9,6 +9,10 @@ const ASSETS_ESTATICOS = [
   '/favicon.webp',
 ];
 
+// Formularios de consumos que se guardan en cola si se cae la conexión
+const RUTAS_EN_COLA = ['/guardar-consumos-dia', '/registrar-consumo'];
+const ETIQUETA_SYNC = 'kiosco-consumos';
+
 // Instalar: cachear assets estáticos
 self.addEventListener('install', (e) => {
   e.waitUntil(
@@ -31,11 +35,17 @@ self.addEventListener('activate', (e) => {
 
 // Fetch: cache-first para estáticos
 self.addEventListener('fetch', (e) => {
-  // Solo manejar GET
-  if (e.request.method !== 'GET') return;
-
   const url = new URL(e.request.url);
 
+  // Consumos: si no hay conexión quedan en cola para reenviarse después
+  if (e.request.method === 'POST' && RUTAS_EN_COLA.includes(url.pathname)) {
+    e.respondWith(enviarOEncolar(e.request));
+    return;
+  }
+
+  // Solo manejar GET; el flujo de eventos en vivo va directo a la red
+  if (e.request.method !== 'GET' || url.pathname === '/eventos') return;
+
   // Assets estáticos
   const esEstatico =
     url.pathname.startsWith('/dist/') ||
@@ -65,3 +75,194 @@ self.addEventListener('fetch', (e) => {
     fetch(e.request).catch(() => caches.match(e.request))
   );
 });
+
+// Background Sync (Chrome/Android): reenviar la cola al volver la conexión.
+// Si quedan envíos, fallar hace que el navegador lo reintente más tarde.
+self.addEventListener('sync', (e) => {
+  if (e.tag !== ETIQUETA_SYNC) return;
+  e.waitUntil(
+    sincronizar().then((restantes) => {
+      if (restantes > 0) throw new Error('Envíos pendientes');
+    })
+  );
+});
+
+// Mensajes de las páginas: 'sincronizar' al cargar y al volver la conexión
+// (navegadores sin Background Sync), 'contar' para pedir el contador
+self.addEventListener('message', (e) => {
+  if (e.data === 'sincronizar') e.waitUntil(sincronizar());
+  if (e.data === 'contar') e.waitUntil(avisarPendientes());
+});
+
+// ---------------------------------------------------------------------------
+// Cola de envíos en IndexedDB
+// ---------------------------------------------------------------------------
+function abrirCola() {
+  return new Promise((resolve, reject) => {
+    const req = indexedDB.open('kiosco-sync', 1);
+    req.onupgradeneeded = () => req.result.createObjectStore('envios', { keyPath: 'id', autoIncrement: true });
+    req.onsuccess = () => resolve(req.result);
+    req.onerror = () => reject(req.error);
+  });
+}
+
+function operarCola(modo, operacion) {
+  return abrirCola().then((db) => new Promise((resolve, reject) => {
+    const tx = db.transaction('envios', modo);
+    const req = operacion(tx.objectStore('envios'));
+    tx.oncomplete = () => { db.close(); resolve(req.result); };
+    tx.onerror = () => { db.close(); reject(tx.error); };
+  }));
+}
+
+// diaDeEnvio identifica el día de un estudiante que guarda un envío de
+// /guardar-consumos-dia ("" para los demás envíos)
+function diaDeEnvio(envio) {
+  if (new URL(envio.url).pathname !== '/guardar-consumos-dia') return '';
+  const datos = new URLSearchParams(envio.cuerpo);
+  return `${datos.get('id_estudiante')}|${datos.get('fecha')}`;
+}
+
+// encolar agrega un envío a la cola. El formulario de un día lleva todas sus
+// cantidades, así que un nuevo guardado del mismo estudiante y día reemplaza a
+// los que seguían en cola (corregir sin conexión lo que se acaba de guardar sin
+// conexión). Reenviar los dos haría fallar el segundo con 409, porque ambos
+// llevan la versión previa al primero; se conserva la del más antiguo, que es
+// la que el servidor sigue teniendo.
+async function encolar(envio) {
+  const dia = diaDeEnvio(envio);
+  const db = await abrirCola();
+  return new Promise((resolve, reject) => {
+    const tx = db.transaction('envios', 'readwrite');
+    const store = tx.objectStore('envios');
+    tx.oncomplete = () => { db.close(); resolve(); };
+    tx.onerror = () => { db.close(); reject(tx.error); };
+    if (!dia) {
+      store.add(envio);
+      return;
+    }
+    store.getAll().onsuccess = (e) => {
+      const anteriores = e.target.result.filter((otro) => diaDeEnvio(otro) === dia);
+      if (anteriores.length > 0) {
+        const datos = new URLSearchParams(envio.cuerpo);
+        datos.set('version', new URLSearchParams(anteriores[0].cuerpo).get('version') || '');
+        envio.cuerpo = datos.toString();
+        anteriores.forEach((otro) => store.delete(otro.id));
+      }
+      store.add(envio);
+    };
+  });
+}
+
+const listarCola = () => operarCola('readonly', (store) => store.getAll());
+const contarCola = () => operarCola('readonly', (store) => store.count());
+const quitarDeCola = (id) => operarCola('readwrite', (store) => store.delete(id));
+const actualizarEnCola = (envio) => operarCola('readwrite', (store) => store.put(envio));
+
+// Reenvíos con error del servidor (5xx) antes de descartar un envío
+const MAX_INTENTOS_SERVIDOR = 5;
+
+// avisarPendientes envía a las páginas abiertas el contador de la cola y, si
+// hubo, los envíos que el servidor rechazó al reenviarlos
+async function avisarPendientes(rechazados = []) {
+  const cantidad = await contarCola();
+  const clientes = await self.clients.matchAll({ type: 'window', includeUncontrolled: true });
+  clientes.forEach((c) => c.postMessage({ tipo: 'pendientes', cantidad, rechazados }));
+  return cantidad;
+}
+
+// enviarOEncolar intenta el envío normal; solo si falla la red (no si el servidor
+// responde con error) lo guarda en la cola. El formulario lleva su clave de
+// idempotencia, así que reenviarlo nunca duplica consumos aunque el primer
+// intento sí haya llegado.
+async function enviarOEncolar(request) {
+  const copia = request.clone();
+  try {
+    return await fetch(request);
+  } catch (err) {
+    await encolar({
+      url: copia.url,
+      cuerpo: await copia.text(),
+      tipo: copia.headers.get('Content-Type') || 'application/x-www-form-urlencoded',
+      creado: Date.now(),
+    });
+    if (self.registration.sync) {
+      self.registration.sync.register(ETIQUETA_SYNC).catch(() => {});
+    }
+    return respuestaSinConexion(await avisarPendientes());
+  }
+}
+
+function respuestaSinConexion(cantidad) {
+  const html = `<!DOCTYPE html>
+<html lang="es-PE"><head><meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Guardado sin conexión</title></head>
+<body style="font-family: system-ui, sans-serif; background: #F2F2F7; margin: 0; padding: 80px 24px; text-align: center;">
+<div style="max-width: 400px; margin: 0 auto; background: #fff; border: 1px solid #e5e7eb; border-radius: 24px; padding: 24px;">
+<h1 style="font-size: 22px; margin: 0 0 8px;">Guardado sin conexión</h1>
+<p style="font-size: 15px; color: #4b5563; margin: 0 0 20px;">No hay conexión con el servidor. El registro quedó en este dispositivo y se enviará solo al volver la conexión (${cantidad} pendiente${cantidad === 1 ? '' : 's'}).</p>
+<button onclick="history.back()" style="width: 100%; padding: 12px; border: 0; border-radius: 16px; background: #2563eb; color: #fff; font-size: 16px; font-weight: 700;">Volver</button>
+</div></body></html>`;
+  return new Response(html, { headers: { 'Content-Type': 'text/html; charset=utf-8', 'Cache-Control': 'no-store' } });
+}
+
+// sincronizar reenvía la cola en orden; un solo reenvío a la vez.
+// Retorna cuántos envíos quedan.
+let sincronizando = null;
+function sincronizar() {
+  if (!sincronizando) {
+    sincronizando = reenviarCola().finally(() => { sincronizando = null; });
+  }
+  return sincronizando;
+}
+
+async function reenviarCola() {
+  const rechazados = [];
+  for (const envio of await listarCola()) {
+    let res;
+    try {
+      res = await fetch(envio.url, {
+        method: 'POST',
+        body: envio.cuerpo,
+        headers: { 'Content-Type': envio.tipo },
+        credentials: 'same-origin',
+      });
+    } catch (err) {
+      break; // Sigue sin conexión
+    }
+    if (res.redirected && new URL(res.url).pathname === '/login') {
+      break; // Sesión vencida: se reenvía después de volver a iniciar sesión
+    }
+    if (res.status >= 500) {
+      // Error del servidor: se salta en esta pasada para no trabar los demás y
+      // se reintenta más tarde, hasta MAX_INTENTOS_SERVIDOR veces
+      envio.intentos = (envio.intentos || 0) + 1;
+      if (envio.intentos < MAX_INTENTOS_SERVIDOR) {
+        await actualizarEnCola(envio);
+        continue;
+      }
+      rechazados.push(`el servidor falló ${envio.intentos} veces al recibirlo (${await motivoRechazo(res)}); vuelva a registrarlo`);
+      await quitarDeCola(envio.id);
+      continue;
+    }
+    if (!res.ok) {
+      // Rechazado (día sin atención, datos inválidos, otra edición del mismo día):
+      // reintentarlo no sirve
+      rechazados.push(await motivoRechazo(res));
+    }
+    await quitarDeCola(envio.id);
+  }
+  return avisarPendientes(rechazados);
+}
+
+async function motivoRechazo(res) {
+  if (res.status === 409) {
+    return 'otra persona modificó los consumos de ese día; vuelva a registrarlos';
+  }
+  if (res.status === 422) {
+    return 'hay cantidades inválidas en los consumos de ese día; no se guardó ninguna';
+  }
+  const esTexto = (res.headers.get('Content-Type') || '').startsWith('text/plain');
+  return (esTexto && (await res.text()).trim()) || `Error ${res.status}`;
+}
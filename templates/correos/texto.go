@@ -0,0 +1,100 @@
+// Package correos contiene los mensajes que se envían a los apoderados:
+// la versión HTML en correos.templ y la de texto plano aquí.
+package correos
+
+import (
+	"bytes"
+	"kiosco/internal/models"
+	"kiosco/internal/utils"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// EstadoCuenta son los datos del estado de cuenta semanal de un estudiante
+type EstadoCuenta struct {
+	Apoderado   string
+	Comprobante models.DatosConsumoSemanal
+	URLBaja     string // "" si no hay URL pública configurada
+}
+
+// RecordatorioDeuda son los datos del recordatorio de saldo pendiente
+type RecordatorioDeuda struct {
+	Apoderado  string
+	Estudiante string
+	Saldo      float64
+	FechaCorte time.Time // Último día incluido en el saldo
+	URLBaja    string
+}
+
+// AsuntoEstadoCuenta retorna el asunto del estado de cuenta
+func AsuntoEstadoCuenta(d EstadoCuenta) string {
+	return "Estado de cuenta del kiosco: " + d.Comprobante.NombreEstudiante + ", semana del " + semanaMinusculas(d.Comprobante.FechaInicio, d.Comprobante.FechaFin)
+}
+
+// AsuntoRecordatorio retorna el asunto del recordatorio de deuda
+func AsuntoRecordatorio(d RecordatorioDeuda) string {
+	return "Recordatorio de saldo pendiente en el kiosco: " + d.Estudiante
+}
+
+func semanaMinusculas(inicio, fin time.Time) string {
+	return strings.ToLower(utils.FormatearSemana(inicio, fin))
+}
+
+var plantillasTexto = template.Must(template.New("correos").Funcs(template.FuncMap{
+	"moneda":     utils.FormatearMoneda,
+	"fechaLarga": utils.FormatearFechaLarga,
+	"semana":     semanaMinusculas,
+}).Parse(`
+{{define "pie"}}
+--
+Kiosco escolar. Este es un mensaje automático.
+{{- if .URLBaja}}
+Para dejar de recibir estos correos: {{.URLBaja}}
+{{- end}}
+{{end}}
+
+{{define "estado_cuenta" -}}
+Estimado(a) {{.Apoderado}}:
+
+Le enviamos el estado de cuenta del kiosco de {{.Comprobante.NombreEstudiante}} para la semana del {{semana .Comprobante.FechaInicio .Comprobante.FechaFin}}.
+{{range .Comprobante.ConsumosPorDia}}
+{{fechaLarga .Fecha}}: S/ {{moneda .Total}}
+{{- range .Productos}}
+  {{.Cantidad}} x {{.Nombre}}  S/ {{moneda .Total}}
+{{- end}}
+{{else}}
+No hubo consumos esta semana.
+{{end}}
+Consumo de la semana: S/ {{moneda .Comprobante.SubTotal}}
+Deuda anterior:       S/ {{moneda .Comprobante.DeudaAnterior}}
+Pagos de la semana:  -S/ {{moneda .Comprobante.Pagos}}
+Saldo:                S/ {{moneda .Comprobante.Total}}
+{{template "pie" .}}{{end}}
+
+{{define "recordatorio" -}}
+Estimado(a) {{.Apoderado}}:
+
+Le recordamos que {{.Estudiante}} tiene un saldo pendiente de S/ {{moneda .Saldo}} en el kiosco al {{fechaLarga .FechaCorte}}.
+
+Puede cancelarlo en el kiosco del colegio. Si ya realizó el pago, por favor ignore este mensaje.
+{{template "pie" .}}{{end}}
+`))
+
+// TextoEstadoCuenta retorna la versión de texto plano del estado de cuenta
+func TextoEstadoCuenta(d EstadoCuenta) (string, error) {
+	return ejecutar("estado_cuenta", d)
+}
+
+// TextoRecordatorio retorna la versión de texto plano del recordatorio
+func TextoRecordatorio(d RecordatorioDeuda) (string, error) {
+	return ejecutar("recordatorio", d)
+}
+
+func ejecutar(nombre string, datos any) (string, error) {
+	var buf bytes.Buffer
+	if err := plantillasTexto.ExecuteTemplate(&buf, nombre, datos); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
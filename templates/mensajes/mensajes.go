@@ -0,0 +1,62 @@
+// Package mensajes contiene los textos de WhatsApp/SMS a los apoderados.
+// Son cortos y sin formato: algunos proveedores de SMS cobran por cada 160 caracteres.
+package mensajes
+
+import (
+	"bytes"
+	"kiosco/internal/utils"
+	"text/template"
+	"time"
+)
+
+// RecordatorioDeuda son los datos del recordatorio de saldo pendiente
+type RecordatorioDeuda struct {
+	Estudiante string // Nombres Apellidos
+	Saldo      float64
+	FechaCorte time.Time
+	URLBaja    string // "" si no hay URL pública configurada
+}
+
+// ConfirmacionPago son los datos de la confirmación de un pago registrado
+type ConfirmacionPago struct {
+	Estudiante string
+	Monto      float64
+	Fecha      time.Time
+	Saldo      float64 // Saldo después del pago (negativo = a favor)
+	URLBaja    string
+}
+
+var plantillas = template.Must(template.New("mensajes").Funcs(template.FuncMap{
+	"moneda": utils.FormatearMoneda,
+	"fecha":  func(t time.Time) string { return t.Format("02/01/2006") },
+	"aFavor": func(v float64) float64 { return -v },
+}).Parse(`
+{{define "baja"}}{{if .URLBaja}} Para no recibir más mensajes: {{.URLBaja}}{{end}}{{end}}
+
+{{define "recordatorio" -}}
+Kiosco escolar: {{.Estudiante}} tiene un saldo pendiente de S/ {{moneda .Saldo}} al {{fecha .FechaCorte}}. Si ya pagó, ignore este mensaje.{{template "baja" .}}
+{{- end}}
+
+{{define "pago" -}}
+Kiosco escolar: recibimos S/ {{moneda .Monto}} a cuenta de {{.Estudiante}} el {{fecha .Fecha}}.
+{{- if gt .Saldo 0.0}} Saldo pendiente: S/ {{moneda .Saldo}}.{{else if lt .Saldo 0.0}} Saldo a favor: S/ {{moneda (aFavor .Saldo)}}.{{else}} Sin saldo pendiente.{{end}} Gracias.{{template "baja" .}}
+{{- end}}
+`))
+
+// TextoRecordatorio retorna el texto del recordatorio de deuda
+func TextoRecordatorio(d RecordatorioDeuda) (string, error) {
+	return ejecutar("recordatorio", d)
+}
+
+// TextoConfirmacionPago retorna el texto de la confirmación de pago
+func TextoConfirmacionPago(d ConfirmacionPago) (string, error) {
+	return ejecutar("pago", d)
+}
+
+func ejecutar(nombre string, datos any) (string, error) {
+	var buf bytes.Buffer
+	if err := plantillas.ExecuteTemplate(&buf, nombre, datos); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}